@@ -2,6 +2,7 @@ package configuration
 
 import (
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/akrck02/valhalla-core/log"
@@ -13,6 +14,34 @@ type GlobalConfiguration struct {
 	Port   string
 	Secret string
 	Mongo  string
+
+	// Base url of the links sent by email, like https://valhalla.example.com
+	PublicURL string
+
+	// Role of the previous owner after a team transfer
+	TeamOwnerDemotion string
+
+	// Users must validate their email to create teams, projects and invitations
+	RestrictUnvalidated bool
+
+	// Blob storage driver, local or s3, and its s3 settings
+	Storage     string
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+
+	// Mail driver, file or smtp, and its smtp settings
+	Mail         string
+	MailHost     string
+	MailPort     string
+	MailUser     string
+	MailPassword string
+	MailFrom     string
+
+	// Webhooks can post to loopback and private addresses
+	WebhookPrivateNetworks bool
 }
 
 var Params GlobalConfiguration
@@ -30,8 +59,34 @@ func LoadConfiguration() {
 		Port:   os.Getenv("PORT"),
 		Secret: os.Getenv("SECRET"),
 		Mongo:  os.Getenv("IP_MONGODB"),
+
+		PublicURL: os.Getenv("PUBLIC_URL"),
+
+		TeamOwnerDemotion:   os.Getenv("TEAM_OWNER_DEMOTION_ROLE"),
+		RestrictUnvalidated: os.Getenv("RESTRICT_UNVALIDATED_USERS") == "true",
+
+		Storage:     os.Getenv("STORAGE"),
+		S3Endpoint:  os.Getenv("S3_ENDPOINT"),
+		S3Region:    os.Getenv("S3_REGION"),
+		S3Bucket:    os.Getenv("S3_BUCKET"),
+		S3AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey: os.Getenv("S3_SECRET_KEY"),
+
+		Mail:         os.Getenv("MAIL"),
+		MailHost:     os.Getenv("MAIL_HOST"),
+		MailPort:     os.Getenv("MAIL_PORT"),
+		MailUser:     os.Getenv("MAIL_USER"),
+		MailPassword: os.Getenv("MAIL_PASSWORD"),
+		MailFrom:     os.Getenv("MAIL_FROM"),
+
+		WebhookPrivateNetworks: os.Getenv("WEBHOOK_PRIVATE_NETWORKS") == "true",
+	}
+
+	if configuration.PublicURL == "" {
+		configuration.PublicURL = "https://" + configuration.Ip + ":" + configuration.Port
 	}
 
+	configuration.PublicURL = strings.TrimSuffix(configuration.PublicURL, "/")
 	checkCompulsoryVariables(configuration)
 	Params = configuration
 }
@@ -45,6 +100,12 @@ func checkCompulsoryVariables(Configuration GlobalConfiguration) {
 	log.Info("PORT: " + Configuration.Port)
 	log.Info("SECRET: " + strings.Repeat("*", len(Configuration.Secret)))
 	log.Info("MONGO: " + Configuration.Mongo)
+	log.Info("PUBLIC_URL: " + Configuration.PublicURL)
+	log.Info("TEAM_OWNER_DEMOTION_ROLE: " + Configuration.TeamOwnerDemotion)
+	log.Info("RESTRICT_UNVALIDATED_USERS: " + strconv.FormatBool(Configuration.RestrictUnvalidated))
+	log.Info("STORAGE: " + Configuration.Storage)
+	log.Info("MAIL: " + Configuration.Mail)
+	log.Info("WEBHOOK_PRIVATE_NETWORKS: " + strconv.FormatBool(Configuration.WebhookPrivateNetworks))
 }
 
 func IsDevelopment() bool {
@@ -1,11 +1,12 @@
 package configuration
 
 var (
-	BASE_PATH             = ""
-	ENV_PATH              = ""
-	RESOURCES_PATH        = ""
-	IMAGES_PATH           = ""
-	PROFILE_PICTURES_PATH = ""
+	BASE_PATH      = ""
+	ENV_PATH       = ""
+	RESOURCES_PATH = ""
+	IMAGES_PATH    = ""
+	MEDIA_PATH     = ""
+	MAIL_PATH      = ""
 )
 
 func SetBasePath(path string) int {
@@ -14,7 +15,8 @@ func SetBasePath(path string) int {
 	ENV_PATH = BASE_PATH + "/.env"
 	RESOURCES_PATH = BASE_PATH + "/resources/"
 	IMAGES_PATH = RESOURCES_PATH + "images/"
-	PROFILE_PICTURES_PATH = RESOURCES_PATH + "profile_pictures/"
+	MEDIA_PATH = RESOURCES_PATH + "media/"
+	MAIL_PATH = RESOURCES_PATH + "mail/"
 
 	return 0
 }
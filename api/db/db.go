@@ -6,6 +6,7 @@ import (
 	"github.com/akrck02/valhalla-core/configuration"
 	"github.com/akrck02/valhalla-core/log"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -25,6 +26,24 @@ const TASK = "task"
 const NOTE = "note"
 const WIKI = "wiki"
 const ROLE = "role"
+const SPRINT = "sprint"
+const TASK_DEPENDENCY = "task_dependency"
+const TIME_ENTRY = "time_entry"
+const COMMENT = "comment"
+const NOTIFICATION = "notification"
+const REMINDER = "reminder"
+const SCHEDULER = "scheduler"
+const INVITATION = "invitation"
+const TEAM_TRANSFER = "team_transfer"
+const TEAM_AUDIT = "team_audit"
+const TEAM_JOIN_REQUEST = "team_join_request"
+const MEDIA = "media"
+const PREFERENCES = "preferences"
+const DATA_EXPORT = "data_export"
+const EMAIL_CHANGE = "email_change"
+const MAIL_OUTBOX = "mail_outbox"
+const WEBHOOK = "webhook"
+const WEBHOOK_DELIVERY = "webhook_delivery"
 
 var CurrentDatabase = "valhalla"
 
@@ -65,6 +84,7 @@ func SetupTest() {
 	}
 
 	defer Disconnect(*client, ctx)
+	CreateIndexes(ctx, client)
 }
 
 func Disconnect(client mongo.Client, ctx context.Context) {
@@ -77,5 +97,23 @@ func Setup() {
 	var ctx = Connect(*client)
 
 	defer Disconnect(*client, ctx)
+	CreateIndexes(ctx, client)
+}
+
+// Create the indexes the services rely on, existing ones are kept
+//
+// [param] ctx | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+func CreateIndexes(ctx context.Context, client *mongo.Client) {
+
+	// A user can only have one running timer
+	timeEntries := client.Database(CurrentDatabase).Collection(TIME_ENTRY)
+	_, err := timeEntries.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"user": 1},
+		Options: options.Index().SetName("running_timer").SetUnique(true).SetPartialFilterExpression(bson.M{"end": 0}),
+	})
 
+	if err != nil {
+		log.FormattedError("Cannot create the indexes of ${0}: ${1}", TIME_ENTRY, err.Error())
+	}
 }
@@ -0,0 +1,19 @@
+package error
+
+type Account int
+
+const (
+	EXPORT_NOT_FOUND          = 910
+	EXPORT_PENDING            = 911
+	EXPORT_NOT_READY          = 912
+	EXPORT_NOT_CREATED        = 913
+	DELETION_NOT_SCHEDULED    = 914
+	USER_PENDING_DELETION     = 915
+	EMAIL_CHANGE_NOT_FOUND    = 916
+	INVALID_EMAIL_CHANGE_CODE = 917
+	EMAIL_CHANGE_EXPIRED      = 918
+	EMAIL_CHANGE_NOT_CREATED  = 919
+	VALIDATION_CODE_EXPIRED   = 920
+	VALIDATION_RESEND_LIMIT   = 921
+	USER_NOT_VALIDATED        = 922
+)
@@ -0,0 +1,15 @@
+package error
+
+type Comment int
+
+const (
+	EMPTY_COMMENT          = 870
+	COMMENT_TOO_LONG       = 871
+	COMMENT_NOT_FOUND      = 872
+	INVALID_COMMENT_TARGET = 873
+	COMMENT_DELETED        = 874
+	NOT_COMMENT_AUTHOR     = 875
+	INVALID_COMMENT_PARENT = 876
+	COMMENT_NOT_CREATED    = 877
+	COMMENT_NOT_UPDATED    = 878
+)
@@ -7,4 +7,5 @@ const (
 	ACCESS_DENIED    = 001
 	NOT_IMPLEMENTED  = 002
 	INVALID_REQUEST  = 003
+	INVALID_PAGE     = 004
 )
@@ -0,0 +1,13 @@
+package error
+
+type Image int
+
+const (
+	IMAGE_TOO_LARGE   = 900
+	UNSUPPORTED_IMAGE = 901
+	INVALID_IMAGE     = 902
+	IMAGE_DIMENSIONS  = 903
+	IMAGE_NOT_ENCODED = 904
+	IMAGE_NOT_SAVED   = 905
+	MEDIA_NOT_FOUND   = 906
+)
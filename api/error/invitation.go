@@ -0,0 +1,15 @@
+package error
+
+type Invitation int
+
+const (
+	NO_INVITEE                 = 890
+	INVITATION_NOT_FOUND       = 891
+	INVITATION_EXPIRED         = 892
+	INVITATION_NOT_PENDING     = 893
+	INVALID_INVITATION_TOKEN   = 894
+	NOT_INVITEE                = 895
+	INVITATION_ALREADY_PENDING = 896
+	INVITATION_NOT_CREATED     = 897
+	INVITATION_NOT_UPDATED     = 898
+)
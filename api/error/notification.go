@@ -0,0 +1,8 @@
+package error
+
+type Notification int
+
+const (
+	NOTIFICATION_NOT_FOUND   = 860
+	NOTIFICATION_NOT_UPDATED = 861
+)
@@ -0,0 +1,9 @@
+package error
+
+type Reminder int
+
+const (
+	INVALID_REMINDER     = 880
+	REMINDER_NOT_FOUND   = 881
+	REMINDER_NOT_CREATED = 882
+)
@@ -0,0 +1,15 @@
+package error
+
+type Sprint int
+
+const (
+	SPRINT_NOT_FOUND        = 750
+	INVALID_SPRINT_DATES    = 751
+	SPRINT_ALREADY_EXISTS   = 752
+	SPRINT_NOT_DELETED      = 753
+	TASK_ALREADY_IN_SPRINT  = 754
+	TASK_NOT_IN_SPRINT      = 755
+	INVALID_BURNDOWN_UNIT   = 756
+	TASK_FROM_OTHER_PROJECT = 757
+	SPRINT_NOT_UPDATED      = 758
+)
@@ -0,0 +1,22 @@
+package error
+
+type Task int
+
+const (
+	EMPTY_TASK_NAME        = 800
+	TASK_NOT_FOUND         = 801
+	TASK_NOT_CREATED       = 802
+	TASK_ALREADY_COMPLETED = 803
+	INVALID_TASK_POINTS    = 804
+	TASK_NOT_UPDATED       = 805
+	DEPENDENCY_CYCLE       = 806
+	DEPENDENCY_EXISTS      = 807
+	DEPENDENCY_NOT_FOUND   = 808
+	TASK_BLOCKED           = 809
+	SELF_DEPENDENCY        = 810
+	UNLINKED_PROJECTS      = 811
+	INVALID_TASK_DURATION  = 812
+	INVALID_RECURRENCE     = 813
+	MISSING_DUE_DATE       = 814
+	INVALID_ASSIGNEE       = 815
+)
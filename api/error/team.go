@@ -3,23 +3,41 @@ package error
 type Team int
 
 const (
-	NO_PERMISSION          = 630
-	TEAM_ALREADY_EXISTS    = 631
-	EMPTY_TEAM_NAME        = 632
-	NO_OWNER               = 633
-	OWNER_DOESNT_EXIST     = 634
-	BAD_OBJECT_ID          = 635
-	UPDATE_ERROR           = 636
-	TEAM_NOT_FOUND         = 637
-	SHORT_NAME             = 638
-	LONG_NAME              = 639
-	SHORT_DESCRIPTION      = 640
-	LONG_DESCRIPTION       = 641
-	EMPTY_TEAM_DESCRIPTION = 642
-	NO_MEMBER              = 643
-	NO_TEAM                = 644
-	NO_PROJECT             = 645
-	USER_IS_OWNER          = 646
-	USER_ALREADY_MEMBER    = 647
-	TEAM_SEARCH_ERROR      = 648
+	NO_PERMISSION            = 630
+	TEAM_ALREADY_EXISTS      = 631
+	EMPTY_TEAM_NAME          = 632
+	NO_OWNER                 = 633
+	OWNER_DOESNT_EXIST       = 634
+	BAD_OBJECT_ID            = 635
+	UPDATE_ERROR             = 636
+	TEAM_NOT_FOUND           = 637
+	SHORT_NAME               = 638
+	LONG_NAME                = 639
+	SHORT_DESCRIPTION        = 640
+	LONG_DESCRIPTION         = 641
+	EMPTY_TEAM_DESCRIPTION   = 642
+	NO_MEMBER                = 643
+	NO_TEAM                  = 644
+	NO_PROJECT               = 645
+	USER_IS_OWNER            = 646
+	USER_ALREADY_MEMBER      = 647
+	TEAM_SEARCH_ERROR        = 648
+	NOT_A_MEMBER             = 649
+	OWNER_CANNOT_LEAVE       = 650
+	INVALID_TEAM_ROLE        = 651
+	TRANSFER_NOT_FOUND       = 652
+	TRANSFER_EXPIRED         = 653
+	TRANSFER_NOT_PENDING     = 654
+	TRANSFER_PENDING         = 655
+	NOT_TRANSFER_RECIPIENT   = 656
+	TRANSFER_NOT_UPDATED     = 657
+	INVALID_TEAM_PARENT      = 658
+	TEAM_HAS_CHILDREN        = 659
+	INVALID_TEAM_VISIBILITY  = 660
+	TEAM_NOT_JOINABLE        = 661
+	JOIN_REQUEST_NOT_FOUND   = 662
+	JOIN_REQUEST_NOT_PENDING = 663
+	JOIN_REQUEST_PENDING     = 664
+	JOIN_REQUEST_NOT_CREATED = 665
+	JOIN_REQUEST_NOT_UPDATED = 666
 )
@@ -0,0 +1,12 @@
+package error
+
+type Time int
+
+const (
+	TIMER_ALREADY_RUNNING   = 850
+	NO_RUNNING_TIMER        = 851
+	INVALID_TIME_RANGE      = 852
+	TIME_ENTRY_NOT_FOUND    = 853
+	INVALID_TIMESHEET_GROUP = 854
+	TIME_ENTRY_NOT_CREATED  = 855
+)
@@ -25,4 +25,11 @@ const (
 	CANNOT_CREATE_VALIDATION_CODE  = 619
 	INVALID_VALIDATION_CODE        = 620
 	USER_ALREADY_VALIDATED         = 621
+	USER_SEARCH_ERROR              = 622
+	INVALID_LOCALE                 = 623
+	INVALID_TIMEZONE               = 624
+	INVALID_THEME                  = 625
+	INVALID_DATE_FORMAT            = 626
+	INVALID_DEFAULT_TEAM           = 627
+	PREFERENCES_NOT_UPDATED        = 628
 )
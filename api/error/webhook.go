@@ -0,0 +1,14 @@
+package error
+
+type Webhook int
+
+const (
+	WEBHOOK_NOT_FOUND      = 930
+	INVALID_WEBHOOK_URL    = 931
+	INVALID_WEBHOOK_EVENTS = 932
+	INVALID_WEBHOOK_TARGET = 933
+	WEBHOOK_NOT_CREATED    = 934
+	WEBHOOK_NOT_UPDATED    = 935
+	DELIVERY_NOT_FOUND     = 936
+	WEBHOOK_DISABLED       = 937
+)
@@ -0,0 +1,79 @@
+package events
+
+const (
+	EVENT_RESET               = "reset"
+	EVENT_NOTIFICATION        = "notification"
+	EVENT_TEAM_UPDATED        = "team.updated"
+	EVENT_TEAM_MEMBER_ADDED   = "team.member_added"
+	EVENT_TEAM_MEMBER_REMOVED = "team.member_removed"
+	EVENT_TASK_CREATED        = "task.created"
+	EVENT_TASK_UPDATED        = "task.updated"
+)
+
+// Domain event pushed to the users allowed to see it,
+// the hub publishing it sets its id and date
+type Event struct {
+	ID    string      `json:"id"`
+	Type  string      `json:"type"`
+	Data  interface{} `json:"data"`
+	Date  int64       `json:"date"`
+	Users []string    `json:"-"`
+}
+
+// Get if a user can see an event
+//
+// [param] user | string: id of the user
+//
+// [return] bool: true if the user is on the audience of the event
+func (e *Event) VisibleTo(user string) bool {
+
+	for _, candidate := range e.Users {
+		if candidate == user {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Events of a user since the subscription, the channel is closed
+// when the hub drops the subscriber. Missed are the events after
+// the last event id given, Reset is true when they cannot be known
+// and the client must load everything again
+type Subscription struct {
+	User   string
+	Events chan *Event
+	Missed []*Event
+	Reset  bool
+	Latest string
+}
+
+// Publish and subscribe to events, the in-process hub can
+// be replaced by one backed by a broker setting Current
+type Hub interface {
+	Publish(event *Event)
+	Subscribe(user string, lastEventID string) *Subscription
+	Unsubscribe(subscription *Subscription)
+}
+
+// Events kept to resume the streams of reconnecting clients
+const HISTORY_SIZE = 1000
+
+// Events waiting to be sent to a subscriber before it is dropped
+const SUBSCRIBER_BUFFER = 64
+
+var Current Hub = NewMemoryHub(HISTORY_SIZE, SUBSCRIBER_BUFFER)
+
+// Publish an event on the current hub
+//
+// [param] kind | string: type of the event
+// [param] data | interface{}: content of the event
+// [param] users | []string: ids of the users allowed to see the event
+func Publish(kind string, data interface{}, users []string) {
+
+	if len(users) == 0 {
+		return
+	}
+
+	Current.Publish(&Event{Type: kind, Data: data, Users: users})
+}
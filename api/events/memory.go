@@ -0,0 +1,144 @@
+package events
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Hub of a single process, events are lost on restart so
+// their ids start with the start time of the hub to know
+// when a client resumes from another run
+type MemoryHub struct {
+	mutex       sync.Mutex
+	epoch       string
+	sequence    uint64
+	history     []*Event
+	historySize int
+	buffer      int
+	subscribers map[*Subscription]bool
+}
+
+// Create an in-process hub
+//
+// [param] historySize | int: events kept to resume streams
+// [param] buffer | int: events waiting on a subscriber before it is dropped
+//
+// [return] *MemoryHub: the hub
+func NewMemoryHub(historySize int, buffer int) *MemoryHub {
+	return &MemoryHub{
+		epoch:       strconv.FormatInt(time.Now().UnixMilli(), 36),
+		historySize: historySize,
+		buffer:      buffer,
+		subscribers: map[*Subscription]bool{},
+	}
+}
+
+// Publish an event to the subscribers allowed to see it, slow
+// subscribers are dropped and resume when they reconnect
+//
+// [param] event | *Event: event to publish
+func (h *MemoryHub) Publish(event *Event) {
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.sequence++
+	event.ID = h.epoch + "-" + strconv.FormatUint(h.sequence, 10)
+	event.Date = time.Now().UnixMilli()
+
+	h.history = append(h.history, event)
+	if len(h.history) > h.historySize {
+		h.history = h.history[len(h.history)-h.historySize:]
+	}
+
+	for subscription := range h.subscribers {
+		if !event.VisibleTo(subscription.User) {
+			continue
+		}
+
+		select {
+		case subscription.Events <- event:
+		default:
+			delete(h.subscribers, subscription)
+			close(subscription.Events)
+		}
+	}
+}
+
+// Subscribe a user to the events, resuming after an event
+//
+// [param] user | string: id of the user
+// [param] lastEventID | string: id of the last event received, empty for none
+//
+// [return] *Subscription: the subscription
+func (h *MemoryHub) Subscribe(user string, lastEventID string) *Subscription {
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	subscription := &Subscription{
+		User:   user,
+		Events: make(chan *Event, h.buffer),
+	}
+
+	if len(h.history) > 0 {
+		subscription.Latest = h.history[len(h.history)-1].ID
+	}
+
+	if lastEventID != "" {
+		subscription.Missed, subscription.Reset = h.since(user, lastEventID)
+	}
+
+	h.subscribers[subscription] = true
+	return subscription
+}
+
+// Stop sending events to a subscription
+//
+// [param] subscription | *Subscription: subscription to stop
+func (h *MemoryHub) Unsubscribe(subscription *Subscription) {
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.subscribers[subscription] {
+		delete(h.subscribers, subscription)
+		close(subscription.Events)
+	}
+}
+
+// Get the events of a user after an event of the history
+//
+// [param] user | string: id of the user
+// [param] lastEventID | string: id of the last event received
+//
+// [return] []*Event: events after it --> bool: true if the history does not reach it
+func (h *MemoryHub) since(user string, lastEventID string) ([]*Event, bool) {
+
+	separator := strings.LastIndex(lastEventID, "-")
+	if separator == -1 || lastEventID[:separator] != h.epoch {
+		return nil, true
+	}
+
+	last, err := strconv.ParseUint(lastEventID[separator+1:], 10, 64)
+	if err != nil || last > h.sequence {
+		return nil, true
+	}
+
+	// The event after the last one must still be on the history
+	first := h.sequence - uint64(len(h.history)) + 1
+	if last+1 < first {
+		return nil, true
+	}
+
+	missed := []*Event{}
+	for _, event := range h.history[last+1-first:] {
+		if event.VisibleTo(user) {
+			missed = append(missed, event)
+		}
+	}
+
+	return missed, false
+}
@@ -0,0 +1,179 @@
+package imaging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"unicode"
+
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+)
+
+const CONTENT_TYPE_SVG = "image/svg+xml"
+
+// Cells of an identicon side, the left half is mirrored
+const IDENTICON_CELLS = 5
+
+// Get the colour of an avatar, the same seed always has the same colour.
+// The hue comes from the hash, saturation and lightness keep white text readable
+//
+// [param] seed | string: name or id the avatar belongs to
+//
+// [return] color.RGBA: colour of the avatar
+func AvatarColor(seed string) color.RGBA {
+
+	hash := sha256.Sum256([]byte(seed))
+	hue := float64(int(hash[0])<<8|int(hash[1])) / 65536 * 360
+
+	return hslToRGB(hue, 0.55, 0.45)
+}
+
+// Get the initials of a name, the first letter of its first two words
+//
+// [param] name | string: name to get the initials of
+//
+// [return] string: uppercase initials, ? if the name has no letters
+func Initials(name string) string {
+
+	initials := ""
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		initials += string(unicode.ToUpper([]rune(word)[0]))
+
+		if len([]rune(initials)) == 2 {
+			break
+		}
+	}
+
+	if initials == "" {
+		return "?"
+	}
+
+	return initials
+}
+
+// Generate a square svg avatar with the initials of a name
+// on the colour of the name
+//
+// [param] name | string: name of the user or team
+// [param] size | int: side of the avatar
+//
+// [return] []byte: svg avatar
+func InitialsSVG(name string, size int) []byte {
+
+	background := AvatarColor(name)
+
+	return []byte(fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 100 100">`+
+			`<rect width="100" height="100" fill="#%02x%02x%02x"/>`+
+			`<text x="50" y="50" dy=".35em" text-anchor="middle" font-family="sans-serif" font-size="42" fill="#ffffff">%s</text>`+
+			`</svg>`,
+		size, size, background.R, background.G, background.B, html.EscapeString(Initials(name)),
+	))
+}
+
+// Generate a square png identicon, a symmetric grid of cells
+// lit by the hash of the seed on the colour of the seed
+//
+// [param] seed | string: name or id the avatar belongs to
+// [param] size | int: side of the avatar
+//
+// [return] []byte: png avatar --> *models.Error: error if any
+func IdenticonPNG(seed string, size int) ([]byte, *models.Error) {
+
+	hash := sha256.Sum256([]byte(seed))
+	foreground := AvatarColor(seed)
+	background := color.RGBA{R: 240, G: 240, B: 240, A: 255}
+
+	// Cells of the left half and the middle column
+	half := (IDENTICON_CELLS + 1) / 2
+	lit := make([][]bool, IDENTICON_CELLS)
+
+	for row := 0; row < IDENTICON_CELLS; row++ {
+		lit[row] = make([]bool, IDENTICON_CELLS)
+
+		for column := 0; column < half; column++ {
+			bit := hash[2+row*half+column]%2 == 0
+			lit[row][column] = bit
+			lit[row][IDENTICON_CELLS-1-column] = bit
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if lit[y*IDENTICON_CELLS/size][x*IDENTICON_CELLS/size] {
+				img.SetRGBA(x, y, foreground)
+			} else {
+				img.SetRGBA(x, y, background)
+			}
+		}
+	}
+
+	var buffer bytes.Buffer
+	if png.Encode(&buffer, img) != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.IMAGE_NOT_ENCODED),
+			Message: "Image not encoded",
+		}
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Convert a colour from hsl to rgb
+//
+// [param] hue | float64: hue in degrees
+// [param] saturation | float64: saturation from 0 to 1
+// [param] lightness | float64: lightness from 0 to 1
+//
+// [return] color.RGBA: opaque rgb colour
+func hslToRGB(hue float64, saturation float64, lightness float64) color.RGBA {
+
+	chroma := (1 - abs(2*lightness-1)) * saturation
+	sector := hue / 60
+	x := chroma * (1 - abs(mod2(sector)-1))
+
+	var r, g, b float64
+	switch int(sector) {
+	case 0:
+		r, g, b = chroma, x, 0
+	case 1:
+		r, g, b = x, chroma, 0
+	case 2:
+		r, g, b = 0, chroma, x
+	case 3:
+		r, g, b = 0, x, chroma
+	case 4:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+
+	m := lightness - chroma/2
+	return color.RGBA{
+		R: uint8((r + m) * 255),
+		G: uint8((g + m) * 255),
+		B: uint8((b + m) * 255),
+		A: 255,
+	}
+}
+
+func abs(value float64) float64 {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
+
+func mod2(value float64) float64 {
+	return value - 2*float64(int(value/2))
+}
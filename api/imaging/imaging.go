@@ -0,0 +1,299 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	_ "image/gif"
+
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+)
+
+// Maximum size of an uploaded image
+const MAX_IMAGE_BYTES = 5 * 1024 * 1024
+
+// Minimum and maximum width and height of an uploaded image
+const MIN_IMAGE_DIMENSION = 32
+const MAX_IMAGE_DIMENSION = 4096
+
+// Side of the stored image, bigger images are scaled down
+const MAX_STORED_DIMENSION = 1024
+
+// Quality of the re-encoded jpeg images
+const JPEG_QUALITY = 85
+
+const (
+	CONTENT_TYPE_JPEG = "image/jpeg"
+	CONTENT_TYPE_PNG  = "image/png"
+	CONTENT_TYPE_GIF  = "image/gif"
+)
+
+// Sides of the square thumbnails generated for every image
+var THUMBNAIL_SIZES = []int{32, 64, 128, 256}
+
+// A processed image, re-encoded without metadata
+// and with its thumbnails by size
+type Image struct {
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+	Original    []byte
+	Thumbnails  map[int][]byte
+}
+
+// Process an uploaded image: sniff its type, check its size and dimensions,
+// re-encode it dropping any EXIF or other metadata and generate the thumbnails.
+// Images with transparency are stored as png, the rest as jpeg.
+//
+// [param] data | []byte: uploaded image
+//
+// [return] *Image: processed image --> *models.Error: error if the image is not valid
+func Process(data []byte) (*Image, *models.Error) {
+
+	if len(data) > MAX_IMAGE_BYTES {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_REQUEST_ENTITY_TOO_LARGE,
+			Error:   int(error.IMAGE_TOO_LARGE),
+			Message: "Images cannot be bigger than " + utils.Int2String(MAX_IMAGE_BYTES/1024/1024) + "MB",
+		}
+	}
+
+	// The content type is sniffed, never taken from the request
+	contentType := http.DetectContentType(data)
+	if contentType != CONTENT_TYPE_JPEG && contentType != CONTENT_TYPE_PNG && contentType != CONTENT_TYPE_GIF {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_UNSUPPORTED_MEDIA_TYPE,
+			Error:   int(error.UNSUPPORTED_IMAGE),
+			Message: "Images must be jpeg, png or gif",
+		}
+	}
+
+	invalid := &models.Error{
+		Status:  utils.HTTP_STATUS_BAD_REQUEST,
+		Error:   int(error.INVALID_IMAGE),
+		Message: "Invalid image",
+	}
+
+	// Dimensions are checked before decoding the whole image
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, invalid
+	}
+
+	dimensionsErr := checkDimensions(config.Width, config.Height)
+	if dimensionsErr != nil {
+		return nil, dimensionsErr
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, invalid
+	}
+
+	rgba := toRGBA(decoded)
+	opaque := rgba.Opaque()
+
+	stored := rgba
+	if rgba.Bounds().Dx() > MAX_STORED_DIMENSION || rgba.Bounds().Dy() > MAX_STORED_DIMENSION {
+		width, height := fit(rgba.Bounds().Dx(), rgba.Bounds().Dy(), MAX_STORED_DIMENSION)
+		stored = Resize(rgba, width, height)
+	}
+
+	processed := &Image{
+		ContentType: CONTENT_TYPE_PNG,
+		Extension:   ".png",
+		Width:       stored.Bounds().Dx(),
+		Height:      stored.Bounds().Dy(),
+		Thumbnails:  map[int][]byte{},
+	}
+
+	if opaque {
+		processed.ContentType = CONTENT_TYPE_JPEG
+		processed.Extension = ".jpg"
+	}
+
+	original, encodeErr := encode(stored, opaque)
+	if encodeErr != nil {
+		return nil, encodeErr
+	}
+
+	processed.Original = original
+
+	square := CropSquare(rgba)
+	for _, size := range THUMBNAIL_SIZES {
+		thumbnail, encodeErr := encode(Resize(square, size, size), opaque)
+
+		if encodeErr != nil {
+			return nil, encodeErr
+		}
+
+		processed.Thumbnails[size] = thumbnail
+	}
+
+	return processed, nil
+}
+
+// Crop the centered square of an image
+//
+// [param] img | *image.RGBA: image to crop
+//
+// [return] *image.RGBA: centered square
+func CropSquare(img *image.RGBA) *image.RGBA {
+
+	bounds := img.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	square := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), img, image.Point{X: x, Y: y}, draw.Src)
+	return square
+}
+
+// Resize an image averaging the source pixels covered by every
+// destination pixel, smooth when scaling down as avatars do
+//
+// [param] img | *image.RGBA: image to resize
+// [param] width | int: width of the resized image
+// [param] height | int: height of the resized image
+//
+// [return] *image.RGBA: resized image
+func Resize(img *image.RGBA, width int, height int) *image.RGBA {
+
+	bounds := img.Bounds()
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		fromY := bounds.Min.Y + y*bounds.Dy()/height
+		toY := bounds.Min.Y + (y+1)*bounds.Dy()/height
+		if toY <= fromY {
+			toY = fromY + 1
+		}
+
+		for x := 0; x < width; x++ {
+			fromX := bounds.Min.X + x*bounds.Dx()/width
+			toX := bounds.Min.X + (x+1)*bounds.Dx()/width
+			if toX <= fromX {
+				toX = fromX + 1
+			}
+
+			var r, g, b, a, count uint32
+			for sy := fromY; sy < toY; sy++ {
+				for sx := fromX; sx < toX; sx++ {
+					pixel := img.RGBAAt(sx, sy)
+					r += uint32(pixel.R)
+					g += uint32(pixel.G)
+					b += uint32(pixel.B)
+					a += uint32(pixel.A)
+					count++
+				}
+			}
+
+			resized.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / count),
+				G: uint8(g / count),
+				B: uint8(b / count),
+				A: uint8(a / count),
+			})
+		}
+	}
+
+	return resized
+}
+
+// Check the width and height of an image
+//
+// [param] width | int: width of the image
+// [param] height | int: height of the image
+//
+// [return] *models.Error: error if the dimensions are not allowed
+func checkDimensions(width int, height int) *models.Error {
+
+	if width < MIN_IMAGE_DIMENSION || height < MIN_IMAGE_DIMENSION || width > MAX_IMAGE_DIMENSION || height > MAX_IMAGE_DIMENSION {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.IMAGE_DIMENSIONS),
+			Message: "Images must be between " + utils.Int2String(MIN_IMAGE_DIMENSION) + " and " + utils.Int2String(MAX_IMAGE_DIMENSION) + " pixels wide and high",
+		}
+	}
+
+	return nil
+}
+
+// Get the dimensions of an image scaled to fit a square
+//
+// [param] width | int: width of the image
+// [param] height | int: height of the image
+// [param] side | int: side of the square
+//
+// [return] int: scaled width --> int: scaled height
+func fit(width int, height int, side int) (int, int) {
+
+	if width >= height {
+		return side, max(1, height*side/width)
+	}
+
+	return max(1, width*side/height), side
+}
+
+func max(a int, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+// Draw any decoded image on a rgba canvas, this
+// also applies the palette of gif and paletted png images
+//
+// [param] img | image.Image: decoded image
+//
+// [return] *image.RGBA: rgba image
+func toRGBA(img image.Image) *image.RGBA {
+
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// Encode an image, opaque images as jpeg and the rest as png
+//
+// [param] img | *image.RGBA: image to encode
+// [param] opaque | bool: true if the image has no transparency
+//
+// [return] []byte: encoded image --> *models.Error: error if any
+func encode(img *image.RGBA, opaque bool) ([]byte, *models.Error) {
+
+	var buffer bytes.Buffer
+	var failed bool
+
+	if opaque {
+		failed = jpeg.Encode(&buffer, img, &jpeg.Options{Quality: JPEG_QUALITY}) != nil
+	} else {
+		failed = png.Encode(&buffer, img) != nil
+	}
+
+	if failed {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.IMAGE_NOT_ENCODED),
+			Message: "Image not encoded",
+		}
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,161 @@
+package lang
+
+import (
+	"embed"
+	"encoding/json"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locale of the messages when no other is supported
+const DEFAULT_LOCALE = "en"
+
+// Message catalogs by locale on catalogs/{locale}.json, keys are
+// error.{code} for error messages and mail.{key} for email texts.
+// Messages take ${0} placeholders like Format
+//
+//go:embed catalogs
+var catalogFiles embed.FS
+
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+
+	loaded := map[string]map[string]string{}
+	files, err := catalogFiles.ReadDir("catalogs")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, file := range files {
+		content, err := catalogFiles.ReadFile("catalogs/" + file.Name())
+		if err != nil {
+			panic(err)
+		}
+
+		messages := map[string]string{}
+		err = json.Unmarshal(content, &messages)
+		if err != nil {
+			panic("Malformed catalog " + file.Name() + ": " + err.Error())
+		}
+
+		loaded[strings.TrimSuffix(file.Name(), path.Ext(file.Name()))] = messages
+	}
+
+	return loaded
+}
+
+// Get the locales with a catalog
+//
+// [return] []string: supported locales, sorted
+func Locales() []string {
+
+	locales := []string{}
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+
+	sort.Strings(locales)
+	return locales
+}
+
+// Get the locales a message is looked for on, the locale
+// itself, its language and then the default locale
+//
+// [param] locale | string: locale like es-ES or es
+//
+// [return] []string: fallback chain of the locale
+func Fallbacks(locale string) []string {
+
+	chain := []string{}
+	add := func(candidate string) {
+		for _, added := range chain {
+			if added == candidate {
+				return
+			}
+		}
+
+		chain = append(chain, candidate)
+	}
+
+	if locale != "" {
+		add(locale)
+
+		if separator := strings.Index(locale, "-"); separator > 0 {
+			add(locale[:separator])
+		}
+	}
+
+	add(DEFAULT_LOCALE)
+	return chain
+}
+
+// Get the first locale of the fallback chain with a catalog
+//
+// [param] locale | string: requested locale
+//
+// [return] string: supported locale
+func Resolve(locale string) string {
+
+	for _, candidate := range Fallbacks(locale) {
+		if _, found := catalogs[candidate]; found {
+			return candidate
+		}
+	}
+
+	return DEFAULT_LOCALE
+}
+
+// Look a message up on the fallback chain of a locale
+//
+// [param] locale | string: requested locale
+// [param] key | string: key of the message
+//
+// [return] string: the message --> bool: true if found
+func Lookup(locale string, key string) (string, bool) {
+
+	for _, candidate := range Fallbacks(locale) {
+		if message, found := catalogs[candidate][key]; found {
+			return message, true
+		}
+	}
+
+	return "", false
+}
+
+// Translate a message to a locale
+//
+// [param] locale | string: requested locale
+// [param] key | string: key of the message
+// [param] args | ...string: values of the placeholders
+//
+// [return] string: the message, or the key if no catalog has it
+func Translate(locale string, key string, args ...string) string {
+
+	message, found := Lookup(locale, key)
+	if !found {
+		return key
+	}
+
+	return Format(message, args...)
+}
+
+// Translate an error message, the English messages are
+// written by the services so they are the last fallback
+//
+// [param] locale | string: requested locale
+// [param] code | int: internal error code
+// [param] message | string: message written by the service
+//
+// [return] string: the translated message
+func ErrorMessage(locale string, code int, message string) string {
+
+	translated, found := Lookup(locale, "error."+strconv.Itoa(code))
+	if !found {
+		return message
+	}
+
+	return translated
+}
@@ -0,0 +1,75 @@
+package lang
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Choose the locale of a request from its Accept-Language
+// header, the most preferred language with a catalog wins
+//
+// [param] header | string: Accept-Language header, like es-ES,es;q=0.9,en;q=0.8
+//
+// [return] string: supported locale, the default if none is
+func Negotiate(header string) string {
+
+	type weighted struct {
+		locale string
+		weight float64
+	}
+
+	languages := []weighted{}
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		locale := canonicalLocale(fields[0])
+
+		if locale == "" || locale == "*" {
+			continue
+		}
+
+		weight := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				parsed, err := strconv.ParseFloat(param[2:], 64)
+				if err == nil {
+					weight = parsed
+				}
+			}
+		}
+
+		if weight > 0 {
+			languages = append(languages, weighted{locale, weight})
+		}
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].weight > languages[j].weight
+	})
+
+	for _, language := range languages {
+		if _, found := catalogs[strings.SplitN(language.locale, "-", 2)[0]]; found {
+			return language.locale
+		}
+	}
+
+	return DEFAULT_LOCALE
+}
+
+// Write a locale as language-REGION, like es-ES
+//
+// [param] locale | string: locale in any case
+//
+// [return] string: canonical locale
+func canonicalLocale(locale string) string {
+
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	parts[0] = strings.ToLower(parts[0])
+
+	if len(parts) > 1 {
+		return parts[0] + "-" + strings.ToUpper(parts[1])
+	}
+
+	return parts[0]
+}
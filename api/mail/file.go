@@ -0,0 +1,87 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Email sink appending to an mbox file, for development
+// and tests. Any mail client can open the file
+type File struct {
+	Path  string
+	mutex sync.Mutex
+}
+
+// Create an mbox email sink
+//
+// [param] path | string: path of the mbox file
+//
+// [return] *File: the sink
+func NewFile(path string) *File {
+	return &File{Path: path}
+}
+
+func (f *File) Send(ctx context.Context, message *Message) error {
+
+	now := time.Now()
+	content, err := Encode(message, now)
+	if err != nil {
+		return err
+	}
+
+	from, _ := mail.ParseAddress(message.From)
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	err = os.MkdirAll(filepath.Dir(f.Path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	_, err = file.Write(MboxEntry(from.Address, now, content))
+	return err
+}
+
+// Get the mbox entry of an email, lines starting like
+// a new entry are quoted so the mailbox can be split
+//
+// [param] sender | string: address of the sender
+// [param] date | time.Time: date of delivery
+// [param] content | []byte: encoded email
+//
+// [return] []byte: the entry, ending on an empty line
+func MboxEntry(sender string, date time.Time, content []byte) []byte {
+
+	var buffer bytes.Buffer
+	buffer.WriteString("From " + sender + " " + date.UTC().Format(time.ANSIC) + "\n")
+
+	lines := bytes.Split(bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n")), []byte("\n"))
+	for i, line := range lines {
+		if i == len(lines)-1 && len(line) == 0 {
+			break
+		}
+
+		if bytes.HasPrefix(bytes.TrimLeft(line, ">"), []byte("From ")) {
+			buffer.WriteByte('>')
+		}
+
+		buffer.Write(line)
+		buffer.WriteByte('\n')
+	}
+
+	buffer.WriteByte('\n')
+	return buffer.Bytes()
+}
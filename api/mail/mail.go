@@ -0,0 +1,64 @@
+package mail
+
+import (
+	"context"
+	"errors"
+
+	"github.com/akrck02/valhalla-core/configuration"
+	"github.com/akrck02/valhalla-core/log"
+)
+
+const (
+	DRIVER_SMTP = "smtp"
+	DRIVER_FILE = "file"
+)
+
+// Sender of the emails when none is configured
+const DEFAULT_FROM = "Valhalla <no-reply@valhalla.local>"
+
+var ErrInvalidHeader = errors.New("invalid email header")
+
+// Email ready to be sent, with a plain text body
+// and an optional html alternative
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender delivers emails
+type Sender interface {
+	Send(ctx context.Context, message *Message) error
+}
+
+// Email sender of the API, set up on start
+var Current Sender
+
+// Address the emails are sent from
+var From = DEFAULT_FROM
+
+// Set up the email sender configured with the MAIL environment
+// variable, an mbox file on the resources unless smtp is configured
+func Setup() {
+
+	if configuration.Params.MailFrom != "" {
+		From = configuration.Params.MailFrom
+	}
+
+	if configuration.Params.Mail == DRIVER_SMTP {
+		Current = NewSMTP(
+			configuration.Params.MailHost,
+			configuration.Params.MailPort,
+			configuration.Params.MailUser,
+			configuration.Params.MailPassword,
+		)
+
+		log.FormattedInfo("Mail through smtp server ${0}:${1}", configuration.Params.MailHost, configuration.Params.MailPort)
+		return
+	}
+
+	Current = NewFile(configuration.MAIL_PATH + "outbox.mbox")
+	log.FormattedInfo("Mail on local mbox at ${0}", configuration.MAIL_PATH+"outbox.mbox")
+}
@@ -0,0 +1,100 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Encode a message as a MIME email, html messages are
+// multipart/alternative with the plain text first
+//
+// [param] message | *Message: message to encode
+// [param] date | time.Time: date of the message
+//
+// [return] []byte: the email with CRLF line endings --> error: error if the headers are not valid
+func Encode(message *Message, date time.Time) ([]byte, error) {
+
+	if strings.ContainsAny(message.From+message.To+message.Subject, "\r\n") {
+		return nil, ErrInvalidHeader
+	}
+
+	from, err := mail.ParseAddress(message.From)
+	if err != nil {
+		return nil, ErrInvalidHeader
+	}
+
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return nil, ErrInvalidHeader
+	}
+
+	boundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	writeHeader(&buffer, "From", from.String())
+	writeHeader(&buffer, "To", to.String())
+	writeHeader(&buffer, "Subject", mime.QEncoding.Encode("utf-8", message.Subject))
+	writeHeader(&buffer, "Date", date.Format(time.RFC1123Z))
+	writeHeader(&buffer, "Message-ID", "<"+boundary+"@"+domainOf(from.Address)+">")
+	writeHeader(&buffer, "MIME-Version", "1.0")
+
+	if message.HTML == "" {
+		writePart(&buffer, "text/plain", message.Text)
+		return buffer.Bytes(), nil
+	}
+
+	writeHeader(&buffer, "Content-Type", "multipart/alternative; boundary=\""+boundary+"\"")
+	buffer.WriteString("\r\n")
+
+	buffer.WriteString("--" + boundary + "\r\n")
+	writePart(&buffer, "text/plain", message.Text)
+	buffer.WriteString("\r\n--" + boundary + "\r\n")
+	writePart(&buffer, "text/html", message.HTML)
+	buffer.WriteString("\r\n--" + boundary + "--\r\n")
+
+	return buffer.Bytes(), nil
+}
+
+func writeHeader(buffer *bytes.Buffer, name string, value string) {
+	buffer.WriteString(name + ": " + value + "\r\n")
+}
+
+// Write a quoted-printable part with its headers
+//
+// [param] buffer | *bytes.Buffer: email being written
+// [param] contentType | string: type of the part
+// [param] content | string: utf-8 content of the part
+func writePart(buffer *bytes.Buffer, contentType string, content string) {
+
+	writeHeader(buffer, "Content-Type", contentType+"; charset=utf-8")
+	writeHeader(buffer, "Content-Transfer-Encoding", "quoted-printable")
+	buffer.WriteString("\r\n")
+
+	writer := quotedprintable.NewWriter(buffer)
+	writer.Write([]byte(strings.ReplaceAll(content, "\n", "\r\n")))
+	writer.Close()
+}
+
+func randomBoundary() (string, error) {
+
+	random := make([]byte, 16)
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(random), nil
+}
+
+func domainOf(address string) string {
+	return address[strings.LastIndex(address, "@")+1:]
+}
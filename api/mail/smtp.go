@@ -0,0 +1,58 @@
+package mail
+
+import (
+	"context"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// Email sender through an SMTP server, the connection
+// is upgraded with STARTTLS when the server offers it
+type SMTP struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+}
+
+// Create an SMTP email sender
+//
+// [param] host | string: host of the server
+// [param] port | string: port of the server, 587 if empty
+// [param] username | string: user to authenticate, empty for none
+// [param] password | string: password of the user
+//
+// [return] *SMTP: the sender
+func NewSMTP(host string, port string, username string, password string) *SMTP {
+
+	if port == "" {
+		port = "587"
+	}
+
+	return &SMTP{
+		Host:     host,
+		Port:     port,
+		Username: username,
+		Password: password,
+	}
+}
+
+func (s *SMTP) Send(ctx context.Context, message *Message) error {
+
+	content, err := Encode(message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Encode already checked the addresses
+	from, _ := mail.ParseAddress(message.From)
+	to, _ := mail.ParseAddress(message.To)
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	return smtp.SendMail(s.Host+":"+s.Port, auth, from.Address, []string{to.Address}, content)
+}
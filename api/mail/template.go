@@ -0,0 +1,103 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+
+	"github.com/akrck02/valhalla-core/lang"
+)
+
+const (
+	TEMPLATE_VALIDATION          = "validation"
+	TEMPLATE_EMAIL_CHANGE        = "email_change"
+	TEMPLATE_EMAIL_CHANGE_NOTICE = "email_change_notice"
+	TEMPLATE_INVITATION          = "invitation"
+)
+
+// Every template has a text body on templates/{name}.txt defining
+// its subject as {name}.subject, and an html body on {name}.html.
+// Texts come from the lang catalogs through {{t "mail.key" args...}}
+//
+//go:embed templates
+var templateFiles embed.FS
+
+var textTemplates = texttemplate.Must(texttemplate.New("").Funcs(localeFuncs(lang.DEFAULT_LOCALE)).ParseFS(templateFiles, "templates/*.txt"))
+var htmlTemplates = htmltemplate.Must(htmltemplate.New("").Funcs(localeFuncs(lang.DEFAULT_LOCALE)).ParseFS(templateFiles, "templates/*.html"))
+
+// Render a template as a message in a locale, values
+// on the html body are escaped
+//
+// [param] name | string: name of the template
+// [param] locale | string: locale of the recipient
+// [param] to | string: address of the recipient
+// [param] data | interface{}: values of the template
+//
+// [return] *Message: the message --> error: error if the template cannot be rendered
+func Render(name string, locale string, to string, data interface{}) (*Message, error) {
+
+	// The parsed templates are never executed
+	// so every render can clone them
+	funcs := localeFuncs(locale)
+	textLocalized, err := textTemplates.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	htmlLocalized, err := htmlTemplates.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	textLocalized.Funcs(funcs)
+	htmlLocalized.Funcs(funcs)
+
+	var subject, text, html bytes.Buffer
+
+	err = textLocalized.ExecuteTemplate(&subject, name+".subject", data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = textLocalized.ExecuteTemplate(&text, name+".txt", data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = htmlLocalized.ExecuteTemplate(&html, name+".html", data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		From:    From,
+		To:      to,
+		Subject: subject.String(),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// Get the functions of the templates for a locale
+//
+// [param] locale | string: locale of the recipient
+//
+// [return] map[string]interface{}: functions by name
+func localeFuncs(locale string) map[string]interface{} {
+
+	return map[string]interface{}{
+		"locale": func() string {
+			return lang.Resolve(locale)
+		},
+		"t": func(key string, args ...interface{}) string {
+			values := make([]string, len(args))
+			for i, arg := range args {
+				values[i] = fmt.Sprint(arg)
+			}
+
+			return lang.Translate(locale, key, values...)
+		},
+	}
+}
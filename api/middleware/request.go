@@ -1,10 +1,13 @@
 package middleware
 
 import (
+	"github.com/akrck02/valhalla-core/lang"
 	"github.com/akrck02/valhalla-core/models"
 	"github.com/gin-gonic/gin"
 )
 
+const ACCEPT_LANGUAGE_HEADER = "Accept-Language"
+
 func Request() gin.HandlerFunc {
 	return func(c *gin.Context) {
 
@@ -12,6 +15,7 @@ func Request() gin.HandlerFunc {
 			Authorization: c.Request.Header.Get(AUTHORITATION_HEADER),
 			IP:            c.ClientIP(),
 			UserAgent:     c.Request.UserAgent(),
+			Locale:        lang.Negotiate(c.Request.Header.Get(ACCEPT_LANGUAGE_HEADER)),
 		}
 
 		c.Set("request", request)
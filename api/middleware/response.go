@@ -3,7 +3,9 @@ package middleware
 import (
 	"time"
 
+	"github.com/akrck02/valhalla-core/lang"
 	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -23,10 +25,19 @@ func APIResponseManagement(endpoint models.Endpoint) func(c *gin.Context) {
 
 		if error != nil {
 			// log.FormattedError("Error ${0} : ${1} in ${2}", error.Error, error.Message, c.Request.URL.Path)
+			if request := utils.GetRequestMetadata(c); request != nil {
+				error.Message = lang.ErrorMessage(request.Locale, error.Error, error.Message)
+			}
+
 			c.JSON(error.Status, error)
 			return
 		}
 
+		// The listener already wrote a raw body (files, csv, streams...)
+		if result == nil {
+			return
+		}
+
 		result.ResponseTime = elapsed.Nanoseconds()
 		c.JSON(result.Code, result)
 	}
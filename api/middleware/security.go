@@ -2,9 +2,11 @@ package middleware
 
 import (
 	"context"
+	"strings"
 
 	"github.com/akrck02/valhalla-core/db"
 	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/lang"
 	"github.com/akrck02/valhalla-core/log"
 	"github.com/akrck02/valhalla-core/models"
 	"github.com/akrck02/valhalla-core/utils"
@@ -26,7 +28,7 @@ func Security(endpoints []models.Endpoint, baseUrl string) gin.HandlerFunc {
 
 		// Check if endpoint is registered and secured
 		for _, endpoint := range endpoints {
-			if baseUrl+endpoint.Path == c.Request.URL.Path {
+			if matchesEndpoint(baseUrl+endpoint.Path, c.Request.URL.Path) {
 				if !endpoint.Secured {
 					log.FormattedInfo("Endpoint ${0} is not secured", endpoint.Path)
 					return
@@ -64,6 +66,10 @@ func Security(endpoints []models.Endpoint, baseUrl string) gin.HandlerFunc {
 		user, err := IsTokenValid(client, token)
 
 		if err != nil {
+			if request := utils.GetRequestMetadata(c); request != nil {
+				err.Message = lang.ErrorMessage(request.Locale, err.Error, err.Message)
+			}
+
 			c.AbortWithStatusJSON(
 				err.Status,
 				err,
@@ -80,11 +86,54 @@ func Security(endpoints []models.Endpoint, baseUrl string) gin.HandlerFunc {
 		var castedRequest = request.(models.Request)
 		castedRequest.User = user
 
+		// The locale chosen by the user wins over the browser one
+		if locale := preferredLocale(conn, client, user); locale != "" {
+			castedRequest.Locale = locale
+		}
+
 		// Set user in request
 		c.Set("request", castedRequest)
 	}
 }
 
+// Get if a request path matches an endpoint path, endpoints
+// with a wildcard like media/*path match every path under them
+//
+// [param] endpoint | string: endpoint path
+// [param] path | string: request path
+//
+// [return] bool: true if the path matches
+func matchesEndpoint(endpoint string, path string) bool {
+
+	wildcard := strings.Index(endpoint, "*")
+	if wildcard == -1 {
+		return endpoint == path
+	}
+
+	return strings.HasPrefix(path, endpoint[:wildcard])
+}
+
+// Get the locale a user chose on the preferences
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user of the request
+//
+// [return] string: locale, empty if the user never chose one
+func preferredLocale(conn context.Context, client *mongo.Client, user *models.User) string {
+
+	var preferences models.Preferences
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.PREFERENCES)
+	err := coll.FindOne(conn, bson.M{"user": user.ID}).Decode(&preferences)
+
+	if err != nil {
+		return ""
+	}
+
+	return preferences.Locale
+}
+
 // Get user from token
 //
 //	[param] conn | context.Context : The connection to the database
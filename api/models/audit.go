@@ -0,0 +1,20 @@
+package models
+
+const (
+	AUDIT_TRANSFER_REQUESTED = "transfer_requested"
+	AUDIT_TRANSFER_ACCEPTED  = "transfer_accepted"
+	AUDIT_TRANSFER_DECLINED  = "transfer_declined"
+	AUDIT_TRANSFER_CANCELLED = "transfer_cancelled"
+)
+
+// Audit entries record who did what on a team,
+// they are never updated or deleted
+type TeamAudit struct {
+	Team   string `bson:"team,omitempty"`
+	Action string `bson:"action,omitempty"`
+	Author string `bson:"author,omitempty"`
+	Target string `bson:"target,omitempty"`
+	Source string `bson:"source,omitempty"`
+	Date   int64  `bson:"date,omitempty"`
+	ID     string `bson:"_id,omitempty"`
+}
@@ -0,0 +1,31 @@
+package models
+
+const (
+	COMMENT_TARGET_TASK = "task"
+	COMMENT_TARGET_WIKI = "wiki"
+)
+
+type Comment struct {
+	Author       string            `bson:"author,omitempty"`
+	Target       string            `bson:"target,omitempty"`
+	TargetType   string            `bson:"target_type,omitempty"`
+	Parent       string            `bson:"parent,omitempty"`
+	Content      string            `bson:"content"`
+	Mentions     []string          `bson:"mentions"`
+	History      []CommentRevision `bson:"history"`
+	Deleted      bool              `bson:"deleted"`
+	CreationDate int64             `bson:"creation_date,omitempty"`
+	EditDate     int64             `bson:"edit_date,omitempty"`
+	DeletionDate int64             `bson:"deletion_date,omitempty"`
+	ID           string            `bson:"_id,omitempty"`
+}
+
+type CommentRevision struct {
+	Content string `bson:"content"`
+	Date    int64  `bson:"date"`
+}
+
+type CommentThread struct {
+	Comment Comment         `json:"comment"`
+	Replies []CommentThread `json:"replies"`
+}
@@ -0,0 +1,14 @@
+package models
+
+// Pending change of the email of a user, the code sent
+// to the new address is stored hashed
+type EmailChange struct {
+	User           string `bson:"user,omitempty"`
+	Email          string `bson:"email,omitempty"`
+	NewEmail       string `bson:"new_email,omitempty"`
+	Code           string `bson:"code,omitempty"`
+	Attempts       int    `bson:"attempts"`
+	CreationDate   int64  `bson:"creation_date,omitempty"`
+	ExpirationDate int64  `bson:"expiration_date,omitempty"`
+	ID             string `bson:"_id,omitempty"`
+}
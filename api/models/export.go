@@ -0,0 +1,21 @@
+package models
+
+const (
+	EXPORT_PENDING = "pending"
+	EXPORT_READY   = "ready"
+	EXPORT_FAILED  = "failed"
+	EXPORT_EXPIRED = "expired"
+)
+
+// Exports of the data of a user, built in background
+// as a zip archive downloadable until they expire
+type DataExport struct {
+	User           string `bson:"user,omitempty" json:"-"`
+	Status         string `bson:"status,omitempty" json:"status"`
+	Object         string `bson:"object,omitempty" json:"-"`
+	Size           int    `bson:"size,omitempty" json:"size,omitempty"`
+	CreationDate   int64  `bson:"creation_date,omitempty" json:"creation_date"`
+	CompletionDate int64  `bson:"completion_date,omitempty" json:"completion_date,omitempty"`
+	ExpirationDate int64  `bson:"expiration_date,omitempty" json:"expiration_date,omitempty"`
+	ID             string `bson:"_id,omitempty" json:"id"`
+}
@@ -0,0 +1,22 @@
+package models
+
+const (
+	INVITATION_PENDING  = "pending"
+	INVITATION_ACCEPTED = "accepted"
+	INVITATION_DECLINED = "declined"
+	INVITATION_REVOKED  = "revoked"
+)
+
+// Invitations are made to a registered user or to
+// an email for users that are not registered yet
+type Invitation struct {
+	Team           string `bson:"team,omitempty"`
+	Inviter        string `bson:"inviter,omitempty"`
+	User           string `bson:"user,omitempty"`
+	Email          string `bson:"email,omitempty"`
+	Status         string `bson:"status,omitempty"`
+	CreationDate   int64  `bson:"creation_date,omitempty"`
+	ExpirationDate int64  `bson:"expiration_date,omitempty"`
+	ResponseDate   int64  `bson:"response_date,omitempty"`
+	ID             string `bson:"_id,omitempty"`
+}
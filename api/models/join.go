@@ -0,0 +1,21 @@
+package models
+
+const (
+	JOIN_REQUEST_PENDING   = "pending"
+	JOIN_REQUEST_APPROVED  = "approved"
+	JOIN_REQUEST_REJECTED  = "rejected"
+	JOIN_REQUEST_CANCELLED = "cancelled"
+)
+
+// Requests of users to join a public or discoverable team,
+// the responder is the owner or admin who answered
+type JoinRequest struct {
+	Team         string `bson:"team,omitempty"`
+	User         string `bson:"user,omitempty"`
+	Message      string `bson:"message,omitempty"`
+	Status       string `bson:"status,omitempty"`
+	Responder    string `bson:"responder,omitempty"`
+	CreationDate int64  `bson:"creation_date,omitempty"`
+	ResponseDate int64  `bson:"response_date,omitempty"`
+	ID           string `bson:"_id,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+const (
+	MEDIA_OF_USER = "user"
+	MEDIA_OF_TEAM = "team"
+)
+
+// Key of the original file on the media objects,
+// thumbnails are keyed by their size
+const MEDIA_ORIGINAL = "original"
+
+// Stored files are referenced by the id of their media,
+// never by their path on the server. Objects are the
+// content-addressed keys of the files on the blob storage
+type Media struct {
+	Kind         string            `bson:"kind,omitempty"`
+	Owner        string            `bson:"owner,omitempty"`
+	ContentType  string            `bson:"content_type,omitempty"`
+	Hash         string            `bson:"hash,omitempty"`
+	Objects      map[string]string `bson:"objects,omitempty"`
+	CreationDate int64             `bson:"creation_date,omitempty"`
+	ID           string            `bson:"_id,omitempty"`
+}
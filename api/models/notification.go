@@ -0,0 +1,27 @@
+package models
+
+const (
+	NOTIFICATION_MENTION    = "mention"
+	NOTIFICATION_REMINDER   = "reminder"
+	NOTIFICATION_INVITATION = "invitation"
+	NOTIFICATION_TRANSFER   = "transfer"
+	NOTIFICATION_JOIN       = "join_request"
+	NOTIFICATION_EXPORT     = "export"
+	NOTIFICATION_MEMBER     = "member_added"
+	NOTIFICATION_ASSIGNMENT = "task_assigned"
+	NOTIFICATION_WEBHOOK    = "webhook_disabled"
+)
+
+// Notifications are kept until the user deletes them,
+// Source is the id of the object they are about
+type Notification struct {
+	User         string `bson:"user,omitempty"`
+	Type         string `bson:"type,omitempty"`
+	Title        string `bson:"title,omitempty"`
+	Message      string `bson:"message,omitempty"`
+	Source       string `bson:"source,omitempty"`
+	Read         bool   `bson:"read"`
+	ReadDate     int64  `bson:"read_date,omitempty"`
+	CreationDate int64  `bson:"creation_date,omitempty"`
+	ID           string `bson:"_id,omitempty"`
+}
@@ -0,0 +1,25 @@
+package models
+
+const (
+	OUTBOX_PENDING = "pending"
+	OUTBOX_SENT    = "sent"
+	OUTBOX_FAILED  = "failed"
+)
+
+// Email waiting on the outbox, failed deliveries are
+// retried until the attempts run out. The bodies are
+// removed once the email is sent or failed
+type OutboxEmail struct {
+	To           string `bson:"to,omitempty"`
+	Template     string `bson:"template,omitempty"`
+	Subject      string `bson:"subject,omitempty"`
+	Text         string `bson:"text,omitempty"`
+	HTML         string `bson:"html,omitempty"`
+	Status       string `bson:"status,omitempty"`
+	Attempts     int    `bson:"attempts"`
+	NextAttempt  int64  `bson:"next_attempt,omitempty"`
+	LastError    string `bson:"last_error,omitempty"`
+	CreationDate int64  `bson:"creation_date,omitempty"`
+	SentDate     int64  `bson:"sent_date,omitempty"`
+	ID           string `bson:"_id,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+const (
+	SORT_BY_NAME      = "name"
+	SORT_BY_CREATION  = "created"
+	SORT_BY_RELEVANCE = "relevance"
+)
+
+// Sort can be prefixed with - for descending order,
+// Cursor is the next cursor of the previous page
+type PageRequest struct {
+	Cursor string
+	Limit  int
+	Sort   string
+}
+
+type Page struct {
+	Items      interface{} `json:"items"`
+	Total      int64       `json:"total"`
+	Limit      int         `json:"limit"`
+	NextCursor string      `json:"next_cursor"`
+}
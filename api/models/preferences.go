@@ -0,0 +1,173 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Version of the preferences schema, stored preferences
+// of older versions are upgraded when read
+const PREFERENCES_VERSION = 1
+
+const (
+	THEME_SYSTEM = "system"
+	THEME_LIGHT  = "light"
+	THEME_DARK   = "dark"
+)
+
+var DATE_FORMATS = []string{"YYYY-MM-DD", "DD/MM/YYYY", "MM/DD/YYYY", "DD.MM.YYYY"}
+
+// Preferences of a user, one document per user,
+// an empty locale follows the language of the requests
+type Preferences struct {
+	User          string                  `bson:"user,omitempty" json:"-"`
+	Version       int                     `bson:"version" json:"version"`
+	Locale        string                  `bson:"locale" json:"locale"`
+	Timezone      string                  `bson:"timezone" json:"timezone"`
+	Theme         string                  `bson:"theme" json:"theme"`
+	DateFormat    string                  `bson:"date_format" json:"date_format"`
+	DefaultTeam   string                  `bson:"default_team" json:"default_team"`
+	Notifications NotificationPreferences `bson:"notifications" json:"notifications"`
+	UpdateDate    int64                   `bson:"update_date,omitempty" json:"update_date,omitempty"`
+}
+
+// Kinds of notifications the user wants to get
+type NotificationPreferences struct {
+	InApp       bool `bson:"in_app" json:"in_app"`
+	Mentions    bool `bson:"mentions" json:"mentions"`
+	Reminders   bool `bson:"reminders" json:"reminders"`
+	Invitations bool `bson:"invitations" json:"invitations"`
+	Teams       bool `bson:"teams" json:"teams"`
+}
+
+// Partial update of the preferences, only the
+// fields present on the request are changed
+type PreferencesPatch struct {
+	Locale        *string                       `json:"locale"`
+	Timezone      *string                       `json:"timezone"`
+	Theme         *string                       `json:"theme"`
+	DateFormat    *string                       `json:"date_format"`
+	DefaultTeam   *string                       `json:"default_team"`
+	Notifications *NotificationPreferencesPatch `json:"notifications"`
+}
+
+type NotificationPreferencesPatch struct {
+	InApp       *bool `json:"in_app"`
+	Mentions    *bool `json:"mentions"`
+	Reminders   *bool `json:"reminders"`
+	Invitations *bool `json:"invitations"`
+	Teams       *bool `json:"teams"`
+}
+
+// Get the preferences of a user who never changed them
+//
+// [param] user | string: id of the user
+//
+// [return] *Preferences: default preferences
+func DefaultPreferences(user string) *Preferences {
+	return &Preferences{
+		User:       user,
+		Version:    PREFERENCES_VERSION,
+		Timezone:   "UTC",
+		Theme:      THEME_SYSTEM,
+		DateFormat: DATE_FORMATS[0],
+		Notifications: NotificationPreferences{
+			InApp:       true,
+			Mentions:    true,
+			Reminders:   true,
+			Invitations: true,
+			Teams:       true,
+		},
+	}
+}
+
+// Apply a patch on the preferences
+//
+// [param] patch | *PreferencesPatch: fields to change
+func (p *Preferences) Apply(patch *PreferencesPatch) {
+
+	setString(&p.Locale, patch.Locale)
+	setString(&p.Timezone, patch.Timezone)
+	setString(&p.Theme, patch.Theme)
+	setString(&p.DateFormat, patch.DateFormat)
+	setString(&p.DefaultTeam, patch.DefaultTeam)
+
+	if patch.Notifications == nil {
+		return
+	}
+
+	setBool(&p.Notifications.InApp, patch.Notifications.InApp)
+	setBool(&p.Notifications.Mentions, patch.Notifications.Mentions)
+	setBool(&p.Notifications.Reminders, patch.Notifications.Reminders)
+	setBool(&p.Notifications.Invitations, patch.Notifications.Invitations)
+	setBool(&p.Notifications.Teams, patch.Notifications.Teams)
+}
+
+// Get the fields of the preferences with their nested keys
+//
+// [return] bson.M: every field by its dotted key
+func (p *Preferences) FlatBson() bson.M {
+	return bson.M{
+		"version":                   p.Version,
+		"locale":                    p.Locale,
+		"timezone":                  p.Timezone,
+		"theme":                     p.Theme,
+		"date_format":               p.DateFormat,
+		"default_team":              p.DefaultTeam,
+		"notifications.in_app":      p.Notifications.InApp,
+		"notifications.mentions":    p.Notifications.Mentions,
+		"notifications.reminders":   p.Notifications.Reminders,
+		"notifications.invitations": p.Notifications.Invitations,
+		"notifications.teams":       p.Notifications.Teams,
+	}
+}
+
+// Get the fields present on the patch with their nested keys,
+// so updating a notification keeps the rest of them
+//
+// [return] bson.M: fields to set by their dotted key
+func (p *PreferencesPatch) PurgedBson() bson.M {
+
+	purgedBson := bson.M{}
+
+	putString(purgedBson, "locale", p.Locale)
+	putString(purgedBson, "timezone", p.Timezone)
+	putString(purgedBson, "theme", p.Theme)
+	putString(purgedBson, "date_format", p.DateFormat)
+	putString(purgedBson, "default_team", p.DefaultTeam)
+
+	if p.Notifications == nil {
+		return purgedBson
+	}
+
+	putBool(purgedBson, "notifications.in_app", p.Notifications.InApp)
+	putBool(purgedBson, "notifications.mentions", p.Notifications.Mentions)
+	putBool(purgedBson, "notifications.reminders", p.Notifications.Reminders)
+	putBool(purgedBson, "notifications.invitations", p.Notifications.Invitations)
+	putBool(purgedBson, "notifications.teams", p.Notifications.Teams)
+
+	return purgedBson
+}
+
+func setString(field *string, value *string) {
+	if value != nil {
+		*field = *value
+	}
+}
+
+func setBool(field *bool, value *bool) {
+	if value != nil {
+		*field = *value
+	}
+}
+
+func putString(purgedBson bson.M, key string, value *string) {
+	if value != nil {
+		purgedBson[key] = *value
+	}
+}
+
+func putBool(purgedBson bson.M, key string, value *bool) {
+	if value != nil {
+		purgedBson[key] = *value
+	}
+}
@@ -8,4 +8,5 @@ type Project struct {
 	Wikis       []string `bson:"wikis,omitempty"`
 	Notes       []string `bson:"notes,omitempty"`
 	Tasks       []string `bson:"tasks,omitempty"`
+	ID          string   `bson:"_id,omitempty"`
 }
@@ -0,0 +1,22 @@
+package models
+
+const (
+	RECURRENCE_DAILY   = "daily"
+	RECURRENCE_WEEKLY  = "weekly"
+	RECURRENCE_MONTHLY = "monthly"
+
+	RECURRENCE_ON_COMPLETION = "completion"
+	RECURRENCE_ON_SCHEDULE   = "schedule"
+)
+
+// Weekdays go from 0 (sunday) to 6 (saturday),
+// Start is the due date of the first occurrence.
+type Recurrence struct {
+	Frequency string `bson:"frequency"`
+	Interval  int    `bson:"interval"`
+	Weekdays  []int  `bson:"weekdays,omitempty"`
+	Until     int64  `bson:"until,omitempty"`
+	Count     int    `bson:"count,omitempty"`
+	Mode      string `bson:"mode"`
+	Start     int64  `bson:"start"`
+}
@@ -0,0 +1,11 @@
+package models
+
+type Reminder struct {
+	User         string `bson:"user,omitempty"`
+	Task         string `bson:"task,omitempty"`
+	Before       int64  `bson:"before"`
+	FireDate     int64  `bson:"fire_date"`
+	Fired        bool   `bson:"fired"`
+	CreationDate int64  `bson:"creation_date,omitempty"`
+	ID           string `bson:"_id,omitempty"`
+}
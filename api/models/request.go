@@ -5,4 +5,5 @@ type Request struct {
 	IP            string `json:"ip"`
 	UserAgent     string `json:"userAgent"`
 	User          *User  `json:"user"`
+	Locale        string `json:"locale"`
 }
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	SCOPE_CHANGE_ADDED   = "added"
+	SCOPE_CHANGE_REMOVED = "removed"
+
+	BURNDOWN_UNIT_COUNT  = "count"
+	BURNDOWN_UNIT_POINTS = "points"
+)
+
+type Sprint struct {
+	Name         string        `bson:"name,omitempty"`
+	Goal         string        `bson:"goal,omitempty"`
+	Project      string        `bson:"project,omitempty"`
+	StartDate    int64         `bson:"start_date,omitempty"`
+	EndDate      int64         `bson:"end_date,omitempty"`
+	Tasks        []string      `bson:"tasks"`
+	ScopeChanges []ScopeChange `bson:"scope_changes"`
+	ID           string        `bson:"_id,omitempty"`
+}
+
+type ScopeChange struct {
+	Task   string `bson:"task"`
+	Action string `bson:"action"`
+	Points int    `bson:"points"`
+	Date   int64  `bson:"date"`
+}
+
+type BurndownPoint struct {
+	Date      int64   `json:"date"`
+	Ideal     float64 `json:"ideal"`
+	Scope     *int    `json:"scope,omitempty"`
+	Completed *int    `json:"completed,omitempty"`
+	Remaining *int    `json:"remaining,omitempty"`
+}
+
+func (s *Sprint) PurgedBson() bson.M {
+
+	purgedBson := bson.M{}
+
+	if s.Name != "" {
+		purgedBson["name"] = s.Name
+	}
+
+	if s.Goal != "" {
+		purgedBson["goal"] = s.Goal
+	}
+
+	if s.StartDate != 0 {
+		purgedBson["start_date"] = s.StartDate
+	}
+
+	if s.EndDate != 0 {
+		purgedBson["end_date"] = s.EndDate
+	}
+
+	return purgedBson
+}
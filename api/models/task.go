@@ -0,0 +1,41 @@
+package models
+
+type Task struct {
+	Name           string      `bson:"name,omitempty"`
+	Description    string      `bson:"description,omitempty"`
+	Project        string      `bson:"project,omitempty"`
+	Owner          string      `bson:"owner,omitempty"`
+	Assignee       string      `bson:"assignee,omitempty"`
+	Points         int         `bson:"points"`
+	Duration       int         `bson:"duration,omitempty"`
+	Done           bool        `bson:"done"`
+	CreationDate   int64       `bson:"creation_date,omitempty"`
+	CompletionDate int64       `bson:"completion_date,omitempty"`
+	DueDate        int64       `bson:"due_date,omitempty"`
+	Recurrence     *Recurrence `bson:"recurrence,omitempty"`
+	Series         string      `bson:"series,omitempty"`
+	Occurrence     int         `bson:"occurrence,omitempty"`
+	Spawned        bool        `bson:"spawned"`
+	ID             string      `bson:"_id,omitempty"`
+}
+
+type TaskDependency struct {
+	Blocker      string `bson:"blocker,omitempty"`
+	Blocked      string `bson:"blocked,omitempty"`
+	CreationDate int64  `bson:"creation_date,omitempty"`
+	ID           string `bson:"_id,omitempty"`
+}
+
+type GanttTask struct {
+	Task           string `json:"task"`
+	Name           string `json:"name"`
+	Duration       int    `json:"duration"`
+	EarliestStart  int    `json:"earliest_start"`
+	EarliestFinish int    `json:"earliest_finish"`
+	LatestStart    int    `json:"latest_start"`
+	LatestFinish   int    `json:"latest_finish"`
+	Slack          int    `json:"slack"`
+	Critical       bool   `json:"critical"`
+	StartDate      int64  `json:"start_date"`
+	EndDate        int64  `json:"end_date"`
+}
@@ -4,28 +4,124 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 )
 
+const (
+	TEAM_ROLE_OWNER  = "owner"
+	TEAM_ROLE_ADMIN  = "admin"
+	TEAM_ROLE_MEMBER = "member"
+)
+
+// Public teams can be seen and joined by everyone, discoverable teams
+// are found on searches and joined on approval, private teams are only
+// seen by their members
+const (
+	TEAM_VISIBILITY_PUBLIC       = "public"
+	TEAM_VISIBILITY_DISCOVERABLE = "discoverable"
+	TEAM_VISIBILITY_PRIVATE      = "private"
+)
+
 type Team struct {
-	Name        string   `bson:"name,omitempty"`
-	Description string   `bson:"description,omitempty"`
-	ProfilePic  string   `bson:"profilepic,omitempty"`
-	Projects    []string `bson:"projects,omitempty"`
-	Owner       string   `bson:"owner,omitempty"`
-	Members     []string `bson:"members,omitempty"`
-	ID          string   `bson:"_id,omitempty"`
+	Name        string            `bson:"name,omitempty"`
+	Description string            `bson:"description,omitempty"`
+	ProfilePic  string            `bson:"profilepic,omitempty"`
+	Projects    []string          `bson:"projects,omitempty"`
+	Owner       string            `bson:"owner,omitempty"`
+	Members     []string          `bson:"members,omitempty"`
+	Roles       map[string]string `bson:"roles,omitempty"`
+	Parent      string            `bson:"parent,omitempty"`
+	Ancestors   []string          `bson:"ancestors,omitempty"`
+	Visibility  string            `bson:"visibility,omitempty"`
+	ID          string            `bson:"_id,omitempty"`
+}
+
+type TeamMember struct {
+	ID         string `json:"id"`
+	Username   string `json:"username"`
+	ProfilePic string `json:"profile_pic"`
+	Role       string `json:"role"`
 }
 
 func (t *Team) Clone() *Team {
 	return &Team{
 		Name:        t.Name,
 		Description: t.Description,
-		ProfilePic:  t.Description,
+		ProfilePic:  t.ProfilePic,
 		Projects:    t.Projects,
 		Owner:       t.Owner,
 		Members:     t.Members,
+		Roles:       t.Roles,
+		Parent:      t.Parent,
+		Ancestors:   t.Ancestors,
+		Visibility:  t.Visibility,
 		ID:          t.ID,
 	}
 }
 
+// Get the role of a user on the team
+//
+// [param] user | string: id of the user
+//
+// [return] string: role of the user, empty if not a member
+func (t *Team) RoleOf(user string) string {
+
+	if user == t.Owner {
+		return TEAM_ROLE_OWNER
+	}
+
+	for _, member := range t.Members {
+		if member != user {
+			continue
+		}
+
+		if role, found := t.Roles[user]; found {
+			return role
+		}
+
+		return TEAM_ROLE_MEMBER
+	}
+
+	return ""
+}
+
+// Get if the team is found on searches by users who are not members
+//
+// [return] bool: true for public and discoverable teams
+func (t *Team) IsListed() bool {
+	return t.Visibility == TEAM_VISIBILITY_PUBLIC || t.Visibility == TEAM_VISIBILITY_DISCOVERABLE
+}
+
+// Get the fields of the team shown to users who cannot see it,
+// without its members, roles or owner
+//
+// [return] map[string]interface{}: summary of the team
+func (t *Team) Summary() map[string]interface{} {
+	return map[string]interface{}{
+		"id":          t.ID,
+		"name":        t.Name,
+		"description": t.Description,
+		"profilepic":  t.ProfilePic,
+		"visibility":  t.Visibility,
+	}
+}
+
+// Get if the team is an organization, a team without parent
+//
+// [return] bool: true if the team has no parent
+func (t *Team) IsOrganization() bool {
+	return t.Parent == ""
+}
+
+// Get the organization of the team, the root of its hierarchy
+//
+// [return] string: id of the organization
+func (t *Team) Organization() string {
+
+	if len(t.Ancestors) == 0 {
+		return t.ID
+	}
+
+	return t.Ancestors[0]
+}
+
 func (t *Team) PurgedBson(hideID bool) bson.M {
 
 	purgedBson := bson.M{}
@@ -38,14 +134,14 @@ func (t *Team) PurgedBson(hideID bool) bson.M {
 		purgedBson["description"] = t.Description
 	}
 
-	if t.ProfilePic != "" {
-		purgedBson["profilepic"] = t.ProfilePic
-	}
-
 	if t.Owner != "" {
 		purgedBson["owner"] = t.Owner
 	}
 
+	if t.Visibility != "" {
+		purgedBson["visibility"] = t.Visibility
+	}
+
 	if !hideID && t.ID != "" {
 		purgedBson["_id"] = t.ID
 	}
@@ -0,0 +1,27 @@
+package models
+
+const (
+	TIMESHEET_GROUP_DAY  = "day"
+	TIMESHEET_GROUP_WEEK = "week"
+)
+
+type TimeEntry struct {
+	User    string `bson:"user,omitempty"`
+	Task    string `bson:"task,omitempty"`
+	Project string `bson:"project,omitempty"`
+	Note    string `bson:"note,omitempty"`
+	Start   int64  `bson:"start,omitempty"`
+	End     int64  `bson:"end"`
+	Manual  bool   `bson:"manual"`
+	ID      string `bson:"_id,omitempty"`
+}
+
+type TimesheetRow struct {
+	Period      int64   `json:"period"`
+	User        string  `json:"user"`
+	Username    string  `json:"username"`
+	Project     string  `json:"project"`
+	ProjectName string  `json:"project_name"`
+	Duration    int64   `json:"duration"`
+	Hours       float64 `json:"hours"`
+}
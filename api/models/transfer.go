@@ -0,0 +1,21 @@
+package models
+
+const (
+	TRANSFER_PENDING   = "pending"
+	TRANSFER_ACCEPTED  = "accepted"
+	TRANSFER_DECLINED  = "declined"
+	TRANSFER_CANCELLED = "cancelled"
+)
+
+// Ownership transfers are requested by the owner
+// and must be accepted by the new owner in time
+type TeamTransfer struct {
+	Team           string `bson:"team,omitempty"`
+	From           string `bson:"from,omitempty"`
+	To             string `bson:"to,omitempty"`
+	Status         string `bson:"status,omitempty"`
+	CreationDate   int64  `bson:"creation_date,omitempty"`
+	ExpirationDate int64  `bson:"expiration_date,omitempty"`
+	ResponseDate   int64  `bson:"response_date,omitempty"`
+	ID             string `bson:"_id,omitempty"`
+}
@@ -6,14 +6,23 @@ import (
 
 type User struct {
 	Email          string `bson:"email,omitempty"`
-	Password       string `bson:"password,omitempty"`
+	Password       string `bson:"password,omitempty" json:",omitempty"`
 	Username       string `bson:"username,omitempty"`
 	Validated      bool   `bson:"validated"`
-	ValidationCode string `bson:"validation_code,omitempty"`
+	ValidationCode string `bson:"validation_code,omitempty" json:"-"`
+	ValidationSent int64  `bson:"validation_sent,omitempty" json:"-"`
 	ProfilePic     string `bson:"profile_pic,omitempty"`
+	DeletionDate   int64  `bson:"deletion_date,omitempty"`
+	Deleted        bool   `bson:"deleted,omitempty"`
 	ID             string `bson:"_id,omitempty"`
 }
 
+type UserProfile struct {
+	ID         string `json:"id"`
+	Username   string `json:"username"`
+	ProfilePic string `json:"profile_pic"`
+}
+
 func (u *User) Clone() *User {
 	return &User{
 		Email:          u.Email,
@@ -21,11 +30,26 @@ func (u *User) Clone() *User {
 		Username:       u.Username,
 		Validated:      u.Validated,
 		ValidationCode: u.ValidationCode,
+		ValidationSent: u.ValidationSent,
 		ProfilePic:     u.ProfilePic,
+		DeletionDate:   u.DeletionDate,
+		Deleted:        u.Deleted,
 		ID:             u.ID,
 	}
 }
 
+// Get the public profile of the user, only the fields
+// every user sharing a team or organization can see
+//
+// [return] UserProfile: public profile
+func (u *User) PublicProfile() UserProfile {
+	return UserProfile{
+		ID:         u.ID,
+		Username:   u.Username,
+		ProfilePic: u.ProfilePic,
+	}
+}
+
 func (u *User) PurgedBson() bson.M {
 
 	purgedBson := bson.M{}
@@ -0,0 +1,44 @@
+package models
+
+const (
+	DELIVERY_PENDING   = "pending"
+	DELIVERY_DELIVERED = "delivered"
+	DELIVERY_FAILED    = "failed"
+)
+
+// Every event of the team or project of a webhook
+const WEBHOOK_ALL_EVENTS = "*"
+
+// Subscription of a url to the events of a team or a project,
+// Events filters them by type. The secret signs the payloads
+// and is only shown when the webhook is created
+type Webhook struct {
+	Team         string   `bson:"team,omitempty"`
+	Project      string   `bson:"project,omitempty"`
+	URL          string   `bson:"url,omitempty"`
+	Events       []string `bson:"events,omitempty"`
+	Secret       string   `bson:"secret,omitempty" json:"-"`
+	Active       bool     `bson:"active"`
+	Failures     int      `bson:"failures"`
+	Author       string   `bson:"author,omitempty"`
+	CreationDate int64    `bson:"creation_date,omitempty"`
+	DisableDate  int64    `bson:"disable_date,omitempty"`
+	ID           string   `bson:"_id,omitempty"`
+}
+
+// Delivery of an event to a webhook, failed deliveries are
+// retried until the attempts run out
+type WebhookDelivery struct {
+	Webhook      string `bson:"webhook,omitempty"`
+	Event        string `bson:"event,omitempty"`
+	Payload      string `bson:"payload,omitempty"`
+	Status       string `bson:"status,omitempty"`
+	Attempts     int    `bson:"attempts"`
+	NextAttempt  int64  `bson:"next_attempt,omitempty"`
+	ResponseCode int    `bson:"response_code,omitempty"`
+	LastError    string `bson:"last_error,omitempty"`
+	Redelivery   string `bson:"redelivery,omitempty"`
+	CreationDate int64  `bson:"creation_date,omitempty"`
+	DeliveryDate int64  `bson:"delivery_date,omitempty"`
+	ID           string `bson:"_id,omitempty"`
+}
@@ -0,0 +1,179 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Time between checks for due jobs
+const TICK = 15 * time.Second
+
+// Time a job is locked for while running, so other
+// instances do not run it at the same time
+const LEASE = 5 * time.Minute
+
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(conn context.Context, client *mongo.Client) *models.Error
+}
+
+// Persisted state of a job, next runs survive restarts
+// and overdue jobs run as soon as the scheduler starts
+type JobState struct {
+	Name        string `bson:"_id"`
+	LastRun     int64  `bson:"last_run"`
+	NextRun     int64  `bson:"next_run"`
+	LockedUntil int64  `bson:"locked_until"`
+	LastError   string `bson:"last_error"`
+}
+
+// Create a new job
+//
+// [param] name | string: unique name of the job
+// [param] interval | time.Duration: time between runs
+// [param] run | func: job logic
+//
+// [return] *Job: the job
+func JobFrom(name string, interval time.Duration, run func(conn context.Context, client *mongo.Client) *models.Error) *Job {
+	return &Job{
+		Name:     name,
+		Interval: interval,
+		Run:      run,
+	}
+}
+
+// Start running the given jobs in background
+//
+// [param] jobs | []*Job: jobs to run
+func Start(jobs []*Job) {
+
+	client := db.CreateClient()
+	conn := db.Connect(*client)
+
+	for _, job := range jobs {
+		err := register(conn, client, job)
+
+		if err != nil {
+			log.FormattedError("Job ${0} not registered: ${1}", job.Name, err.Error())
+		}
+	}
+
+	log.FormattedInfo("Scheduler started with ${0} jobs", utils.Int2String(len(jobs)))
+
+	go func() {
+		ticker := time.NewTicker(TICK)
+		defer ticker.Stop()
+
+		for {
+			runDueJobs(conn, client, jobs, time.Now().UnixMilli())
+			<-ticker.C
+		}
+	}()
+}
+
+// Create the persisted state of a job if it does not exist yet
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] job | *Job: job to register
+//
+// [return] error: error if any
+func register(conn context.Context, client *mongo.Client, job *Job) error {
+
+	states := client.Database(db.CurrentDatabase).Collection(db.SCHEDULER)
+	_, err := states.UpdateOne(conn,
+		bson.M{"_id": job.Name},
+		bson.M{"$setOnInsert": JobState{Name: job.Name}},
+		options.Update().SetUpsert(true),
+	)
+
+	return err
+}
+
+// Run the jobs whose next run has been reached
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] jobs | []*Job: registered jobs
+// [param] now | int64: current time in milliseconds
+func runDueJobs(conn context.Context, client *mongo.Client, jobs []*Job, now int64) {
+
+	for _, job := range jobs {
+		if !claim(conn, client, job, now) {
+			continue
+		}
+
+		runErr := run(conn, client, job)
+		lastError := ""
+
+		if runErr != nil {
+			lastError = runErr.Message
+			log.FormattedError("Job ${0} failed: ${1}", job.Name, runErr.Message)
+		}
+
+		states := client.Database(db.CurrentDatabase).Collection(db.SCHEDULER)
+		_, err := states.UpdateOne(conn, bson.M{"_id": job.Name}, bson.M{"$set": bson.M{
+			"last_run":     now,
+			"next_run":     now + job.Interval.Milliseconds(),
+			"locked_until": 0,
+			"last_error":   lastError,
+		}})
+
+		if err != nil {
+			log.FormattedError("Job ${0} state not saved: ${1}", job.Name, err.Error())
+		}
+	}
+}
+
+// Lock a due job so no other instance runs it
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] job | *Job: job to claim
+// [param] now | int64: current time in milliseconds
+//
+// [return] bool: true if the job is due and was claimed
+func claim(conn context.Context, client *mongo.Client, job *Job, now int64) bool {
+
+	states := client.Database(db.CurrentDatabase).Collection(db.SCHEDULER)
+	result, err := states.UpdateOne(conn,
+		bson.M{
+			"_id":          job.Name,
+			"next_run":     bson.M{"$lte": now},
+			"locked_until": bson.M{"$lt": now},
+		},
+		bson.M{"$set": bson.M{"locked_until": now + LEASE.Milliseconds()}},
+	)
+
+	return err == nil && result.ModifiedCount > 0
+}
+
+// Run a job recovering from panics so the scheduler keeps running
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] job | *Job: job to run
+//
+// [return] *models.Error: error if any
+func run(conn context.Context, client *mongo.Client, job *Job) (runErr *models.Error) {
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			runErr = &models.Error{
+				Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+				Message: "Job panicked",
+			}
+		}
+	}()
+
+	return job.Run(conn, client)
+}
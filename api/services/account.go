@@ -0,0 +1,598 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"sort"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/storage"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Time a user can cancel the deletion of the account
+const USER_DELETION_GRACE = 14 * utils.MILLIS_PER_DAY
+
+// Time a data export can be downloaded
+const EXPORT_EXPIRATION = 7 * utils.MILLIS_PER_DAY
+
+// Namespace of the data exports on the blob storage
+const EXPORTS_NAMESPACE = "exports"
+
+// Name authored content shows once its author is deleted
+const DELETED_USERNAME = "Deleted user"
+
+// Schedule the deletion of a user after the grace period
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user to delete
+//
+// [return] int64: deletion date --> *models.Error: error if any
+func ScheduleUserDeletion(conn context.Context, client *mongo.Client, user *models.User) (int64, *models.Error) {
+
+	if user.DeletionDate != 0 {
+		return 0, &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.USER_PENDING_DELETION),
+			Message: "User deletion already scheduled",
+		}
+	}
+
+	deletionDate := utils.GetCurrentMillis() + USER_DELETION_GRACE
+	updateErr := setUserDeletionDate(conn, client, user, bson.M{"$set": bson.M{"deletion_date": deletionDate}})
+
+	if updateErr != nil {
+		return 0, updateErr
+	}
+
+	user.DeletionDate = deletionDate
+	return deletionDate, nil
+}
+
+// Cancel the scheduled deletion of a user
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user to keep
+//
+// [return] *models.Error: error if any
+func CancelUserDeletion(conn context.Context, client *mongo.Client, user *models.User) *models.Error {
+
+	if user.DeletionDate == 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.DELETION_NOT_SCHEDULED),
+			Message: "User deletion is not scheduled",
+		}
+	}
+
+	updateErr := setUserDeletionDate(conn, client, user, bson.M{"$unset": bson.M{"deletion_date": ""}})
+	if updateErr != nil {
+		return updateErr
+	}
+
+	user.DeletionDate = 0
+	return nil
+}
+
+// Delete the users whose grace period is over
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+//
+// [return] *models.Error: error if any
+func DeleteScheduledUsers(conn context.Context, client *mongo.Client) *models.Error {
+
+	users := client.Database(db.CurrentDatabase).Collection(db.USER)
+	found, err := users.Find(conn, bson.M{
+		"deletion_date": bson.M{"$lte": utils.GetCurrentMillis()},
+		"deleted":       bson.M{"$ne": true},
+	})
+
+	due := []models.User{}
+	if err != nil || found.All(conn, &due) != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get the users to delete",
+		}
+	}
+
+	// A failed user must not stop the rest
+	for i := range due {
+		deleteErr := DeleteUser(conn, client, &due[i])
+
+		if deleteErr != nil {
+			log.FormattedError("Cannot delete user ${0}: ${1}", due[i].ID, deleteErr.Message)
+		}
+	}
+
+	return nil
+}
+
+// Request an export of the data of a user, it is
+// built in background and downloaded once ready
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: owner of the data
+//
+// [return] *models.DataExport: export requested --> *models.Error: error if any
+func RequestDataExport(conn context.Context, client *mongo.Client, user *models.User) (*models.DataExport, *models.Error) {
+
+	exports := client.Database(db.CurrentDatabase).Collection(db.DATA_EXPORT)
+	pending, err := exports.CountDocuments(conn, bson.M{"user": user.ID, "status": models.EXPORT_PENDING})
+
+	if err != nil || pending > 0 {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.EXPORT_PENDING),
+			Message: "There is already a pending export",
+		}
+	}
+
+	export := &models.DataExport{
+		User:         user.ID,
+		Status:       models.EXPORT_PENDING,
+		CreationDate: utils.GetCurrentMillis(),
+	}
+
+	result, err := exports.InsertOne(conn, export)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.EXPORT_NOT_CREATED),
+			Message: "Export not created",
+		}
+	}
+
+	export.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return export, nil
+}
+
+// Get data export logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] id | string: id of the export
+//
+// [return] *models.DataExport: export found --> *models.Error: error if any
+func GetDataExport(conn context.Context, client *mongo.Client, id string) (*models.DataExport, *models.Error) {
+
+	notFound := &models.Error{
+		Status:  utils.HTTP_STATUS_NOT_FOUND,
+		Error:   int(error.EXPORT_NOT_FOUND),
+		Message: "Export not found",
+	}
+
+	objID, err := utils.StringToObjectId(id)
+	if err != nil {
+		return nil, notFound
+	}
+
+	exports := client.Database(db.CurrentDatabase).Collection(db.DATA_EXPORT)
+
+	var found models.DataExport
+	if exports.FindOne(conn, bson.M{"_id": objID}).Decode(&found) != nil {
+		return nil, notFound
+	}
+
+	return &found, nil
+}
+
+// Build the pending data exports and delete the expired ones
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+//
+// [return] *models.Error: error if any
+func BuildDataExports(conn context.Context, client *mongo.Client) *models.Error {
+
+	now := utils.GetCurrentMillis()
+	exports := client.Database(db.CurrentDatabase).Collection(db.DATA_EXPORT)
+
+	expired := []models.DataExport{}
+	found, err := exports.Find(conn, bson.M{"status": models.EXPORT_READY, "expiration_date": bson.M{"$lte": now}})
+
+	if err == nil && found.All(conn, &expired) == nil {
+		for _, export := range expired {
+			expireDataExport(conn, client, &export)
+		}
+	}
+
+	pending := []models.DataExport{}
+	found, err = exports.Find(conn, bson.M{"status": models.EXPORT_PENDING})
+
+	if err != nil || found.All(conn, &pending) != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get the pending exports",
+		}
+	}
+
+	for i := range pending {
+		buildDataExport(conn, client, &pending[i], now)
+	}
+
+	return nil
+}
+
+// Build a data export and store it, failed exports
+// are marked so the user can request a new one
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] export | *models.DataExport: export to build
+// [param] now | int64: current time in milliseconds
+func buildDataExport(conn context.Context, client *mongo.Client, export *models.DataExport, now int64) {
+
+	exports := client.Database(db.CurrentDatabase).Collection(db.DATA_EXPORT)
+	objID, _ := utils.StringToObjectId(export.ID)
+
+	fail := func(reason string) {
+		log.FormattedError("Cannot build export ${0}: ${1}", export.ID, reason)
+		exports.UpdateOne(conn, bson.M{"_id": objID}, bson.M{"$set": bson.M{
+			"status":          models.EXPORT_FAILED,
+			"completion_date": now,
+		}})
+	}
+
+	user, getErr := GetUserById(conn, client, export.User)
+	if getErr != nil {
+		fail(getErr.Message)
+		return
+	}
+
+	files, collectErr := collectUserData(conn, client, user)
+	if collectErr != nil {
+		fail(collectErr.Message)
+		return
+	}
+
+	archive, archiveErr := writeExportArchive(files)
+	if archiveErr != nil {
+		fail(archiveErr.Message)
+		return
+	}
+
+	key := EXPORTS_NAMESPACE + "/" + export.ID + ".zip"
+	err := storage.Current.Put(conn, key, archive, "application/zip")
+
+	if err != nil {
+		fail(err.Error())
+		return
+	}
+
+	_, err = exports.UpdateOne(conn, bson.M{"_id": objID}, bson.M{"$set": bson.M{
+		"status":          models.EXPORT_READY,
+		"object":          key,
+		"size":            len(archive),
+		"completion_date": now,
+		"expiration_date": now + EXPORT_EXPIRATION,
+	}})
+
+	if err != nil {
+		fail(err.Error())
+		return
+	}
+
+	NotifyUser(conn, client, &models.Notification{
+		User:   user.ID,
+		Type:   models.NOTIFICATION_EXPORT,
+		Title:  "Your data export is ready",
+		Source: export.ID,
+	})
+}
+
+// Delete the archive of an export and mark it expired
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] export | *models.DataExport: export to expire
+func expireDataExport(conn context.Context, client *mongo.Client, export *models.DataExport) {
+
+	if export.Object != "" {
+		err := storage.Current.Delete(conn, export.Object)
+
+		if err != nil && err != storage.ErrNotFound {
+			log.FormattedError("Cannot delete export ${0}: ${1}", export.ID, err.Error())
+			return
+		}
+	}
+
+	objID, _ := utils.StringToObjectId(export.ID)
+	exports := client.Database(db.CurrentDatabase).Collection(db.DATA_EXPORT)
+	exports.UpdateOne(conn, bson.M{"_id": objID}, bson.M{
+		"$set":   bson.M{"status": models.EXPORT_EXPIRED},
+		"$unset": bson.M{"object": ""},
+	})
+}
+
+// Collect the data of a user as json files, the uploaded
+// pictures are added as they were stored
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: owner of the data
+//
+// [return] map[string][]byte: files by name --> *models.Error: error if any
+func collectUserData(conn context.Context, client *mongo.Client, user *models.User) (map[string][]byte, *models.Error) {
+
+	database := client.Database(db.CurrentDatabase)
+
+	devices := []models.Device{}
+	teams := []models.Team{}
+	tasks := []models.Task{}
+	comments := []models.Comment{}
+	media := []models.Media{}
+
+	queries := []struct {
+		collection string
+		filter     bson.M
+		found      interface{}
+	}{
+		{db.DEVICE, bson.M{"user": user.Email}, &devices},
+		{db.TEAM, bson.M{"$or": bson.A{bson.M{"owner": user.ID}, bson.M{"members": user.ID}}}, &teams},
+		{db.TASK, bson.M{"owner": user.ID}, &tasks},
+		{db.COMMENT, bson.M{"author": user.ID}, &comments},
+		{db.MEDIA, bson.M{"kind": models.MEDIA_OF_USER, "owner": user.ID}, &media},
+	}
+
+	for _, query := range queries {
+		found, err := database.Collection(query.collection).Find(conn, query.filter)
+
+		if err != nil || found.All(conn, query.found) != nil {
+			return nil, &models.Error{
+				Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+				Error:   int(error.UNEXPECTED_ERROR),
+				Message: "Cannot get the " + query.collection + " of the user",
+			}
+		}
+	}
+
+	// Device tokens are credentials, not data
+	for i := range devices {
+		devices[i].Token = ""
+	}
+
+	preferences, preferencesErr := GetPreferences(conn, client, user)
+	if preferencesErr != nil {
+		return nil, preferencesErr
+	}
+
+	files := map[string][]byte{}
+	documents := map[string]interface{}{
+		"profile.json": map[string]interface{}{
+			"id":          user.ID,
+			"email":       user.Email,
+			"username":    user.Username,
+			"validated":   user.Validated,
+			"profile_pic": user.ProfilePic,
+		},
+		"preferences.json": preferences,
+		"devices.json":     devices,
+		"teams.json":       teams,
+		"tasks.json":       tasks,
+		"comments.json":    comments,
+	}
+
+	for name, document := range documents {
+		encoded, err := json.MarshalIndent(document, "", "  ")
+
+		if err != nil {
+			return nil, &models.Error{
+				Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+				Error:   int(error.UNEXPECTED_ERROR),
+				Message: "Cannot encode " + name,
+			}
+		}
+
+		files[name] = encoded
+	}
+
+	for _, picture := range media {
+		key, found := mediaObjectKey(&picture, 0)
+		if !found {
+			continue
+		}
+
+		content, err := storage.Current.Get(conn, key)
+		if err != nil {
+			log.FormattedError("Cannot export object ${0}: ${1}", key, err.Error())
+			continue
+		}
+
+		files["media/"+picture.ID+path.Ext(key)] = content
+	}
+
+	return files, nil
+}
+
+// Write files on a zip archive, sorted by name
+//
+// [param] files | map[string][]byte: files by name
+//
+// [return] []byte: zip archive --> *models.Error: error if any
+func writeExportArchive(files map[string][]byte) ([]byte, *models.Error) {
+
+	notWritten := &models.Error{
+		Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+		Error:   int(error.UNEXPECTED_ERROR),
+		Message: "Cannot write the export archive",
+	}
+
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+
+	for _, name := range names {
+		writer, err := archive.Create(name)
+		if err != nil {
+			return nil, notWritten
+		}
+
+		_, err = writer.Write(files[name])
+		if err != nil {
+			return nil, notWritten
+		}
+	}
+
+	if archive.Close() != nil {
+		return nil, notWritten
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Hand the teams of a user over before deleting it, owned teams pass
+// to their first admin or member and teams left empty are deleted
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | string: id of the user
+//
+// [return] *models.Error: error if any
+func handOverTeams(conn context.Context, client *mongo.Client, user string) *models.Error {
+
+	notUpdated := &models.Error{
+		Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+		Error:   int(error.USER_NOT_DELETED),
+		Message: "Cannot leave the teams of the user",
+	}
+
+	teams := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+	_, err := teams.UpdateMany(conn, bson.M{"members": user}, bson.M{
+		"$pull":  bson.M{"members": user},
+		"$unset": bson.M{"roles." + user: ""},
+	})
+
+	if err != nil {
+		return notUpdated
+	}
+
+	owned := []models.Team{}
+	found, err := teams.Find(conn, bson.M{"owner": user})
+
+	if err != nil || found.All(conn, &owned) != nil {
+		return notUpdated
+	}
+
+	for i := range owned {
+		team := &owned[i]
+		objID, _ := utils.StringToObjectId(team.ID)
+		next := nextTeamOwner(team)
+
+		if next != "" {
+			_, err = teams.UpdateOne(conn, bson.M{"_id": objID}, bson.M{
+				"$set":   bson.M{"owner": next},
+				"$pull":  bson.M{"members": next},
+				"$unset": bson.M{"roles." + next: ""},
+			})
+
+			if err != nil {
+				return notUpdated
+			}
+
+			continue
+		}
+
+		// Child teams of an empty team become organizations
+		children := []models.Team{}
+		found, err = teams.Find(conn, bson.M{"parent": team.ID})
+
+		if err != nil || found.All(conn, &children) != nil {
+			return notUpdated
+		}
+
+		for j := range children {
+			parentErr := SetTeamParent(conn, client, &children[j], nil)
+			if parentErr != nil {
+				return parentErr
+			}
+		}
+
+		_, err = teams.DeleteOne(conn, bson.M{"_id": objID})
+		if err != nil {
+			return notUpdated
+		}
+
+		deleteMedia(conn, client, team.ProfilePic, models.MEDIA_OF_TEAM, team.ID)
+	}
+
+	return nil
+}
+
+// Get the member who owns a team once its owner is deleted,
+// the first admin or the first member if there are no admins
+//
+// [param] team | *models.Team: team to hand over
+//
+// [return] string: id of the new owner, empty if the team has no members
+func nextTeamOwner(team *models.Team) string {
+
+	for _, member := range team.Members {
+		if member != team.Owner && team.RoleOf(member) == models.TEAM_ROLE_ADMIN {
+			return member
+		}
+	}
+
+	for _, member := range team.Members {
+		if member != team.Owner {
+			return member
+		}
+	}
+
+	return ""
+}
+
+// Change the deletion date of a user
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user to change
+// [param] update | bson.M: update of the deletion date
+//
+// [return] *models.Error: error if any
+func setUserDeletionDate(conn context.Context, client *mongo.Client, user *models.User, update bson.M) *models.Error {
+
+	objID, err := utils.StringToObjectId(user.ID)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.BAD_OBJECT_ID),
+			Message: "Bad object id",
+		}
+	}
+
+	users := client.Database(db.CurrentDatabase).Collection(db.USER)
+	_, err = users.UpdateOne(conn, bson.M{"_id": objID}, update)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.USER_NOT_UPDATED),
+			Message: "User not updated",
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/storage"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Cancel user deletion HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func CancelUserDeletionHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	cancelErr := CancelUserDeletion(conn, client, request.User)
+	if cancelErr != nil {
+		return nil, cancelErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "User deletion cancelled"},
+	}, nil
+}
+
+// Request data export HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func RequestDataExportHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	export, exportErr := RequestDataExport(conn, client, request.User)
+	if exportErr != nil {
+		return nil, exportErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Export requested", "export": export},
+	}, nil
+}
+
+// Get data export HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetDataExportHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	export, getErr := getOwnDataExport(conn, client, request.User, c.Query("id"))
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Export found", "export": export},
+	}, nil
+}
+
+// Download data export HTTP API endpoint, the zip
+// archive is written as the body of the response
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func DownloadDataExportHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	export, getErr := getOwnDataExport(conn, client, request.User, c.Query("id"))
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if export.Status != models.EXPORT_READY {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.EXPORT_NOT_READY),
+			Message: "Export is " + export.Status,
+		}
+	}
+
+	content, err := storage.Current.Get(c.Request.Context(), export.Object)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.EXPORT_NOT_FOUND),
+			Message: "Export not found",
+		}
+	}
+
+	completion := time.UnixMilli(export.CompletionDate)
+	c.Header("Content-Disposition", "attachment; filename=\"valhalla-export-"+completion.UTC().Format("2006-01-02")+".zip\"")
+	c.Data(http.StatusOK, "application/zip", content)
+
+	return nil, nil
+}
+
+// Get an export only if it belongs to the user,
+// exports of other users are not found
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user requesting the export
+// [param] id | string: id of the export
+//
+// [return] *models.DataExport: export found --> *models.Error: error if any
+func getOwnDataExport(conn context.Context, client *mongo.Client, user *models.User, id string) (*models.DataExport, *models.Error) {
+
+	export, getErr := GetDataExport(conn, client, id)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if export.User != user.ID {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.EXPORT_NOT_FOUND),
+			Message: "Export not found",
+		}
+	}
+
+	return export, nil
+}
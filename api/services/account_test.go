@@ -0,0 +1,102 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+)
+
+func TestExportArchive(t *testing.T) {
+
+	files := map[string][]byte{
+		"profile.json":      []byte(`{"username":"valhalla"}`),
+		"media/picture.png": {0x89, 0x50, 0x4e, 0x47},
+		"devices.json":      []byte(`[]`),
+	}
+
+	archive, err := writeExportArchive(files)
+	if err != nil {
+		t.Error("The archive was not written", err)
+		return
+	}
+
+	reader, readErr := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if readErr != nil {
+		t.Error("The archive is not a zip file", readErr)
+		return
+	}
+
+	expected := []string{"devices.json", "media/picture.png", "profile.json"}
+	if len(reader.File) != len(expected) {
+		t.Error("The archive must have every file", len(reader.File))
+		return
+	}
+
+	for i, file := range reader.File {
+		if file.Name != expected[i] {
+			t.Error("The files must be sorted by name", file.Name)
+			return
+		}
+
+		opened, _ := file.Open()
+		content, _ := io.ReadAll(opened)
+		opened.Close()
+
+		if !bytes.Equal(content, files[file.Name]) {
+			t.Error("The file content changed", file.Name)
+			return
+		}
+	}
+
+	log.Info("Export archive checked")
+}
+
+func TestNextTeamOwner(t *testing.T) {
+
+	team := &models.Team{
+		Owner:   "owner",
+		Members: []string{"first", "second", "third"},
+		Roles:   map[string]string{"second": models.TEAM_ROLE_ADMIN},
+	}
+
+	if next := nextTeamOwner(team); next != "second" {
+		t.Error("Admins must own the team before members", next)
+		return
+	}
+
+	team.Roles = nil
+	if next := nextTeamOwner(team); next != "first" {
+		t.Error("The first member must own the team without admins", next)
+		return
+	}
+
+	team.Members = []string{}
+	if next := nextTeamOwner(team); next != "" {
+		t.Error("Teams without members must not be handed over", next)
+		return
+	}
+
+	log.Info("Team hand over checked")
+}
+
+func TestUserDeletionSchedule(t *testing.T) {
+
+	_, err := ScheduleUserDeletion(nil, nil, &models.User{DeletionDate: 1})
+	if err == nil || err.Error != error.USER_PENDING_DELETION {
+		t.Error("A scheduled deletion must not be scheduled again")
+		return
+	}
+
+	err = CancelUserDeletion(nil, nil, &models.User{})
+	if err == nil || err.Error != error.DELETION_NOT_SCHEDULED {
+		t.Error("Only scheduled deletions can be cancelled")
+		return
+	}
+
+	log.Info("User deletion schedule checked")
+}
@@ -1,6 +1,13 @@
 package services
 
-import "github.com/akrck02/valhalla-core/models"
+import (
+	"context"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
 
 func CanEditUser(author *models.User, user *models.User) bool {
 	return author.Email == user.Email
@@ -9,3 +16,247 @@ func CanEditUser(author *models.User, user *models.User) bool {
 func CanSeeUser(author *models.User, user *models.User) bool {
 	return author.Email == user.Email
 }
+
+// Get if the author can see the public profile of a user, this is,
+// the author is the user or shares a team or an organization with the user
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] author | *models.User: user requesting access
+// [param] user | string: id of the user to check
+//
+// [return] bool: true if the author can see the profile
+func CanSeeUserProfile(conn context.Context, client *mongo.Client, author *models.User, user string) bool {
+
+	if author == nil {
+		return false
+	}
+
+	if author.ID == user {
+		return true
+	}
+
+	colleagues, err := getColleagues(conn, client, author)
+	if err != nil {
+		return false
+	}
+
+	for _, colleague := range colleagues {
+		if colleague == user {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Get if the author can see the given project, this is,
+// the author owns the project or belongs to one of its teams,
+// directly or through the team hierarchy
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] author | *models.User: user requesting access
+// [param] project | *models.Project: project to check
+//
+// [return] bool: true if the author can see the project
+func CanSeeProject(conn context.Context, client *mongo.Client, author *models.User, project *models.Project) bool {
+
+	if author == nil || project == nil {
+		return false
+	}
+
+	if project.Owner == author.ID {
+		return true
+	}
+
+	teamIDs := toObjectIds(project.Teams)
+	if len(teamIDs) == 0 {
+		return false
+	}
+
+	teams := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+	found, err := teams.Find(conn, bson.M{"_id": bson.M{"$in": teamIDs}})
+
+	projectTeams := []models.Team{}
+	if err != nil || found.All(conn, &projectTeams) != nil {
+		return false
+	}
+
+	for i := range projectTeams {
+		if TeamRoleOf(conn, client, &projectTeams[i], author.ID) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Get if the author can manage the given project, this is,
+// the author owns the project or manages one of its teams
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] author | *models.User: user requesting access
+// [param] project | *models.Project: project to check
+//
+// [return] bool: true if the author can manage the project
+func CanManageProject(conn context.Context, client *mongo.Client, author *models.User, project *models.Project) bool {
+
+	if author == nil || project == nil {
+		return false
+	}
+
+	if project.Owner == author.ID {
+		return true
+	}
+
+	teamIDs := toObjectIds(project.Teams)
+	if len(teamIDs) == 0 {
+		return false
+	}
+
+	teams := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+	found, err := teams.Find(conn, bson.M{"_id": bson.M{"$in": teamIDs}})
+
+	projectTeams := []models.Team{}
+	if err != nil || found.All(conn, &projectTeams) != nil {
+		return false
+	}
+
+	for i := range projectTeams {
+		if managesTeam(TeamRoleOf(conn, client, &projectTeams[i], author.ID)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Get if the author can see the given task, this is,
+// the author can see the project the task belongs to
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] author | *models.User: user requesting access
+// [param] task | *models.Task: task to check
+//
+// [return] bool: true if the author can see the task
+func CanSeeTask(conn context.Context, client *mongo.Client, author *models.User, task *models.Task) bool {
+
+	if task == nil {
+		return false
+	}
+
+	project, err := getProjectById(conn, client, task.Project)
+
+	if err != nil {
+		return false
+	}
+
+	return CanSeeProject(conn, client, author, project)
+}
+
+// Get if the author can manage the given team, this is,
+// the author is the owner or an admin of the team or of a parent team
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] author | *models.User: user requesting access
+// [param] team | *models.Team: team to check
+//
+// [return] bool: true if the author can manage the team
+func CanManageTeam(conn context.Context, client *mongo.Client, author *models.User, team *models.Team) bool {
+	return author != nil && team != nil && managesTeam(TeamRoleOf(conn, client, team, author.ID))
+}
+
+// Get if the author can see the given team, this is, the team is public
+// or the author is the owner or a member of the team, of a child team
+// or manages a parent team
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] author | *models.User: user requesting access
+// [param] team | *models.Team: team to check
+//
+// [return] bool: true if the author can see the team
+func CanSeeTeam(conn context.Context, client *mongo.Client, author *models.User, team *models.Team) bool {
+
+	if author == nil || team == nil {
+		return false
+	}
+
+	return team.Visibility == models.TEAM_VISIBILITY_PUBLIC || TeamRoleOf(conn, client, team, author.ID) != ""
+}
+
+// Get if the author can remove the user from the given team,
+// admins can only remove members, the owner can remove admins too
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] author | *models.User: user requesting access
+// [param] team | *models.Team: team to check
+// [param] user | string: id of the member to remove
+//
+// [return] bool: true if the author can remove the user
+func CanRemoveMember(conn context.Context, client *mongo.Client, author *models.User, team *models.Team, user string) bool {
+
+	if author == nil || team == nil {
+		return false
+	}
+
+	return removesMember(TeamRoleOf(conn, client, team, author.ID), team.RoleOf(user))
+}
+
+// Get if a team role can manage the team
+//
+// [param] role | string: role of the user on the team
+//
+// [return] bool: true for owners and admins
+func managesTeam(role string) bool {
+	return role == models.TEAM_ROLE_OWNER || role == models.TEAM_ROLE_ADMIN
+}
+
+// Get if a team role can remove a member with another role
+//
+// [param] role | string: role of the user removing
+// [param] removed | string: role of the member to remove
+//
+// [return] bool: true if the member can be removed
+func removesMember(role string, removed string) bool {
+
+	switch role {
+	case models.TEAM_ROLE_OWNER:
+		return true
+	case models.TEAM_ROLE_ADMIN:
+		return removed == models.TEAM_ROLE_MEMBER
+	}
+
+	return false
+}
+
+// Get if the author can see the given media, user pictures are
+// visible to every user and team pictures to the team members
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] author | *models.User: user requesting access
+// [param] media | *models.Media: media to check
+//
+// [return] bool: true if the author can see the media
+func CanSeeMedia(conn context.Context, client *mongo.Client, author *models.User, media *models.Media) bool {
+
+	if author == nil || media == nil {
+		return false
+	}
+
+	switch media.Kind {
+	case models.MEDIA_OF_USER:
+		return true
+	case models.MEDIA_OF_TEAM:
+		team, err := GetTeam(conn, client, &models.Team{ID: media.Owner})
+		return err == nil && CanSeeTeam(conn, client, author, team)
+	}
+
+	return false
+}
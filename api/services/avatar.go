@@ -0,0 +1,139 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/imaging"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+)
+
+const (
+	AVATAR_FORMAT_SVG = "svg"
+	AVATAR_FORMAT_PNG = "png"
+)
+
+const DEFAULT_AVATAR_SIZE = 128
+const MIN_AVATAR_SIZE = 16
+const MAX_AVATAR_SIZE = 512
+
+// A generated default avatar
+type Avatar struct {
+	ContentType string
+	Content     []byte
+	ETag        string
+}
+
+// Generate the default avatar of a user or team without picture,
+// svg avatars show the initials of the name and png avatars an identicon
+//
+// [param] name | string: username or team name
+// [param] size | int: side of the avatar
+// [param] format | string: svg or png
+//
+// [return] *Avatar: generated avatar --> *models.Error: error if any
+func GenerateAvatar(name string, size int, format string) (*Avatar, *models.Error) {
+
+	avatar := &Avatar{ContentType: imaging.CONTENT_TYPE_SVG}
+
+	if format == AVATAR_FORMAT_PNG {
+		content, err := imaging.IdenticonPNG(name, size)
+		if err != nil {
+			return nil, err
+		}
+
+		avatar.ContentType = imaging.CONTENT_TYPE_PNG
+		avatar.Content = content
+	} else {
+		avatar.Content = imaging.InitialsSVG(name, size)
+	}
+
+	// Avatars are deterministic, the same content has the same tag
+	hash := sha256.Sum256(avatar.Content)
+	avatar.ETag = "\"" + hex.EncodeToString(hash[:16]) + "\""
+
+	return avatar, nil
+}
+
+// Parse the path of an avatar url, /user/{id} or /team/{id}
+//
+// [param] path | string: path after the avatar endpoint
+//
+// [return] string: kind of the owner --> string: id of the owner --> *models.Error: error if the path is not valid
+func parseAvatarPath(path string) (string, string, *models.Error) {
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	if len(parts) != 2 || (parts[0] != models.MEDIA_OF_USER && parts[0] != models.MEDIA_OF_TEAM) || parts[1] == "" {
+		return "", "", &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.MEDIA_NOT_FOUND),
+			Message: "Avatar not found",
+		}
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// Parse the ?size= and ?format= of an avatar request
+//
+// [param] size | string: requested size, empty for the default
+// [param] format | string: requested format, empty for svg
+//
+// [return] int: size --> string: format --> *models.Error: error if the parameters are not valid
+func parseAvatarOptions(size string, format string) (int, string, *models.Error) {
+
+	side := DEFAULT_AVATAR_SIZE
+	invalid := &models.Error{
+		Status:  utils.HTTP_STATUS_BAD_REQUEST,
+		Error:   int(error.INVALID_REQUEST),
+		Message: "Avatars must be svg or png between " + utils.Int2String(MIN_AVATAR_SIZE) + " and " + utils.Int2String(MAX_AVATAR_SIZE) + " pixels",
+	}
+
+	if size != "" {
+		parsed, err := strconv.Atoi(size)
+
+		if err != nil || parsed < MIN_AVATAR_SIZE || parsed > MAX_AVATAR_SIZE {
+			return 0, "", invalid
+		}
+
+		side = parsed
+	}
+
+	if format == "" {
+		format = AVATAR_FORMAT_SVG
+	}
+
+	if format != AVATAR_FORMAT_SVG && format != AVATAR_FORMAT_PNG {
+		return 0, "", invalid
+	}
+
+	return side, format, nil
+}
+
+// Get the thumbnail of a picture closest to an avatar size,
+// the smallest one not smaller than the size or the original
+//
+// [param] media | *models.Media: uploaded picture
+// [param] size | int: side of the avatar
+//
+// [return] int: thumbnail size, 0 for the original
+func avatarThumbnailSize(media *models.Media, size int) int {
+
+	best := 0
+	for _, thumbnail := range imaging.THUMBNAIL_SIZES {
+		if _, found := mediaObjectKey(media, thumbnail); !found || thumbnail < size {
+			continue
+		}
+
+		if best == 0 || thumbnail < best {
+			best = thumbnail
+		}
+	}
+
+	return best
+}
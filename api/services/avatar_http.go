@@ -0,0 +1,95 @@
+package services
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Time clients can cache generated avatars, names can change
+const AVATAR_MAX_AGE = 60 * 60
+
+// Get avatar HTTP API endpoint, avatar/user/{id} or avatar/team/{id}
+// with ?size= and ?format=. Streams the uploaded picture or a generated
+// default avatar when there is none
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetAvatarHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	kind, id, pathErr := parseAvatarPath(c.Param("path"))
+	if pathErr != nil {
+		return nil, pathErr
+	}
+
+	size, format, optionsErr := parseAvatarOptions(c.Query("size"), c.Query("format"))
+	if optionsErr != nil {
+		return nil, optionsErr
+	}
+
+	var name, picture string
+
+	if kind == models.MEDIA_OF_USER {
+		user, getErr := GetUserById(conn, client, id)
+		if getErr != nil {
+			return nil, getErr
+		}
+
+		name, picture = user.Username, user.ProfilePic
+	} else {
+		team, getErr := GetTeam(conn, client, &models.Team{ID: id})
+		if getErr != nil {
+			return nil, getErr
+		}
+
+		if !CanSeeTeam(conn, client, request.User, team) {
+			return nil, &models.Error{
+				Status:  utils.HTTP_STATUS_FORBIDDEN,
+				Error:   error.ACCESS_DENIED,
+				Message: "Access denied: Cannot see the team",
+			}
+		}
+
+		name, picture = team.Name, team.ProfilePic
+	}
+
+	// Uploaded pictures are preferred over generated avatars
+	if picture != "" {
+		media, mediaErr := GetMedia(conn, client, picture)
+
+		// Only the picture of the requested user or team is served
+		if mediaErr == nil && media.Kind == kind && media.Owner == id && CanSeeMedia(conn, client, request.User, media) {
+			serveErr := serveMedia(c, media, avatarThumbnailSize(media, size))
+			if serveErr != nil {
+				return nil, serveErr
+			}
+
+			return nil, nil
+		}
+	}
+
+	avatar, avatarErr := GenerateAvatar(name, size, format)
+	if avatarErr != nil {
+		return nil, avatarErr
+	}
+
+	c.Header("ETag", avatar.ETag)
+	c.Header("Cache-Control", "private, max-age="+strconv.Itoa(AVATAR_MAX_AGE))
+	c.Header("Content-Type", avatar.ContentType)
+	http.ServeContent(c.Writer, c.Request, "", time.Time{}, bytes.NewReader(avatar.Content))
+
+	return nil, nil
+}
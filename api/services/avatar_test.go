@@ -0,0 +1,108 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/akrck02/valhalla-core/imaging"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+)
+
+func TestInitials(t *testing.T) {
+
+	var cases = map[string]string{
+		"valhalla core":    "VC",
+		"odin":             "O",
+		"  the all father": "TA",
+		"élan-vital team":  "ÉV",
+		"***":              "?",
+	}
+
+	for name, expected := range cases {
+		if initials := imaging.Initials(name); initials != expected {
+			t.Error("Unexpected initials of "+name, initials)
+			return
+		}
+	}
+
+	log.Info("Initials checked")
+}
+
+func TestGenerateAvatar(t *testing.T) {
+
+	svg, err := GenerateAvatar("Valhalla <core>", 64, AVATAR_FORMAT_SVG)
+
+	if err != nil || svg.ContentType != imaging.CONTENT_TYPE_SVG || !strings.Contains(string(svg.Content), `width="64"`) || strings.Contains(string(svg.Content), "<core>") {
+		t.Error("Unexpected svg avatar", err)
+		return
+	}
+
+	again, _ := GenerateAvatar("Valhalla <core>", 64, AVATAR_FORMAT_SVG)
+	other, _ := GenerateAvatar("Asgard", 64, AVATAR_FORMAT_SVG)
+
+	if again.ETag != svg.ETag || other.ETag == svg.ETag {
+		t.Error("Avatars must be deterministic")
+		return
+	}
+
+	identicon, err := GenerateAvatar("Valhalla", 40, AVATAR_FORMAT_PNG)
+	if err != nil || identicon.ContentType != imaging.CONTENT_TYPE_PNG {
+		t.Error("Unexpected png avatar", err)
+		return
+	}
+
+	decoded, _, decodeErr := image.Decode(bytes.NewReader(identicon.Content))
+	if decodeErr != nil || decoded.Bounds().Dx() != 40 || decoded.Bounds().Dy() != 40 {
+		t.Error("The identicon must have the requested size", decodeErr)
+		return
+	}
+
+	// Identicons are symmetric
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 20; x++ {
+			if decoded.At(x, y) != decoded.At(39-x, y) {
+				t.Error("The identicon must be symmetric")
+				return
+			}
+		}
+	}
+
+	log.Info("Avatars generated")
+}
+
+func TestAvatarOptions(t *testing.T) {
+
+	if size, format, err := parseAvatarOptions("", ""); err != nil || size != DEFAULT_AVATAR_SIZE || format != AVATAR_FORMAT_SVG {
+		t.Error("Unexpected default avatar options", size, format, err)
+		return
+	}
+
+	for _, options := range [][]string{{"8", ""}, {"1024", ""}, {"big", ""}, {"64", "gif"}} {
+		if _, _, err := parseAvatarOptions(options[0], options[1]); err == nil {
+			t.Error("Invalid avatar options must be rejected", options)
+			return
+		}
+	}
+
+	if kind, id, err := parseAvatarPath("/team/65a1b2c3d4e5f6a7b8c9d0e1"); err != nil || kind != models.MEDIA_OF_TEAM || id != "65a1b2c3d4e5f6a7b8c9d0e1" {
+		t.Error("Unexpected avatar path", kind, id, err)
+		return
+	}
+
+	if _, _, err := parseAvatarPath("/project/65a1b2c3d4e5f6a7b8c9d0e1"); err == nil {
+		t.Error("Only users and teams have avatars")
+		return
+	}
+
+	var media = &models.Media{Objects: map[string]string{models.MEDIA_ORIGINAL: "o", "32": "a", "64": "b", "128": "c", "256": "d"}}
+
+	if avatarThumbnailSize(media, 50) != 64 || avatarThumbnailSize(media, 32) != 32 || avatarThumbnailSize(media, 512) != 0 {
+		t.Error("The closest thumbnail must be served")
+		return
+	}
+
+	log.Info("Avatar options checked")
+}
@@ -0,0 +1,513 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const MAXIMUM_CHARACTERS_FOR_COMMENT = 10000
+
+var mentionRegex = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_.\-]+)`)
+
+// Create comment logic, mentioned users are notified
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] author | *models.User: author of the comment
+// [param] comment | *models.Comment: comment to create
+//
+// [return] *models.Error: error if any
+func CreateComment(conn context.Context, client *mongo.Client, author *models.User, comment *models.Comment) *models.Error {
+
+	contentErr := checkCommentContent(comment.Content)
+
+	if contentErr != nil {
+		return contentErr
+	}
+
+	project, targetErr := getCommentTargetProject(conn, client, comment.TargetType, comment.Target)
+
+	if targetErr != nil {
+		return targetErr
+	}
+
+	// Replies must belong to the same target
+	if comment.Parent != "" {
+		parent, parentErr := GetComment(conn, client, comment.Parent)
+
+		if parentErr != nil || parent.Target != comment.Target || parent.TargetType != comment.TargetType {
+			return &models.Error{
+				Status:  utils.HTTP_STATUS_BAD_REQUEST,
+				Error:   int(error.INVALID_COMMENT_PARENT),
+				Message: "Replies must belong to a comment of the same target",
+			}
+		}
+	}
+
+	mentioned := resolveMentions(conn, client, project, comment.Content)
+
+	comment.Author = author.ID
+	comment.Mentions = []string{}
+	comment.History = []models.CommentRevision{}
+	comment.Deleted = false
+	comment.CreationDate = utils.GetCurrentMillis()
+	comment.EditDate = 0
+	comment.DeletionDate = 0
+
+	for _, user := range mentioned {
+		comment.Mentions = append(comment.Mentions, user.ID)
+	}
+
+	comments := client.Database(db.CurrentDatabase).Collection(db.COMMENT)
+	result, err := comments.InsertOne(conn, comment)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.COMMENT_NOT_CREATED),
+			Message: "Comment not created",
+		}
+	}
+
+	comment.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	notifyMentions(conn, client, author, comment, mentioned, []string{})
+	return nil
+}
+
+// Edit comment logic, the previous content is kept in the
+// comment history and new mentions are notified
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] author | *models.User: author of the comment
+// [param] comment | *models.Comment: comment with the new content
+//
+// [return] *models.Error: error if any
+func EditComment(conn context.Context, client *mongo.Client, author *models.User, comment *models.Comment) *models.Error {
+
+	found, getErr := getOwnComment(conn, client, author, comment.ID)
+
+	if getErr != nil {
+		return getErr
+	}
+
+	contentErr := checkCommentContent(comment.Content)
+
+	if contentErr != nil {
+		return contentErr
+	}
+
+	project, targetErr := getCommentTargetProject(conn, client, found.TargetType, found.Target)
+
+	if targetErr != nil {
+		return targetErr
+	}
+
+	mentioned := resolveMentions(conn, client, project, comment.Content)
+	mentions := []string{}
+
+	for _, user := range mentioned {
+		mentions = append(mentions, user.ID)
+	}
+
+	now := utils.GetCurrentMillis()
+	objID, _ := utils.StringToObjectId(found.ID)
+	comments := client.Database(db.CurrentDatabase).Collection(db.COMMENT)
+	_, err := comments.UpdateOne(conn, bson.M{"_id": objID}, bson.M{
+		"$set": bson.M{
+			"content":   comment.Content,
+			"mentions":  mentions,
+			"edit_date": now,
+		},
+		"$push": bson.M{"history": models.CommentRevision{
+			Content: found.Content,
+			Date:    now,
+		}},
+	})
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.COMMENT_NOT_UPDATED),
+			Message: "Comment not updated",
+		}
+	}
+
+	// Users mentioned before the edit were already notified
+	edited := *found
+	edited.Content = comment.Content
+	edited.Mentions = mentions
+	edited.EditDate = now
+
+	notifyMentions(conn, client, author, &edited, mentioned, found.Mentions)
+	return nil
+}
+
+// Delete comment logic, comments are only marked as deleted
+// so the replies keep their place on the thread
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] author | *models.User: author of the comment
+// [param] comment | *models.Comment: comment to delete
+//
+// [return] *models.Error: error if any
+func DeleteComment(conn context.Context, client *mongo.Client, author *models.User, comment *models.Comment) *models.Error {
+
+	found, getErr := getOwnComment(conn, client, author, comment.ID)
+
+	if getErr != nil {
+		return getErr
+	}
+
+	objID, _ := utils.StringToObjectId(found.ID)
+	comments := client.Database(db.CurrentDatabase).Collection(db.COMMENT)
+	_, err := comments.UpdateOne(conn, bson.M{"_id": objID}, bson.M{"$set": bson.M{
+		"deleted":       true,
+		"deletion_date": utils.GetCurrentMillis(),
+	}})
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.COMMENT_NOT_UPDATED),
+			Message: "Comment not deleted",
+		}
+	}
+
+	return nil
+}
+
+// Get comment logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] id | string: id of the comment
+//
+// [return] *models.Comment: comment found --> *models.Error: error if any
+func GetComment(conn context.Context, client *mongo.Client, id string) (*models.Comment, *models.Error) {
+
+	objID, err := utils.StringToObjectId(id)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.BAD_OBJECT_ID),
+			Message: "Bad object id",
+		}
+	}
+
+	comments := client.Database(db.CurrentDatabase).Collection(db.COMMENT)
+
+	var found models.Comment
+	err = comments.FindOne(conn, bson.M{"_id": objID}).Decode(&found)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.COMMENT_NOT_FOUND),
+			Message: "Comment not found",
+		}
+	}
+
+	return &found, nil
+}
+
+// Get the comment threads of a task or wiki page
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] targetType | string: task or wiki
+// [param] target | string: id of the target
+//
+// [return] []models.CommentThread: root comments with their replies --> *models.Error: error if any
+func GetCommentThreads(conn context.Context, client *mongo.Client, targetType string, target string) ([]models.CommentThread, *models.Error) {
+
+	comments := client.Database(db.CurrentDatabase).Collection(db.COMMENT)
+	findOptions := options.Find().SetSort(bson.D{{Key: "creation_date", Value: 1}})
+	cursor, err := comments.Find(conn, bson.M{"target": target, "target_type": targetType}, findOptions)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get comments",
+		}
+	}
+
+	found := []models.Comment{}
+	err = cursor.All(conn, &found)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get comments",
+		}
+	}
+
+	return buildCommentThreads(found), nil
+}
+
+// Build the comment threads, deleted comments lose their
+// content and history but keep their place on the thread
+//
+// [param] comments | []models.Comment: comments sorted by creation date
+//
+// [return] []models.CommentThread: root comments with their replies
+func buildCommentThreads(comments []models.Comment) []models.CommentThread {
+
+	children := map[string][]models.Comment{}
+	roots := []models.Comment{}
+	ids := map[string]bool{}
+
+	for _, comment := range comments {
+		ids[comment.ID] = true
+	}
+
+	for _, comment := range comments {
+		if comment.Deleted {
+			comment.Content = ""
+			comment.Mentions = []string{}
+			comment.History = []models.CommentRevision{}
+		}
+
+		if comment.Parent == "" || !ids[comment.Parent] {
+			roots = append(roots, comment)
+		} else {
+			children[comment.Parent] = append(children[comment.Parent], comment)
+		}
+	}
+
+	var build func(comment models.Comment) models.CommentThread
+	build = func(comment models.Comment) models.CommentThread {
+		thread := models.CommentThread{Comment: comment, Replies: []models.CommentThread{}}
+
+		for _, reply := range children[comment.ID] {
+			thread.Replies = append(thread.Replies, build(reply))
+		}
+
+		return thread
+	}
+
+	threads := []models.CommentThread{}
+	for _, root := range roots {
+		threads = append(threads, build(root))
+	}
+
+	return threads
+}
+
+// Get the usernames mentioned on a text as @username
+//
+// [param] text | string: text to parse
+//
+// [return] []string: unique usernames in order of appearance
+func parseMentions(text string) []string {
+
+	usernames := []string{}
+	seen := map[string]bool{}
+
+	for _, match := range mentionRegex.FindAllStringSubmatch(text, -1) {
+		username := strings.TrimRight(match[1], ".-")
+
+		if username == "" || seen[username] {
+			continue
+		}
+
+		seen[username] = true
+		usernames = append(usernames, username)
+	}
+
+	return usernames
+}
+
+// Resolve the users mentioned on a text, only users
+// that can see the project are taken into account
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] project | *models.Project: project of the comment target
+// [param] text | string: text to parse
+//
+// [return] []models.User: mentioned users
+func resolveMentions(conn context.Context, client *mongo.Client, project *models.Project, text string) []models.User {
+
+	usernames := parseMentions(text)
+	mentioned := []models.User{}
+
+	if len(usernames) == 0 {
+		return mentioned
+	}
+
+	users := client.Database(db.CurrentDatabase).Collection(db.USER)
+	cursor, err := users.Find(conn, bson.M{"username": bson.M{"$in": usernames}})
+
+	if err != nil {
+		return mentioned
+	}
+
+	found := []models.User{}
+	if cursor.All(conn, &found) != nil {
+		return mentioned
+	}
+
+	for _, user := range found {
+		user := user
+		if CanSeeProject(conn, client, &user, project) {
+			mentioned = append(mentioned, user)
+		}
+	}
+
+	return mentioned
+}
+
+// Notify the mentioned users, except the author
+// and the users that were already notified
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] author | *models.User: author of the comment
+// [param] comment | *models.Comment: comment with the mentions
+// [param] mentioned | []models.User: mentioned users
+// [param] alreadyNotified | []string: ids of the users already notified
+func notifyMentions(conn context.Context, client *mongo.Client, author *models.User, comment *models.Comment, mentioned []models.User, alreadyNotified []string) {
+
+	skip := map[string]bool{author.ID: true}
+	for _, id := range alreadyNotified {
+		skip[id] = true
+	}
+
+	for _, user := range mentioned {
+		if skip[user.ID] {
+			continue
+		}
+
+		NotifyUser(conn, client, &models.Notification{
+			User:    user.ID,
+			Type:    models.NOTIFICATION_MENTION,
+			Title:   author.Username + " mentioned you",
+			Message: comment.Content,
+			Source:  comment.ID,
+		})
+	}
+}
+
+// Get the project of a comment target
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] targetType | string: task or wiki
+// [param] target | string: id of the target
+//
+// [return] *models.Project: project of the target --> *models.Error: error if any
+func getCommentTargetProject(conn context.Context, client *mongo.Client, targetType string, target string) (*models.Project, *models.Error) {
+
+	var projectID string
+
+	switch targetType {
+	case models.COMMENT_TARGET_TASK:
+		task, err := GetTask(conn, client, &models.Task{ID: target})
+
+		if err != nil {
+			return nil, err
+		}
+
+		projectID = task.Project
+
+	case models.COMMENT_TARGET_WIKI:
+		objID, err := utils.StringToObjectId(target)
+
+		if err != nil {
+			return nil, &models.Error{
+				Status:  utils.HTTP_STATUS_BAD_REQUEST,
+				Error:   int(error.BAD_OBJECT_ID),
+				Message: "Bad object id",
+			}
+		}
+
+		var wiki struct {
+			Project string `bson:"project"`
+		}
+
+		wikis := client.Database(db.CurrentDatabase).Collection(db.WIKI)
+		err = wikis.FindOne(conn, bson.M{"_id": objID}).Decode(&wiki)
+
+		if err != nil {
+			return nil, &models.Error{
+				Status:  utils.HTTP_STATUS_NOT_FOUND,
+				Error:   int(error.INVALID_COMMENT_TARGET),
+				Message: "Wiki page not found",
+			}
+		}
+
+		projectID = wiki.Project
+
+	default:
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_COMMENT_TARGET),
+			Message: "Comments can only be made on tasks or wiki pages",
+		}
+	}
+
+	return getProjectById(conn, client, projectID)
+}
+
+func getOwnComment(conn context.Context, client *mongo.Client, author *models.User, id string) (*models.Comment, *models.Error) {
+
+	found, err := GetComment(conn, client, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if found.Author != author.ID {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   int(error.NOT_COMMENT_AUTHOR),
+			Message: "Only the author can change the comment",
+		}
+	}
+
+	if found.Deleted {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.COMMENT_DELETED),
+			Message: "Comment was deleted",
+		}
+	}
+
+	return found, nil
+}
+
+func checkCommentContent(content string) *models.Error {
+
+	if utils.IsEmpty(strings.TrimSpace(content)) {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.EMPTY_COMMENT),
+			Message: "Comment cannot be empty",
+		}
+	}
+
+	if len(content) > MAXIMUM_CHARACTERS_FOR_COMMENT {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.COMMENT_TOO_LONG),
+			Message: "Comment must have at most " + utils.Int2String(MAXIMUM_CHARACTERS_FOR_COMMENT) + " characters",
+		}
+	}
+
+	return nil
+}
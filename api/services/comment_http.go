@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Create comment HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func CreateCommentHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var comment *models.Comment = &models.Comment{}
+	err := c.ShouldBindJSON(comment)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	accessErr := checkCommentTargetAccess(conn, client, request.User, comment.TargetType, comment.Target)
+	if accessErr != nil {
+		return nil, accessErr
+	}
+
+	createErr := CreateComment(conn, client, request.User, comment)
+	if createErr != nil {
+		return nil, createErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Comment created", "id": comment.ID, "mentions": comment.Mentions},
+	}, nil
+}
+
+// Edit comment HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func EditCommentHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var comment *models.Comment = &models.Comment{}
+	err := c.ShouldBindJSON(comment)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	editErr := EditComment(conn, client, request.User, comment)
+	if editErr != nil {
+		return nil, editErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Comment updated"},
+	}, nil
+}
+
+// Delete comment HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func DeleteCommentHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var comment *models.Comment = &models.Comment{}
+	err := c.ShouldBindJSON(comment)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	deleteErr := DeleteComment(conn, client, request.User, comment)
+	if deleteErr != nil {
+		return nil, deleteErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Comment deleted"},
+	}, nil
+}
+
+// Get comments HTTP API endpoint, use ?type=task|wiki&target=id
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetCommentsHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	targetType := c.Query("type")
+	target := c.Query("target")
+
+	accessErr := checkCommentTargetAccess(conn, client, request.User, targetType, target)
+	if accessErr != nil {
+		return nil, accessErr
+	}
+
+	threads, getErr := GetCommentThreads(conn, client, targetType, target)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Comments found", "threads": threads},
+	}, nil
+}
+
+// Get comment history HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetCommentHistoryHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	comment, getErr := GetComment(conn, client, c.Query("id"))
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	accessErr := checkCommentTargetAccess(conn, client, request.User, comment.TargetType, comment.Target)
+	if accessErr != nil {
+		return nil, accessErr
+	}
+
+	if comment.Deleted {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   error.COMMENT_DELETED,
+			Message: "Comment was deleted",
+		}
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Comment history", "content": comment.Content, "history": comment.History},
+	}, nil
+}
+
+// Check that the user can see the target of a comment
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user to check
+// [param] targetType | string: task or wiki
+// [param] target | string: id of the target
+//
+// [return] *models.Error: error if the user cannot see the target
+func checkCommentTargetAccess(conn context.Context, client *mongo.Client, user *models.User, targetType string, target string) *models.Error {
+
+	project, targetErr := getCommentTargetProject(conn, client, targetType, target)
+	if targetErr != nil {
+		return targetErr
+	}
+
+	if !CanSeeProject(conn, client, user, project) {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the comments",
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+)
+
+func TestParseMentions(t *testing.T) {
+
+	var text = "@alice can you review this with @bob.smith? Thanks @alice. Mail me at carol@valhalla.com"
+	var mentions = parseMentions(text)
+	var expected = []string{"alice", "bob.smith"}
+
+	if len(mentions) != len(expected) {
+		t.Error("Unexpected mentions", mentions)
+		return
+	}
+
+	for i := range expected {
+		if mentions[i] != expected[i] {
+			t.Error("Unexpected mentions", mentions)
+			return
+		}
+	}
+
+	log.Info("Mentions parsed")
+}
+
+func TestCommentThreads(t *testing.T) {
+
+	var comments = []models.Comment{
+		{ID: "root", Content: "First"},
+		{ID: "reply", Parent: "root", Content: "Second", Deleted: true, History: []models.CommentRevision{{Content: "Old"}}},
+		{ID: "nested", Parent: "reply", Content: "Third"},
+		{ID: "other", Content: "Fourth"},
+	}
+
+	threads := buildCommentThreads(comments)
+
+	if len(threads) != 2 || threads[0].Comment.ID != "root" || threads[1].Comment.ID != "other" {
+		t.Error("Unexpected root comments", threads)
+		return
+	}
+
+	reply := threads[0].Replies[0]
+	if reply.Comment.ID != "reply" || reply.Comment.Content != "" || len(reply.Comment.History) != 0 {
+		t.Error("Deleted replies must keep their place without content", reply)
+		return
+	}
+
+	if len(reply.Replies) != 1 || reply.Replies[0].Comment.ID != "nested" {
+		t.Error("Nested replies must be kept", reply)
+		return
+	}
+
+	log.Info("Comment threads built")
+}
@@ -0,0 +1,502 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Add a dependency between two tasks, the blocker task
+// must be completed before the blocked one
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] dependency | *models.TaskDependency: dependency to add
+//
+// [return] *models.Error: error if any
+func AddTaskDependency(conn context.Context, client *mongo.Client, dependency *models.TaskDependency) *models.Error {
+
+	if dependency.Blocker == dependency.Blocked {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.SELF_DEPENDENCY),
+			Message: "A task cannot block itself",
+		}
+	}
+
+	blocker, err := GetTask(conn, client, &models.Task{ID: dependency.Blocker})
+
+	if err != nil {
+		return err
+	}
+
+	blocked, err := GetTask(conn, client, &models.Task{ID: dependency.Blocked})
+
+	if err != nil {
+		return err
+	}
+
+	// Check the projects are the same or linked
+	linked, err := areProjectsLinked(conn, client, blocker.Project, blocked.Project)
+
+	if err != nil {
+		return err
+	}
+
+	if !linked {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.UNLINKED_PROJECTS),
+			Message: "Tasks must belong to the same or linked projects",
+		}
+	}
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.TASK_DEPENDENCY)
+	count, countErr := coll.CountDocuments(conn, bson.M{"blocker": blocker.ID, "blocked": blocked.ID})
+
+	if countErr != nil || count > 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.DEPENDENCY_EXISTS),
+			Message: "Dependency already exists",
+		}
+	}
+
+	// Check the new edge does not close a cycle
+	cycle, cycleErr := wouldCreateCycle(func(ids []string) ([]string, *models.Error) {
+		return getBlockedTasks(conn, client, ids)
+	}, blocker.ID, blocked.ID)
+
+	if cycleErr != nil {
+		return cycleErr
+	}
+
+	if cycle {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.DEPENDENCY_CYCLE),
+			Message: "Dependency would create a cycle",
+		}
+	}
+
+	toInsert := &models.TaskDependency{
+		Blocker:      blocker.ID,
+		Blocked:      blocked.ID,
+		CreationDate: utils.GetCurrentMillis(),
+	}
+
+	_, insertErr := coll.InsertOne(conn, toInsert)
+
+	if insertErr != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Dependency not created",
+		}
+	}
+
+	return nil
+}
+
+// Remove a dependency between two tasks
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] dependency | *models.TaskDependency: dependency to remove
+//
+// [return] *models.Error: error if any
+func RemoveTaskDependency(conn context.Context, client *mongo.Client, dependency *models.TaskDependency) *models.Error {
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.TASK_DEPENDENCY)
+	result, err := coll.DeleteOne(conn, bson.M{"blocker": dependency.Blocker, "blocked": dependency.Blocked})
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Dependency not removed",
+		}
+	}
+
+	if result.DeletedCount == 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.DEPENDENCY_NOT_FOUND),
+			Message: "Dependency not found",
+		}
+	}
+
+	return nil
+}
+
+// Get the dependencies of a task in both directions
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] task | string: id of the task
+//
+// [return] []models.TaskDependency: dependencies where the task is blocked or blocks --> *models.Error: error if any
+func GetTaskDependencies(conn context.Context, client *mongo.Client, task string) ([]models.TaskDependency, *models.Error) {
+
+	return findDependencies(conn, client, bson.M{"$or": bson.A{
+		bson.M{"blocker": task},
+		bson.M{"blocked": task},
+	}})
+}
+
+// Get the project gantt data, this is, the earliest and latest
+// start of every task and the critical path of the task graph
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] project | *models.Project: project to schedule
+// [param] start | int64: date in milliseconds where the schedule starts
+//
+// [return] []models.GanttTask: scheduled tasks | []string: critical path | int: total duration in days --> *models.Error: error if any
+func GetProjectGantt(conn context.Context, client *mongo.Client, project *models.Project, start int64) ([]models.GanttTask, []string, int, *models.Error) {
+
+	tasks := client.Database(db.CurrentDatabase).Collection(db.TASK)
+	cursor, err := tasks.Find(conn, bson.M{"project": project.ID})
+
+	if err != nil {
+		return nil, nil, 0, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get tasks",
+		}
+	}
+
+	projectTasks := []models.Task{}
+	err = cursor.All(conn, &projectTasks)
+
+	if err != nil {
+		return nil, nil, 0, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get tasks",
+		}
+	}
+
+	ids := []string{}
+	for _, task := range projectTasks {
+		ids = append(ids, task.ID)
+	}
+
+	dependencies, depErr := findDependencies(conn, client, bson.M{"blocked": bson.M{"$in": ids}})
+
+	if depErr != nil {
+		return nil, nil, 0, depErr
+	}
+
+	return computeSchedule(projectTasks, dependencies, utils.StartOfDay(start))
+}
+
+// Calculate the schedule of a task graph using the critical path method.
+// Completed tasks take no time, tasks without duration take one day.
+// Dependencies with tasks outside the graph are ignored.
+//
+// [param] tasks | []models.Task: tasks to schedule
+// [param] dependencies | []models.TaskDependency: dependencies between the tasks
+// [param] start | int64: date in milliseconds of the first schedule day
+//
+// [return] []models.GanttTask: scheduled tasks | []string: critical path | int: total duration in days --> *models.Error: error if any
+func computeSchedule(tasks []models.Task, dependencies []models.TaskDependency, start int64) ([]models.GanttTask, []string, int, *models.Error) {
+
+	nodes := map[string]*models.GanttTask{}
+	order := []string{}
+
+	for _, task := range tasks {
+		duration := task.Duration
+
+		if duration <= 0 {
+			duration = 1
+		}
+
+		if task.Done {
+			duration = 0
+		}
+
+		nodes[task.ID] = &models.GanttTask{Task: task.ID, Name: task.Name, Duration: duration}
+		order = append(order, task.ID)
+	}
+
+	successors := map[string][]string{}
+	predecessors := map[string][]string{}
+	pending := map[string]int{}
+
+	for _, dependency := range dependencies {
+		_, blockerFound := nodes[dependency.Blocker]
+		_, blockedFound := nodes[dependency.Blocked]
+
+		if !blockerFound || !blockedFound {
+			continue
+		}
+
+		successors[dependency.Blocker] = append(successors[dependency.Blocker], dependency.Blocked)
+		predecessors[dependency.Blocked] = append(predecessors[dependency.Blocked], dependency.Blocker)
+		pending[dependency.Blocked]++
+	}
+
+	// Topological sort (Kahn)
+	sorted := []string{}
+	queue := []string{}
+
+	for _, id := range order {
+		if pending[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		sorted = append(sorted, id)
+
+		for _, next := range successors[id] {
+			pending[next]--
+
+			if pending[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	if len(sorted) != len(order) {
+		return nil, nil, 0, &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.DEPENDENCY_CYCLE),
+			Message: "The task graph has a cycle",
+		}
+	}
+
+	// Forward pass
+	total := 0
+	for _, id := range sorted {
+		node := nodes[id]
+
+		for _, previous := range predecessors[id] {
+			if nodes[previous].EarliestFinish > node.EarliestStart {
+				node.EarliestStart = nodes[previous].EarliestFinish
+			}
+		}
+
+		node.EarliestFinish = node.EarliestStart + node.Duration
+
+		if node.EarliestFinish > total {
+			total = node.EarliestFinish
+		}
+	}
+
+	// Backward pass
+	for i := len(sorted) - 1; i >= 0; i-- {
+		node := nodes[sorted[i]]
+		node.LatestFinish = total
+
+		for _, next := range successors[node.Task] {
+			if nodes[next].LatestStart < node.LatestFinish {
+				node.LatestFinish = nodes[next].LatestStart
+			}
+		}
+
+		node.LatestStart = node.LatestFinish - node.Duration
+		node.Slack = node.LatestStart - node.EarliestStart
+		node.Critical = node.Slack == 0
+		node.StartDate = start + int64(node.EarliestStart)*utils.MILLIS_PER_DAY
+		node.EndDate = start + int64(node.EarliestFinish)*utils.MILLIS_PER_DAY
+	}
+
+	// Follow the critical tasks from the first one that starts the project
+	criticalPath := []string{}
+	current := ""
+
+	for _, id := range sorted {
+		if nodes[id].Critical && nodes[id].EarliestStart == 0 && nodes[id].Duration > 0 {
+			current = id
+			break
+		}
+	}
+
+	for current != "" {
+		criticalPath = append(criticalPath, current)
+		next := ""
+
+		for _, candidate := range successors[current] {
+			if nodes[candidate].Critical && nodes[candidate].EarliestStart == nodes[current].EarliestFinish {
+				next = candidate
+				break
+			}
+		}
+
+		current = next
+	}
+
+	schedule := []models.GanttTask{}
+	for _, id := range sorted {
+		schedule = append(schedule, *nodes[id])
+	}
+
+	sort.SliceStable(schedule, func(i, j int) bool {
+		return schedule[i].EarliestStart < schedule[j].EarliestStart
+	})
+
+	return schedule, criticalPath, total, nil
+}
+
+// Get if adding the blocker -> blocked edge would create a cycle,
+// this is, if the blocker is already reachable from the blocked task
+//
+// [param] successors | func([]string) ([]string, *models.Error): tasks blocked by the given ones
+// [param] blocker | string: blocker task
+// [param] blocked | string: blocked task
+//
+// [return] bool: true if a cycle would be created --> *models.Error: error if any
+func wouldCreateCycle(successors func([]string) ([]string, *models.Error), blocker string, blocked string) (bool, *models.Error) {
+
+	visited := map[string]bool{blocked: true}
+	frontier := []string{blocked}
+
+	for len(frontier) > 0 {
+		next, err := successors(frontier)
+
+		if err != nil {
+			return false, err
+		}
+
+		frontier = []string{}
+		for _, id := range next {
+			if id == blocker {
+				return true, nil
+			}
+
+			if !visited[id] {
+				visited[id] = true
+				frontier = append(frontier, id)
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// Get the open tasks blocking the given one
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] task | string: id of the blocked task
+//
+// [return] []models.Task: open blockers --> *models.Error: error if any
+func getOpenBlockers(conn context.Context, client *mongo.Client, task string) ([]models.Task, *models.Error) {
+
+	dependencies, err := findDependencies(conn, client, bson.M{"blocked": task})
+
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, dependency := range dependencies {
+		ids = append(ids, dependency.Blocker)
+	}
+
+	blockers, err := getTasksById(conn, client, ids)
+
+	if err != nil {
+		return nil, err
+	}
+
+	open := []models.Task{}
+	for _, blocker := range blockers {
+		if !blocker.Done {
+			open = append(open, blocker)
+		}
+	}
+
+	return open, nil
+}
+
+func getBlockedTasks(conn context.Context, client *mongo.Client, blockers []string) ([]string, *models.Error) {
+
+	dependencies, err := findDependencies(conn, client, bson.M{"blocker": bson.M{"$in": blockers}})
+
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := []string{}
+	for _, dependency := range dependencies {
+		blocked = append(blocked, dependency.Blocked)
+	}
+
+	return blocked, nil
+}
+
+func findDependencies(conn context.Context, client *mongo.Client, filter bson.M) ([]models.TaskDependency, *models.Error) {
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.TASK_DEPENDENCY)
+	cursor, err := coll.Find(conn, filter)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get dependencies",
+		}
+	}
+
+	dependencies := []models.TaskDependency{}
+	err = cursor.All(conn, &dependencies)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get dependencies",
+		}
+	}
+
+	return dependencies, nil
+}
+
+// Get if two projects are the same or linked, this is,
+// they are shared with at least one common team
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] first | string: id of the first project
+// [param] second | string: id of the second project
+//
+// [return] bool: true if the projects are linked --> *models.Error: error if any
+func areProjectsLinked(conn context.Context, client *mongo.Client, first string, second string) (bool, *models.Error) {
+
+	if first == second {
+		return true, nil
+	}
+
+	firstProject, err := getProjectById(conn, client, first)
+
+	if err != nil {
+		return false, err
+	}
+
+	secondProject, err := getProjectById(conn, client, second)
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, team := range firstProject.Teams {
+		for _, other := range secondProject.Teams {
+			if team == other {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
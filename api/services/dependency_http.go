@@ -0,0 +1,207 @@
+package services
+
+import (
+	"strconv"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Add task dependency HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func AddTaskDependencyHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *models.TaskDependency = &models.TaskDependency{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	for _, id := range []string{params.Blocker, params.Blocked} {
+		task, getErr := GetTask(conn, client, &models.Task{ID: id})
+		if getErr != nil {
+			return nil, getErr
+		}
+
+		if !CanSeeTask(conn, client, request.User, task) {
+			return nil, &models.Error{
+				Status:  utils.HTTP_STATUS_FORBIDDEN,
+				Error:   error.ACCESS_DENIED,
+				Message: "Access denied: Cannot edit the task",
+			}
+		}
+	}
+
+	var addErr = AddTaskDependency(conn, client, params)
+	if addErr != nil {
+		return nil, addErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Dependency added"},
+	}, nil
+}
+
+// Remove task dependency HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func RemoveTaskDependencyHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *models.TaskDependency = &models.TaskDependency{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	task, getErr := GetTask(conn, client, &models.Task{ID: params.Blocked})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if !CanSeeTask(conn, client, request.User, task) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot edit the task",
+		}
+	}
+
+	var removeErr = RemoveTaskDependency(conn, client, params)
+	if removeErr != nil {
+		return nil, removeErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Dependency removed"},
+	}, nil
+}
+
+// Get task dependencies HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetTaskDependenciesHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	task, getErr := GetTask(conn, client, &models.Task{ID: c.Query("id")})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if !CanSeeTask(conn, client, request.User, task) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the task",
+		}
+	}
+
+	dependencies, depErr := GetTaskDependencies(conn, client, task.ID)
+	if depErr != nil {
+		return nil, depErr
+	}
+
+	blockedBy := []string{}
+	blocks := []string{}
+
+	for _, dependency := range dependencies {
+		if dependency.Blocked == task.ID {
+			blockedBy = append(blockedBy, dependency.Blocker)
+		} else {
+			blocks = append(blocks, dependency.Blocked)
+		}
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Dependencies found", "blocked_by": blockedBy, "blocks": blocks},
+	}, nil
+}
+
+// Get project gantt HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetProjectGanttHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	project, projectErr := getProjectById(conn, client, c.Query("id"))
+	if projectErr != nil {
+		return nil, projectErr
+	}
+
+	if !CanSeeProject(conn, client, request.User, project) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the project",
+		}
+	}
+
+	start := utils.GetCurrentMillis()
+	if c.Query("start") != "" {
+		parsed, err := strconv.ParseInt(c.Query("start"), 10, 64)
+		if err != nil {
+			return nil, &models.Error{
+				Status:  utils.HTTP_STATUS_BAD_REQUEST,
+				Error:   error.INVALID_REQUEST,
+				Message: "Start must be a date in milliseconds",
+			}
+		}
+
+		start = parsed
+	}
+
+	schedule, criticalPath, duration, ganttErr := GetProjectGantt(conn, client, project, start)
+	if ganttErr != nil {
+		return nil, ganttErr
+	}
+
+	return &models.Response{
+		Code: utils.HTTP_STATUS_OK,
+		Response: gin.H{
+			"message":       "Project schedule",
+			"tasks":         schedule,
+			"critical_path": criticalPath,
+			"duration":      duration,
+		},
+	}, nil
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+)
+
+func TestDependencyCycle(t *testing.T) {
+
+	// a -> b -> c
+	var graph = map[string][]string{
+		"a": {"b"},
+		"b": {"c"},
+	}
+
+	successors := func(ids []string) ([]string, *models.Error) {
+		next := []string{}
+		for _, id := range ids {
+			next = append(next, graph[id]...)
+		}
+		return next, nil
+	}
+
+	cycle, err := wouldCreateCycle(successors, "c", "a")
+
+	if err != nil || !cycle {
+		t.Error("c -> a must be detected as a cycle")
+		return
+	}
+
+	cycle, err = wouldCreateCycle(successors, "a", "c")
+
+	if err != nil || cycle {
+		t.Error("a -> c must not be detected as a cycle")
+		return
+	}
+
+	log.Info("Dependency cycles detected")
+}
+
+func TestCriticalPath(t *testing.T) {
+
+	var tasks = []models.Task{
+		{ID: "design", Duration: 2},
+		{ID: "backend", Duration: 5},
+		{ID: "frontend", Duration: 3},
+		{ID: "release", Duration: 1},
+		{ID: "docs", Duration: 1, Done: true},
+	}
+
+	var dependencies = []models.TaskDependency{
+		{Blocker: "design", Blocked: "backend"},
+		{Blocker: "design", Blocked: "frontend"},
+		{Blocker: "backend", Blocked: "release"},
+		{Blocker: "frontend", Blocked: "release"},
+		{Blocker: "docs", Blocked: "release"},
+		{Blocker: "external", Blocked: "release"},
+	}
+
+	schedule, criticalPath, duration, err := computeSchedule(tasks, dependencies, 0)
+
+	if err != nil {
+		t.Error("The schedule was not calculated", err)
+		return
+	}
+
+	if duration != 8 {
+		t.Error("The project must take 8 days", duration)
+		return
+	}
+
+	var expected = []string{"design", "backend", "release"}
+	if len(criticalPath) != len(expected) {
+		t.Error("Unexpected critical path", criticalPath)
+		return
+	}
+
+	for i := range expected {
+		if criticalPath[i] != expected[i] {
+			t.Error("Unexpected critical path", criticalPath)
+			return
+		}
+	}
+
+	for _, task := range schedule {
+		if task.Task == "frontend" && (task.EarliestStart != 2 || task.Slack != 2 || task.Critical) {
+			t.Error("The frontend must start on day 2 with 2 days of slack", task)
+			return
+		}
+	}
+
+	log.Info("Critical path calculated")
+}
+
+func TestCriticalPathCycle(t *testing.T) {
+
+	var tasks = []models.Task{{ID: "a"}, {ID: "b"}}
+	var dependencies = []models.TaskDependency{
+		{Blocker: "a", Blocked: "b"},
+		{Blocker: "b", Blocked: "a"},
+	}
+
+	_, _, _, err := computeSchedule(tasks, dependencies, 0)
+
+	if err == nil || err.Error != error.DEPENDENCY_CYCLE {
+		t.Error("A cyclic graph must not be scheduled")
+		return
+	}
+
+	log.Info("Cyclic graph not scheduled")
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Search the users sharing a team or organization with the
+// user, by username or email prefix, case insensitive.
+// Only the public profiles are returned
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user searching
+// [param] searchText | string: username or email prefix
+// [param] request | *models.PageRequest: cursor and limit of the page
+//
+// [return] *models.Page: page of profiles sorted by username --> *models.Error: error if any
+func SearchUsers(conn context.Context, client *mongo.Client, user *models.User, searchText string, request *models.PageRequest) (*models.Page, *models.Error) {
+
+	searchText = strings.TrimSpace(searchText)
+
+	if searchText == "" {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.USER_SEARCH_ERROR),
+			Message: "Search text cannot be empty",
+		}
+	}
+
+	_, descending, sortErr := parseSort(request.Sort, []string{models.SORT_BY_NAME})
+	if sortErr != nil {
+		return nil, sortErr
+	}
+
+	cursor, cursorErr := decodeCursor(request.Cursor)
+	if cursorErr != nil {
+		return nil, cursorErr
+	}
+
+	colleagues, colleaguesErr := getColleagues(conn, client, user)
+	if colleaguesErr != nil {
+		return nil, colleaguesErr
+	}
+
+	searchErr := &models.Error{
+		Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+		Error:   int(error.USER_SEARCH_ERROR),
+		Message: "Cannot get users",
+	}
+
+	prefix := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(searchText), Options: "i"}
+	filter := bson.M{
+		"_id": bson.M{"$in": toObjectIds(colleagues)},
+		"$or": bson.A{
+			bson.M{"username": prefix},
+			bson.M{"email": prefix},
+		},
+	}
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.USER)
+	total, err := coll.CountDocuments(conn, filter)
+
+	if err != nil {
+		return nil, searchErr
+	}
+
+	if cursor != nil {
+		after, keysetErr := keysetFilter("username", descending, cursor)
+		if keysetErr != nil {
+			return nil, keysetErr
+		}
+
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	// One more user than the limit tells if there is a next page
+	users := []models.User{}
+	found, err := coll.Find(conn, filter, options.Find().
+		SetSort(keysetSort("username", descending)).
+		SetLimit(int64(request.Limit+1)))
+
+	if err != nil || found.All(conn, &users) != nil {
+		return nil, searchErr
+	}
+
+	page := &models.Page{Total: total, Limit: request.Limit}
+
+	if len(users) > request.Limit {
+		users = users[:request.Limit]
+		last := users[len(users)-1]
+		page.NextCursor = encodeCursor(pageCursor{Value: last.Username, ID: last.ID})
+	}
+
+	profiles := []models.UserProfile{}
+	for _, found := range users {
+		profiles = append(profiles, found.PublicProfile())
+	}
+
+	page.Items = profiles
+	return page, nil
+}
+
+// Get the public profile of a user
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] id | string: id of the user
+//
+// [return] *models.UserProfile: public profile --> *models.Error: error if any
+func GetUserProfile(conn context.Context, client *mongo.Client, id string) (*models.UserProfile, *models.Error) {
+
+	found, getErr := GetUserById(conn, client, id)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	profile := found.PublicProfile()
+	return &profile, nil
+}
+
+// Get the ids of the users sharing a team or an organization
+// with the user, the user included
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user to get the colleagues of
+//
+// [return] []string: ids of the users --> *models.Error: error if any
+func getColleagues(conn context.Context, client *mongo.Client, user *models.User) ([]string, *models.Error) {
+
+	searchErr := &models.Error{
+		Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+		Error:   int(error.USER_SEARCH_ERROR),
+		Message: "Cannot get users",
+	}
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+	found, err := coll.Find(conn, bson.M{"$or": bson.A{
+		bson.M{"owner": user.ID},
+		bson.M{"members": user.ID},
+	}}, options.Find().SetProjection(bson.M{"_id": 1, "ancestors": 1}))
+
+	teams := []models.Team{}
+	if err != nil || found.All(conn, &teams) != nil {
+		return nil, searchErr
+	}
+
+	organizations := organizationsOf(teams)
+	if len(organizations) == 0 {
+		return []string{user.ID}, nil
+	}
+
+	found, err = coll.Find(conn, bson.M{"$or": bson.A{
+		bson.M{"_id": bson.M{"$in": toObjectIds(organizations)}},
+		bson.M{"ancestors": bson.M{"$in": organizations}},
+	}}, options.Find().SetProjection(bson.M{"owner": 1, "members": 1}))
+
+	teams = []models.Team{}
+	if err != nil || found.All(conn, &teams) != nil {
+		return nil, searchErr
+	}
+
+	return append(membersOf(teams), user.ID), nil
+}
+
+// Get the distinct organizations of some teams
+//
+// [param] teams | []models.Team: teams with their ancestors
+//
+// [return] []string: ids of the organizations
+func organizationsOf(teams []models.Team) []string {
+
+	organizations := []string{}
+	seen := map[string]bool{}
+
+	for i := range teams {
+		organization := teams[i].Organization()
+
+		if !seen[organization] {
+			seen[organization] = true
+			organizations = append(organizations, organization)
+		}
+	}
+
+	return organizations
+}
+
+// Get the distinct owners and members of some teams
+//
+// [param] teams | []models.Team: teams with their owners and members
+//
+// [return] []string: ids of the users
+func membersOf(teams []models.Team) []string {
+
+	members := []string{}
+	seen := map[string]bool{}
+
+	for _, team := range teams {
+		for _, member := range append([]string{team.Owner}, team.Members...) {
+			if member != "" && !seen[member] {
+				seen[member] = true
+				members = append(members, member)
+			}
+		}
+	}
+
+	return members
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Search users HTTP API endpoint, searches ?text= paginated with ?cursor=, ?limit= and ?sort=
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func SearchUsersHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	pageRequest, err := utils.GetPageRequest(c)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_PAGE,
+			Message: "Invalid page: " + err.Error(),
+		}
+	}
+
+	users, searchErr := SearchUsers(conn, client, request.User, c.Query("text"), pageRequest)
+	if searchErr != nil {
+		return nil, searchErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Users found", "page": users},
+	}, nil
+}
+
+// Get user public profile HTTP API endpoint, by ?id=
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetUserProfileHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	id := c.Query("id")
+	if id == "" {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Id cannot be empty",
+		}
+	}
+
+	if !CanSeeUserProfile(conn, client, request.User, id) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the user",
+		}
+	}
+
+	profile, getErr := GetUserProfile(conn, client, id)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "User found", "user": profile},
+	}, nil
+}
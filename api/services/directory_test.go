@@ -0,0 +1,62 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+)
+
+func TestColleagues(t *testing.T) {
+
+	var teams = []models.Team{
+		{ID: "org", Owner: "ceo", Members: []string{"head"}},
+		{ID: "department", Ancestors: []string{"org"}, Owner: "head", Members: []string{"developer"}},
+		{ID: "squad", Ancestors: []string{"org", "department"}, Owner: "developer", Members: []string{"head"}},
+		{ID: "other", Owner: "stranger"},
+	}
+
+	organizations := organizationsOf(teams)
+	if len(organizations) != 2 || organizations[0] != "org" || organizations[1] != "other" {
+		t.Error("Unexpected organizations", organizations)
+		return
+	}
+
+	members := membersOf(teams[:3])
+	if len(members) != 3 || members[0] != "ceo" || members[1] != "head" || members[2] != "developer" {
+		t.Error("Unexpected members", members)
+		return
+	}
+
+	log.Info("Colleagues checked")
+}
+
+func TestPublicProfile(t *testing.T) {
+
+	var user = &models.User{
+		ID:             "user",
+		Email:          "user@valhalla.org",
+		Password:       "hash",
+		Username:       "user",
+		ValidationCode: "code",
+		ProfilePic:     "picture",
+	}
+
+	profile := user.PublicProfile()
+	if profile.ID != user.ID || profile.Username != user.Username || profile.ProfilePic != user.ProfilePic {
+		t.Error("Unexpected profile", profile)
+		return
+	}
+
+	// Secure gets leave the password out of the response
+	user.Password = ""
+	encoded, _ := json.Marshal(user)
+	if strings.Contains(string(encoded), "Password") {
+		t.Error("Users without password must not have the password field", string(encoded))
+		return
+	}
+
+	log.Info("Public profile checked")
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/mail"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Time the code of an email change can be used
+const EMAIL_CHANGE_EXPIRATION = utils.MILLIS_PER_DAY
+
+// Length of the code sent to the new address
+const EMAIL_CHANGE_CODE_LENGTH = 8
+
+// Wrong codes allowed before the change must be requested again
+const EMAIL_CHANGE_MAX_ATTEMPTS = 5
+
+// Request to change the email of a user, a code is sent to the new
+// address and a notice to the current one. A new request replaces
+// the pending one
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] request | *EmailChangeRequest: current and new email
+//
+// [return] string: code sent to the new address --> *models.Error: error if any
+func requestEmailChange(conn context.Context, client *mongo.Client, request *EmailChangeRequest) (string, *models.Error) {
+
+	if utils.IsEmpty(request.Email) || utils.IsEmpty(request.NewEmail) {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.EMPTY_EMAIL),
+			Message: "Email cannot be empty",
+		}
+	}
+
+	// Equal emails
+	if request.Email == request.NewEmail {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.EMAILS_EQUAL),
+			Message: "The new email is the same as the old one",
+		}
+	}
+
+	// validate email
+	var checkedPass = utils.ValidateEmail(request.Email)
+	if checkedPass.Response != 200 {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(checkedPass.Response),
+			Message: checkedPass.Message,
+		}
+	}
+
+	// Check the new email is free
+	users := client.Database(db.CurrentDatabase).Collection(db.USER)
+	found := mailExists(request.NewEmail, conn, users)
+
+	if found != nil {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.USER_ALREADY_EXISTS),
+			Message: "That email is already in use",
+		}
+	}
+
+	var checkedEmail = utils.ValidateEmail(request.NewEmail)
+	if checkedEmail.Response != 200 {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(checkedEmail.Response),
+			Message: checkedEmail.Message,
+		}
+	}
+
+	var user models.User
+	err := users.FindOne(conn, bson.M{"email": request.Email}).Decode(&user)
+
+	if err != nil {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.USER_NOT_FOUND),
+			Message: "User not found",
+		}
+	}
+
+	notCreated := &models.Error{
+		Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+		Error:   int(error.EMAIL_CHANGE_NOT_CREATED),
+		Message: "Email change not created",
+	}
+
+	code, err := utils.GenerateOTP(EMAIL_CHANGE_CODE_LENGTH)
+	if err != nil {
+		return "", notCreated
+	}
+
+	now := utils.GetCurrentMillis()
+	changes := client.Database(db.CurrentDatabase).Collection(db.EMAIL_CHANGE)
+	_, err = changes.ReplaceOne(conn, bson.M{"user": user.ID}, models.EmailChange{
+		User:           user.ID,
+		Email:          request.Email,
+		NewEmail:       request.NewEmail,
+		Code:           utils.EncryptSha256(code),
+		CreationDate:   now,
+		ExpirationDate: now + EMAIL_CHANGE_EXPIRATION,
+	}, options.Replace().SetUpsert(true))
+
+	if err != nil {
+		return "", notCreated
+	}
+
+	locale := userLocale(conn, client, &user)
+	sendEmail(conn, client, locale, request.NewEmail, mail.TEMPLATE_EMAIL_CHANGE, map[string]interface{}{
+		"Username": user.Username,
+		"NewEmail": request.NewEmail,
+		"Code":     code,
+	})
+
+	sendEmail(conn, client, locale, request.Email, mail.TEMPLATE_EMAIL_CHANGE_NOTICE, map[string]interface{}{
+		"Username": user.Username,
+		"NewEmail": request.NewEmail,
+	})
+
+	return code, nil
+}
+
+// Confirm the change of the email of a user, the devices of the user
+// get new tokens as the old ones carry the old email
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user changing the email
+// [param] code | string: code sent to the new address
+// [param] token | string: token of the device confirming the change
+//
+// [return] string: new token of the device --> *models.Error: error if any
+func ConfirmEmailChange(conn context.Context, client *mongo.Client, user *models.User, code string, token string) (string, *models.Error) {
+
+	changes := client.Database(db.CurrentDatabase).Collection(db.EMAIL_CHANGE)
+
+	var change models.EmailChange
+	err := changes.FindOne(conn, bson.M{"user": user.ID}).Decode(&change)
+
+	if err != nil {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.EMAIL_CHANGE_NOT_FOUND),
+			Message: "There is no pending email change",
+		}
+	}
+
+	checkErr := checkEmailChangeCode(&change, code, utils.GetCurrentMillis())
+	if checkErr != nil {
+		if checkErr.Error == error.INVALID_EMAIL_CHANGE_CODE {
+			changes.UpdateOne(conn, bson.M{"user": user.ID}, bson.M{"$inc": bson.M{"attempts": 1}})
+		}
+
+		return "", checkErr
+	}
+
+	users := client.Database(db.CurrentDatabase).Collection(db.USER)
+	if mailExists(change.NewEmail, conn, users) != nil {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.USER_ALREADY_EXISTS),
+			Message: "That email is already in use",
+		}
+	}
+
+	objID, _ := utils.StringToObjectId(user.ID)
+	result, err := users.UpdateOne(conn,
+		bson.M{"_id": objID, "email": change.Email},
+		bson.M{"$set": bson.M{"email": change.NewEmail}},
+	)
+
+	if err != nil || result.MatchedCount == 0 {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.USER_NOT_UPDATED),
+			Message: "User not updated",
+		}
+	}
+
+	changes.DeleteOne(conn, bson.M{"user": user.ID})
+
+	changed := user.Clone()
+	changed.Email = change.NewEmail
+	return reissueDeviceTokens(conn, client, changed, change.Email, token)
+}
+
+// Check the code of an email change
+//
+// [param] change | *models.EmailChange: pending email change
+// [param] code | string: code sent to the new address
+// [param] now | int64: current time in milliseconds
+//
+// [return] *models.Error: error if the code cannot confirm the change
+func checkEmailChangeCode(change *models.EmailChange, code string, now int64) *models.Error {
+
+	if change.ExpirationDate <= now || change.Attempts >= EMAIL_CHANGE_MAX_ATTEMPTS {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_GONE,
+			Error:   int(error.EMAIL_CHANGE_EXPIRED),
+			Message: "Email change expired, request it again",
+		}
+	}
+
+	hashed := utils.EncryptSha256(code)
+	if utils.IsEmpty(code) || subtle.ConstantTimeCompare([]byte(hashed), []byte(change.Code)) != 1 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_EMAIL_CHANGE_CODE),
+			Message: "Invalid email change code",
+		}
+	}
+
+	return nil
+}
+
+// Move the devices of a user to a new email with new tokens,
+// the old tokens stop being valid
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user with the new email
+// [param] oldEmail | string: previous email of the user
+// [param] token | string: old token of the current device
+//
+// [return] string: new token of the current device --> *models.Error: error if any
+func reissueDeviceTokens(conn context.Context, client *mongo.Client, user *models.User, oldEmail string, token string) (string, *models.Error) {
+
+	notUpdated := &models.Error{
+		Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+		Error:   int(error.USER_NOT_UPDATED),
+		Message: "User devices not updated",
+	}
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.DEVICE)
+	found, err := coll.Find(conn, bson.M{"user": oldEmail})
+
+	devices := []models.Device{}
+	if err != nil || found.All(conn, &devices) != nil {
+		return "", notUpdated
+	}
+
+	current := ""
+	for _, device := range devices {
+		oldToken := device.Token
+		device.User = user.Email
+
+		newToken, err := utils.GenerateAuthToken(user, &device)
+		if err != nil {
+			log.FormattedError("Cannot generate token: ${0}", err.Error())
+			return "", notUpdated
+		}
+
+		_, err = coll.UpdateOne(conn, bson.M{"token": oldToken}, bson.M{"$set": bson.M{
+			"user":  user.Email,
+			"token": newToken,
+		}})
+
+		if err != nil {
+			return "", notUpdated
+		}
+
+		if oldToken == token {
+			current = newToken
+		}
+	}
+
+	return current, nil
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+)
+
+func TestEmailChangeCode(t *testing.T) {
+
+	change := &models.EmailChange{
+		Code:           utils.EncryptSha256("12345678"),
+		ExpirationDate: 1000,
+	}
+
+	if err := checkEmailChangeCode(change, "12345678", 999); err != nil {
+		t.Error("The code must confirm the change", err)
+		return
+	}
+
+	if err := checkEmailChangeCode(change, "87654321", 999); err == nil || err.Error != error.INVALID_EMAIL_CHANGE_CODE {
+		t.Error("Wrong codes must not confirm the change")
+		return
+	}
+
+	if err := checkEmailChangeCode(change, "", 999); err == nil || err.Error != error.INVALID_EMAIL_CHANGE_CODE {
+		t.Error("Empty codes must not confirm the change")
+		return
+	}
+
+	if err := checkEmailChangeCode(change, "12345678", 1000); err == nil || err.Error != error.EMAIL_CHANGE_EXPIRED {
+		t.Error("Expired changes must not be confirmed")
+		return
+	}
+
+	change.Attempts = EMAIL_CHANGE_MAX_ATTEMPTS
+	if err := checkEmailChangeCode(change, "12345678", 999); err == nil || err.Error != error.EMAIL_CHANGE_EXPIRED {
+		t.Error("Changes with too many wrong codes must not be confirmed")
+		return
+	}
+
+	log.Info("Email change codes checked")
+}
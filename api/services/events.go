@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/events"
+	"github.com/akrck02/valhalla-core/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Publish an event to the owner and members of a team
+// and queue it to the webhooks of the team
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] team | *models.Team: team of the event
+// [param] kind | string: type of the event
+// [param] data | interface{}: content of the event
+// [param] extra | ...string: ids of other users allowed to see it
+func publishTeamEvent(conn context.Context, client *mongo.Client, team *models.Team, kind string, data interface{}, extra ...string) {
+	events.Publish(kind, data, teamAudience([]models.Team{*team}, append([]string{}, extra...)))
+	queueWebhooks(conn, client, &models.Webhook{Team: team.ID}, kind, data)
+}
+
+// Publish an event to the users who can see a task, the
+// owner of its project and the members of the project teams,
+// and queue it to the webhooks of the project
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] task | *models.Task: task of the event
+// [param] kind | string: type of the event
+func publishTaskEvent(conn context.Context, client *mongo.Client, task *models.Task, kind string) {
+
+	project, projectErr := getProjectById(conn, client, task.Project)
+	if projectErr != nil {
+		return
+	}
+
+	queueWebhooks(conn, client, &models.Webhook{Project: project.ID}, kind, task)
+
+	projectTeams := []models.Team{}
+	teamIDs := toObjectIds(project.Teams)
+
+	if len(teamIDs) > 0 {
+		teams := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+		found, err := teams.Find(conn, bson.M{"_id": bson.M{"$in": teamIDs}})
+
+		if err != nil || found.All(conn, &projectTeams) != nil {
+			return
+		}
+	}
+
+	events.Publish(kind, task, teamAudience(projectTeams, []string{project.Owner}))
+}
+
+// Get the owners and members of some teams without repeating them
+//
+// [param] teams | []models.Team: teams of the audience
+// [param] users | []string: ids of other users of the audience
+//
+// [return] []string: ids of the users of the audience
+func teamAudience(teams []models.Team, users []string) []string {
+
+	seen := map[string]bool{}
+	audience := []string{}
+	add := func(user string) {
+		if user != "" && !seen[user] {
+			seen[user] = true
+			audience = append(audience, user)
+		}
+	}
+
+	for _, user := range users {
+		add(user)
+	}
+
+	for _, team := range teams {
+		add(team.Owner)
+		for _, member := range team.Members {
+			add(member)
+		}
+	}
+
+	return audience
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/akrck02/valhalla-core/events"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// Wait between keep alive messages of idle streams
+const EVENTS_KEEP_ALIVE = 25 * time.Second
+
+// Wait before clients reconnect to a closed stream, in milliseconds
+const EVENTS_RETRY = 3000
+
+// Get events token HTTP API endpoint, the token opens the
+// streams of the user with ?token= for a short time
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetEventsTokenHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+
+	token, expiration := eventsToken(request.User, utils.GetCurrentMillis())
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Token created", "token": token, "expiration_date": expiration},
+	}, nil
+}
+
+// Stream events HTTP API endpoint, pushes the events of the user as
+// Server-Sent Events resuming after the Last-Event-ID header or ?last_event_id=
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func StreamEventsHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	user, userErr := streamUser(c)
+	if userErr != nil {
+		return nil, userErr
+	}
+
+	return streamEvents(c, user)
+}
+
+// Push the events of a user as Server-Sent Events
+//
+// [param] c | *gin.Context: context
+// [param] user | *models.User: user of the stream
+//
+// [return] *models.Response: response | *models.Error: error
+func streamEvents(c *gin.Context, user *models.User) (*models.Response, *models.Error) {
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	subscription := events.Current.Subscribe(user.ID, lastEventID)
+	defer events.Current.Unsubscribe(subscription)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(utils.HTTP_STATUS_OK)
+
+	io.WriteString(c.Writer, "retry: "+utils.Int2String(EVENTS_RETRY)+"\n\n")
+	for _, event := range pendingEvents(subscription) {
+		c.Render(-1, sseEvent(event))
+	}
+
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(EVENTS_KEEP_ALIVE)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return nil, nil
+
+		case event, open := <-subscription.Events:
+			// Dropped subscribers resume when they reconnect
+			if !open {
+				return nil, nil
+			}
+
+			c.Render(-1, sseEvent(event))
+			c.Writer.Flush()
+
+		case <-keepAlive.C:
+			io.WriteString(c.Writer, ": keep-alive\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// Stream events WebSocket API endpoint, pushes the events of the
+// user as JSON messages resuming after ?last_event_id=
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func StreamEventsSocketHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	user, userErr := streamUser(c)
+	if userErr != nil {
+		return nil, userErr
+	}
+
+	server := websocket.Server{
+		// Clients are authorized by their token, not their origin
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			return nil
+		},
+		Handler: func(conn *websocket.Conn) {
+
+			subscription := events.Current.Subscribe(user.ID, c.Query("last_event_id"))
+			defer events.Current.Unsubscribe(subscription)
+
+			// Messages of the client are ignored, reading
+			// them tells when the connection is closed
+			closed := make(chan bool)
+			go func() {
+				io.Copy(io.Discard, conn)
+				close(closed)
+			}()
+
+			for _, event := range pendingEvents(subscription) {
+				if websocket.JSON.Send(conn, event) != nil {
+					return
+				}
+			}
+
+			keepAlive := time.NewTicker(EVENTS_KEEP_ALIVE)
+			defer keepAlive.Stop()
+
+			for {
+				select {
+				case <-closed:
+					return
+
+				case event, open := <-subscription.Events:
+					if !open || websocket.JSON.Send(conn, event) != nil {
+						return
+					}
+
+				case <-keepAlive.C:
+					conn.PayloadType = websocket.PingFrame
+					_, err := conn.Write(nil)
+					conn.PayloadType = websocket.TextFrame
+
+					if err != nil {
+						return
+					}
+				}
+			}
+		},
+	}
+
+	server.ServeHTTP(c.Writer, c.Request)
+	return nil, nil
+}
+
+// Get the events to send before the new ones, a reset event
+// when the missed events cannot be known
+//
+// [param] subscription | *events.Subscription: subscription of the stream
+//
+// [return] []*events.Event: events to send
+func pendingEvents(subscription *events.Subscription) []*events.Event {
+
+	if subscription.Reset {
+		return []*events.Event{{ID: subscription.Latest, Type: events.EVENT_RESET}}
+	}
+
+	return subscription.Missed
+}
+
+// Get the Server-Sent Event of an event
+//
+// [param] event | *events.Event: event to send
+//
+// [return] sse.Event: the event to render
+func sseEvent(event *events.Event) sse.Event {
+	return sse.Event{
+		Id:    event.ID,
+		Event: event.Type,
+		Data:  event,
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akrck02/valhalla-core/events"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func TestEventsHub(t *testing.T) {
+
+	hub := events.NewMemoryHub(3, 8)
+	odin := hub.Subscribe("odin", "")
+	loki := hub.Subscribe("loki", "")
+
+	first := &events.Event{Type: events.EVENT_TEAM_UPDATED, Users: []string{"odin"}}
+	hub.Publish(first)
+
+	if received := <-odin.Events; received.ID != first.ID || first.ID == "" {
+		t.Error("The event must get to its audience with an id")
+		return
+	}
+
+	if len(loki.Events) != 0 {
+		t.Error("Events must only get to their audience")
+		return
+	}
+
+	hub.Publish(&events.Event{Type: events.EVENT_TASK_UPDATED, Users: []string{"loki"}})
+	hub.Publish(&events.Event{Type: events.EVENT_TASK_CREATED, Users: []string{"odin", "loki"}})
+
+	resumed := hub.Subscribe("odin", first.ID)
+	if resumed.Reset || len(resumed.Missed) != 1 || resumed.Missed[0].Type != events.EVENT_TASK_CREATED {
+		t.Error("Resumed streams must get the events they missed", resumed.Missed)
+		return
+	}
+
+	if !hub.Subscribe("odin", "other-1").Reset || !hub.Subscribe("odin", "invalid").Reset {
+		t.Error("Events of other hubs cannot be resumed")
+		return
+	}
+
+	// The history only keeps the last 3 events
+	for i := 0; i < 2; i++ {
+		hub.Publish(&events.Event{Type: events.EVENT_TASK_UPDATED, Users: []string{"odin"}})
+	}
+
+	if !hub.Subscribe("odin", first.ID).Reset {
+		t.Error("Events out of the history cannot be resumed")
+		return
+	}
+
+	log.Info("Event hub checked")
+}
+
+func TestEventsSlowSubscriber(t *testing.T) {
+
+	hub := events.NewMemoryHub(10, 1)
+	slow := hub.Subscribe("thor", "")
+
+	for i := 0; i < 2; i++ {
+		hub.Publish(&events.Event{Type: events.EVENT_NOTIFICATION, Users: []string{"thor"}})
+	}
+
+	<-slow.Events
+	if _, open := <-slow.Events; open {
+		t.Error("Slow subscribers must be dropped")
+		return
+	}
+
+	// Dropped subscriptions can still be unsubscribed
+	hub.Unsubscribe(slow)
+	log.Info("Slow subscribers checked")
+}
+
+func TestEventsStream(t *testing.T) {
+
+	previous := events.Current
+	events.Current = events.NewMemoryHub(events.HISTORY_SIZE, events.SUBSCRIBER_BUFFER)
+	defer func() { events.Current = previous }()
+
+	first := &events.Event{Type: events.EVENT_TASK_CREATED, Users: []string{"odin"}}
+	second := &events.Event{Type: events.EVENT_TASK_UPDATED, Data: map[string]string{"name": "Hammer"}, Users: []string{"odin"}}
+	events.Current.Publish(first)
+	events.Current.Publish(second)
+
+	// A closed request sends the missed events and ends
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest("GET", "/events/stream", nil).WithContext(ctx)
+	c.Request.Header.Set("Last-Event-ID", first.ID)
+
+	streamEvents(c, &models.User{ID: "odin"})
+
+	body := recorder.Body.String()
+	if recorder.Header().Get("Content-Type") != "text/event-stream" || !strings.HasPrefix(body, "retry: ") {
+		t.Error("The stream must be Server-Sent Events", body)
+		return
+	}
+
+	if strings.Contains(body, "id:"+first.ID+"\n") || !strings.Contains(body, "id:"+second.ID+"\nevent:task.updated\ndata:") {
+		t.Error("Only the missed events must be sent", body)
+		return
+	}
+
+	if !strings.Contains(body, `"name":"Hammer"`) {
+		t.Error("The data of the event must be sent", body)
+		return
+	}
+
+	log.Info("Event stream checked")
+}
+
+func TestEventsToken(t *testing.T) {
+
+	var now = utils.GetCurrentMillis()
+	token, expiration := eventsToken(&models.User{ID: "odin"}, now)
+
+	if user, err := parseEventsToken(token, now); err != nil || user != "odin" || expiration != now+EVENTS_TOKEN_TTL {
+		t.Error("The token must open the streams of its user", user, err)
+		return
+	}
+
+	if _, err := parseEventsToken(token, expiration); err == nil {
+		t.Error("Expired tokens must not be valid")
+		return
+	}
+
+	if _, err := parseEventsToken(token+"x", now); err == nil {
+		t.Error("Changed tokens must not be valid")
+		return
+	}
+
+	if _, err := parseEventsToken(utils.SignPayload("odin:"+utils.Int642String(expiration)), now); err == nil {
+		t.Error("Other signed tokens must not be valid")
+		return
+	}
+
+	log.Info("Events token checked")
+}
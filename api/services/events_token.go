@@ -0,0 +1,106 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/middleware"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Time a stream token can be used to open a stream, in milliseconds
+const EVENTS_TOKEN_TTL = 60 * 1000
+
+// Prefix of the stream tokens, so other signed tokens are not taken for them
+const EVENTS_TOKEN_PREFIX = "events"
+
+// Get the user of a stream, from the ?token= of the events token
+// endpoint or from the Authorization header
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.User: user of the stream --> *models.Error: error if the user cannot be known
+func streamUser(c *gin.Context) (*models.User, *models.Error) {
+
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	token := c.Query("token")
+	if token == "" {
+		authorization := c.GetHeader(middleware.AUTHORITATION_HEADER)
+		if authorization == "" {
+			return nil, &models.Error{
+				Status:  utils.HTTP_STATUS_FORBIDDEN,
+				Error:   int(error.INVALID_TOKEN),
+				Message: "Missing token",
+			}
+		}
+
+		return middleware.IsTokenValid(client, authorization)
+	}
+
+	id, tokenErr := parseEventsToken(token, utils.GetCurrentMillis())
+	if tokenErr != nil {
+		return nil, tokenErr
+	}
+
+	user, userErr := GetUserById(conn, client, id)
+	if userErr != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   int(error.INVALID_TOKEN),
+			Message: "Invalid stream token",
+		}
+	}
+
+	return user, nil
+}
+
+// Get a signed token to open the event streams of a user,
+// for clients that cannot send the Authorization header
+//
+// [param] user | *models.User: user of the streams
+// [param] now | int64: current time in milliseconds
+//
+// [return] string: signed token --> int64: expiration date in milliseconds
+func eventsToken(user *models.User, now int64) (string, int64) {
+	expiration := now + EVENTS_TOKEN_TTL
+	return utils.SignPayload(EVENTS_TOKEN_PREFIX + ":" + user.ID + ":" + utils.Int642String(expiration)), expiration
+}
+
+// Get the user id of a signed stream token
+//
+// [param] token | string: signed token
+// [param] now | int64: current time in milliseconds
+//
+// [return] string: user id --> *models.Error: error if the token is not valid or expired
+func parseEventsToken(token string, now int64) (string, *models.Error) {
+
+	invalid := &models.Error{
+		Status:  utils.HTTP_STATUS_FORBIDDEN,
+		Error:   int(error.INVALID_TOKEN),
+		Message: "Invalid stream token",
+	}
+
+	payload, err := utils.VerifySignedPayload(token)
+	if err != nil {
+		return "", invalid
+	}
+
+	parts := strings.Split(payload, ":")
+	if len(parts) != 3 || parts[0] != EVENTS_TOKEN_PREFIX || parts[1] == "" {
+		return "", invalid
+	}
+
+	expiration, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || expiration <= now {
+		return "", invalid
+	}
+
+	return parts[1], nil
+}
@@ -0,0 +1,494 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/mail"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Time an invitation can be answered
+const INVITATION_EXPIRATION = 7 * utils.MILLIS_PER_DAY
+
+// Invite a user or an email to a team
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] inviter | *models.User: user making the invitation
+// [param] team | *models.Team: team to join
+// [param] invitation | *models.Invitation: invitation with the user or email
+//
+// [return] string: signed invitation token --> *models.Error: error if any
+func InviteToTeam(conn context.Context, client *mongo.Client, inviter *models.User, team *models.Team, invitation *models.Invitation) (string, *models.Error) {
+
+	if utils.IsEmpty(invitation.User) && utils.IsEmpty(invitation.Email) {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.NO_INVITEE),
+			Message: "Invitations require a user or an email",
+		}
+	}
+
+	users := client.Database(db.CurrentDatabase).Collection(db.USER)
+
+	// Registered users are always invited by id
+	if utils.IsEmpty(invitation.User) {
+		checkedEmail := utils.ValidateEmail(invitation.Email)
+
+		if checkedEmail.Response != 200 {
+			return "", &models.Error{
+				Status:  utils.HTTP_STATUS_BAD_REQUEST,
+				Error:   int(checkedEmail.Response),
+				Message: checkedEmail.Message,
+			}
+		}
+
+		var found models.User
+		if users.FindOne(conn, bson.M{"email": invitation.Email}).Decode(&found) == nil {
+			invitation.User = found.ID
+			invitation.Email = ""
+		}
+	} else {
+		invitation.Email = ""
+		existsErr := userExists(conn, client, invitation.User)
+
+		if existsErr != nil {
+			return "", existsErr
+		}
+	}
+
+	if invitation.User == team.Owner {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.USER_IS_OWNER),
+			Message: "User is owner of the team",
+		}
+	}
+
+	if invitation.User != "" && isTeamMember(team, invitation.User) {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.USER_ALREADY_MEMBER),
+			Message: "User is already a member of the team",
+		}
+	}
+
+	now := utils.GetCurrentMillis()
+	invitations := client.Database(db.CurrentDatabase).Collection(db.INVITATION)
+
+	filter := bson.M{
+		"team":            team.ID,
+		"status":          models.INVITATION_PENDING,
+		"expiration_date": bson.M{"$gt": now},
+	}
+
+	if invitation.User != "" {
+		filter["user"] = invitation.User
+	} else {
+		filter["email"] = invitation.Email
+	}
+
+	pending, err := invitations.CountDocuments(conn, filter)
+
+	if err != nil || pending > 0 {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.INVITATION_ALREADY_PENDING),
+			Message: "There is already a pending invitation",
+		}
+	}
+
+	invitation.Team = team.ID
+	invitation.Inviter = inviter.ID
+	invitation.Status = models.INVITATION_PENDING
+	invitation.CreationDate = now
+	invitation.ExpirationDate = now + INVITATION_EXPIRATION
+	invitation.ResponseDate = 0
+
+	result, err := invitations.InsertOne(conn, invitation)
+
+	if err != nil {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.INVITATION_NOT_CREATED),
+			Message: "Invitation not created",
+		}
+	}
+
+	invitation.ID = result.InsertedID.(primitive.ObjectID).Hex()
+
+	if invitation.User != "" {
+		NotifyUser(conn, client, &models.Notification{
+			User:    invitation.User,
+			Type:    models.NOTIFICATION_INVITATION,
+			Title:   inviter.Username + " invited you to " + team.Name,
+			Message: team.Description,
+			Source:  invitation.ID,
+		})
+
+		return invitationToken(invitation), nil
+	}
+
+	// Emails not registered yet get a link to the public
+	// preview of the invitation in the language of the inviter
+	token := invitationToken(invitation)
+	query := url.Values{"token": {token}}
+	sendEmail(conn, client, userLocale(conn, client, inviter), invitation.Email, mail.TEMPLATE_INVITATION, map[string]interface{}{
+		"Inviter": inviter.Username,
+		"Team":    team.Name,
+		"Link":    publicLink("team/invitation/preview", query),
+	})
+
+	return token, nil
+}
+
+// Get invitation logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] id | string: id of the invitation
+//
+// [return] *models.Invitation: invitation found --> *models.Error: error if any
+func GetInvitation(conn context.Context, client *mongo.Client, id string) (*models.Invitation, *models.Error) {
+
+	objID, err := utils.StringToObjectId(id)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.BAD_OBJECT_ID),
+			Message: "Bad object id",
+		}
+	}
+
+	invitations := client.Database(db.CurrentDatabase).Collection(db.INVITATION)
+
+	var found models.Invitation
+	err = invitations.FindOne(conn, bson.M{"_id": objID}).Decode(&found)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.INVITATION_NOT_FOUND),
+			Message: "Invitation not found",
+		}
+	}
+
+	return &found, nil
+}
+
+// Accept or decline an invitation, accepting it
+// adds the invitee to the members of the team
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: invitee
+// [param] invitation | *models.Invitation: invitation to answer
+// [param] accept | bool: true to accept the invitation
+//
+// [return] *models.Error: error if any
+func RespondInvitation(conn context.Context, client *mongo.Client, user *models.User, invitation *models.Invitation, accept bool) *models.Error {
+
+	if !isInvitee(invitation, user) {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   int(error.NOT_INVITEE),
+			Message: "The invitation was made to another user",
+		}
+	}
+
+	now := utils.GetCurrentMillis()
+	pendingErr := checkInvitationPending(invitation, now)
+
+	if pendingErr != nil {
+		return pendingErr
+	}
+
+	status := models.INVITATION_DECLINED
+	if accept {
+		status = models.INVITATION_ACCEPTED
+	}
+
+	// Email invitations are bound to the user answering them
+	updateErr := updateInvitationStatus(conn, client, invitation, bson.M{
+		"status":        status,
+		"user":          user.ID,
+		"response_date": now,
+	})
+
+	if updateErr != nil || !accept {
+		return updateErr
+	}
+
+	teamID, _ := utils.StringToObjectId(invitation.Team)
+	teams := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+	result, err := teams.UpdateOne(conn, bson.M{"_id": teamID}, bson.M{"$addToSet": bson.M{
+		"members": user.ID,
+	}})
+
+	// The invitation can be answered again if the member is not added
+	if err != nil || result.MatchedCount == 0 {
+		revertInvitationStatus(conn, client, invitation)
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.UPDATE_ERROR),
+			Message: "Could not add member",
+		}
+	}
+
+	notifyMemberAdded(conn, client, invitation.Team, user.ID, user.ID)
+	return nil
+}
+
+// Revoke a pending invitation
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] invitation | *models.Invitation: invitation to revoke
+//
+// [return] *models.Error: error if any
+func RevokeInvitation(conn context.Context, client *mongo.Client, invitation *models.Invitation) *models.Error {
+
+	if invitation.Status != models.INVITATION_PENDING {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.INVITATION_NOT_PENDING),
+			Message: "Invitation was already " + invitation.Status,
+		}
+	}
+
+	return updateInvitationStatus(conn, client, invitation, bson.M{
+		"status":        models.INVITATION_REVOKED,
+		"response_date": utils.GetCurrentMillis(),
+	})
+}
+
+// Get the pending invitations of a team
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] team | *models.Team: team of the invitations
+//
+// [return] []models.Invitation: pending invitations --> *models.Error: error if any
+func GetTeamInvitations(conn context.Context, client *mongo.Client, team *models.Team) ([]models.Invitation, *models.Error) {
+
+	return findPendingInvitations(conn, client, bson.M{"team": team.ID})
+}
+
+// Get the pending invitations of a user, by id or by
+// email once the user validated it
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: invitee
+//
+// [return] []models.Invitation: pending invitations --> *models.Error: error if any
+func GetUserInvitations(conn context.Context, client *mongo.Client, user *models.User) ([]models.Invitation, *models.Error) {
+
+	// Unvalidated emails could be anyone's
+	if !user.Validated {
+		return findPendingInvitations(conn, client, bson.M{"user": user.ID})
+	}
+
+	return findPendingInvitations(conn, client, bson.M{"$or": bson.A{
+		bson.M{"user": user.ID},
+		bson.M{"email": user.Email},
+	}})
+}
+
+func findPendingInvitations(conn context.Context, client *mongo.Client, filter bson.M) ([]models.Invitation, *models.Error) {
+
+	filter["status"] = models.INVITATION_PENDING
+	filter["expiration_date"] = bson.M{"$gt": utils.GetCurrentMillis()}
+
+	invitations := client.Database(db.CurrentDatabase).Collection(db.INVITATION)
+	cursor, err := invitations.Find(conn, filter)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get invitations",
+		}
+	}
+
+	found := []models.Invitation{}
+	err = cursor.All(conn, &found)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get invitations",
+		}
+	}
+
+	return found, nil
+}
+
+// Change the status of an invitation only if it is still pending
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] invitation | *models.Invitation: invitation to update
+// [param] update | bson.M: fields to set
+//
+// [return] *models.Error: error if any
+func updateInvitationStatus(conn context.Context, client *mongo.Client, invitation *models.Invitation, update bson.M) *models.Error {
+
+	objID, _ := utils.StringToObjectId(invitation.ID)
+	invitations := client.Database(db.CurrentDatabase).Collection(db.INVITATION)
+	result, err := invitations.UpdateOne(conn,
+		bson.M{"_id": objID, "status": models.INVITATION_PENDING},
+		bson.M{"$set": update},
+	)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.INVITATION_NOT_UPDATED),
+			Message: "Invitation not updated",
+		}
+	}
+
+	if result.ModifiedCount == 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.INVITATION_NOT_PENDING),
+			Message: "Invitation is not pending",
+		}
+	}
+
+	return nil
+}
+
+// Set an accepted invitation as pending again, for
+// invitees that could not be added
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] invitation | *models.Invitation: invitation to revert
+func revertInvitationStatus(conn context.Context, client *mongo.Client, invitation *models.Invitation) {
+
+	// Email invitations are unbound from the user again
+	revert := bson.M{"$set": bson.M{"status": models.INVITATION_PENDING}, "$unset": bson.M{"response_date": ""}}
+	if invitation.User != "" {
+		revert["$set"].(bson.M)["user"] = invitation.User
+	} else {
+		revert["$unset"].(bson.M)["user"] = ""
+	}
+
+	objID, _ := utils.StringToObjectId(invitation.ID)
+	invitations := client.Database(db.CurrentDatabase).Collection(db.INVITATION)
+	_, err := invitations.UpdateOne(conn, bson.M{"_id": objID, "status": models.INVITATION_ACCEPTED}, revert)
+
+	if err != nil {
+		log.FormattedError("Cannot set invitation ${0} as pending again: ${1}", invitation.ID, err.Error())
+		return
+	}
+
+	invitation.Status = models.INVITATION_PENDING
+}
+
+// Get the signed token of an invitation, used on invitation
+// links. The token contains the invitation id and expiration date.
+//
+// [param] invitation | *models.Invitation: invitation
+//
+// [return] string: signed token
+func invitationToken(invitation *models.Invitation) string {
+	return utils.SignPayload(invitation.ID + ":" + utils.Int642String(invitation.ExpirationDate))
+}
+
+// Get the invitation id of a signed invitation token
+//
+// [param] token | string: signed token
+// [param] now | int64: current time in milliseconds
+//
+// [return] string: invitation id --> *models.Error: error if the token is not valid or expired
+func parseInvitationToken(token string, now int64) (string, *models.Error) {
+
+	invalid := &models.Error{
+		Status:  utils.HTTP_STATUS_BAD_REQUEST,
+		Error:   int(error.INVALID_INVITATION_TOKEN),
+		Message: "Invalid invitation link",
+	}
+
+	payload, err := utils.VerifySignedPayload(token)
+	if err != nil {
+		return "", invalid
+	}
+
+	parts := strings.Split(payload, ":")
+	if len(parts) != 2 {
+		return "", invalid
+	}
+
+	expiration, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", invalid
+	}
+
+	if expiration <= now {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_GONE,
+			Error:   int(error.INVITATION_EXPIRED),
+			Message: "Invitation expired",
+		}
+	}
+
+	return parts[0], nil
+}
+
+// Check that an invitation can still be answered
+//
+// [param] invitation | *models.Invitation: invitation to check
+// [param] now | int64: current time in milliseconds
+//
+// [return] *models.Error: error if the invitation is not pending
+func checkInvitationPending(invitation *models.Invitation, now int64) *models.Error {
+
+	if invitation.Status != models.INVITATION_PENDING {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.INVITATION_NOT_PENDING),
+			Message: "Invitation was already " + invitation.Status,
+		}
+	}
+
+	if invitation.ExpirationDate <= now {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_GONE,
+			Error:   int(error.INVITATION_EXPIRED),
+			Message: "Invitation expired",
+		}
+	}
+
+	return nil
+}
+
+// Get if the user is the invitee, by id or by email
+// once the user validated it
+//
+// [param] invitation | *models.Invitation: invitation
+// [param] user | *models.User: user to check
+//
+// [return] bool: true if the invitation was made to the user
+func isInvitee(invitation *models.Invitation, user *models.User) bool {
+
+	if invitation.User != "" {
+		return invitation.User == user.ID
+	}
+
+	return user.Validated && invitation.Email != "" && strings.EqualFold(invitation.Email, user.Email)
+}
@@ -0,0 +1,342 @@
+package services
+
+import (
+	"context"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type InvitationRequest struct {
+	ID    string `json:"id"`
+	Token string `json:"token"`
+}
+
+// Invite to team HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func InviteToTeamHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	validatedErr := checkUserValidated(request.User)
+	if validatedErr != nil {
+		return nil, validatedErr
+	}
+
+	var invitation *models.Invitation = &models.Invitation{}
+	err := c.ShouldBindJSON(invitation)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	team, getErr := GetTeam(conn, client, &models.Team{ID: invitation.Team})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if !CanManageTeam(conn, client, request.User, team) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot invite to the team",
+		}
+	}
+
+	token, inviteErr := InviteToTeam(conn, client, request.User, team, invitation)
+	if inviteErr != nil {
+		return nil, inviteErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Invitation sent", "id": invitation.ID, "token": token, "expiration_date": invitation.ExpirationDate},
+	}, nil
+}
+
+// Get invitation HTTP API endpoint, by ?id= or by the ?token= of an invitation link
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetInvitationHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	invitation, getErr := resolveInvitation(conn, client, &InvitationRequest{ID: c.Query("id"), Token: c.Query("token")})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	team, teamErr := GetTeam(conn, client, &models.Team{ID: invitation.Team})
+	if teamErr != nil {
+		return nil, teamErr
+	}
+
+	if !isInvitee(invitation, request.User) && !CanManageTeam(conn, client, request.User, team) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the invitation",
+		}
+	}
+
+	return &models.Response{
+		Code: utils.HTTP_STATUS_OK,
+		Response: gin.H{
+			"message":    "Invitation found",
+			"invitation": invitation,
+			"team":       gin.H{"id": team.ID, "name": team.Name, "description": team.Description},
+		},
+	}, nil
+}
+
+// Preview invitation HTTP API endpoint, public so the ?token= of an
+// invitation link can be opened before registering. It only shows
+// the team, the inviter and the invited email of pending invitations
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func PreviewInvitationHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var token = c.Query("token")
+
+	if token == "" {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_INVITATION_TOKEN,
+			Message: "Invalid invitation link",
+		}
+	}
+
+	invitation, getErr := resolveInvitation(conn, client, &InvitationRequest{Token: token})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	pendingErr := checkInvitationPending(invitation, utils.GetCurrentMillis())
+	if pendingErr != nil {
+		return nil, pendingErr
+	}
+
+	team, teamErr := GetTeam(conn, client, &models.Team{ID: invitation.Team})
+	if teamErr != nil {
+		return nil, teamErr
+	}
+
+	inviter := ""
+	if found, inviterErr := GetUserById(conn, client, invitation.Inviter); inviterErr == nil {
+		inviter = found.Username
+	}
+
+	return &models.Response{
+		Code: utils.HTTP_STATUS_OK,
+		Response: gin.H{
+			"message":         "Invitation found",
+			"email":           invitation.Email,
+			"inviter":         inviter,
+			"expiration_date": invitation.ExpirationDate,
+			"team":            gin.H{"id": team.ID, "name": team.Name, "description": team.Description, "profilepic": team.ProfilePic},
+		},
+	}, nil
+}
+
+// Accept invitation HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func AcceptInvitationHttp(c *gin.Context) (*models.Response, *models.Error) {
+	return respondInvitationHttp(c, true)
+}
+
+// Decline invitation HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func DeclineInvitationHttp(c *gin.Context) (*models.Response, *models.Error) {
+	return respondInvitationHttp(c, false)
+}
+
+func respondInvitationHttp(c *gin.Context, accept bool) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *InvitationRequest = &InvitationRequest{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	invitation, getErr := resolveInvitation(conn, client, params)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	respondErr := RespondInvitation(conn, client, request.User, invitation, accept)
+	if respondErr != nil {
+		return nil, respondErr
+	}
+
+	message := "Invitation declined"
+	if accept {
+		message = "Invitation accepted"
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": message, "team": invitation.Team},
+	}, nil
+}
+
+// Revoke invitation HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func RevokeInvitationHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *InvitationRequest = &InvitationRequest{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	invitation, getErr := GetInvitation(conn, client, params.ID)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	team, teamErr := GetTeam(conn, client, &models.Team{ID: invitation.Team})
+	if teamErr != nil {
+		return nil, teamErr
+	}
+
+	if invitation.Inviter != request.User.ID && !CanManageTeam(conn, client, request.User, team) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot revoke the invitation",
+		}
+	}
+
+	revokeErr := RevokeInvitation(conn, client, invitation)
+	if revokeErr != nil {
+		return nil, revokeErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Invitation revoked"},
+	}, nil
+}
+
+// Get pending invitations HTTP API endpoint, with ?team=
+// the invitations of the team, otherwise the invitations of the user
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetInvitationsHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	if c.Query("team") == "" {
+		invitations, getErr := GetUserInvitations(conn, client, request.User)
+		if getErr != nil {
+			return nil, getErr
+		}
+
+		return &models.Response{
+			Code:     utils.HTTP_STATUS_OK,
+			Response: gin.H{"message": "Invitations found", "invitations": invitations},
+		}, nil
+	}
+
+	team, teamErr := GetTeam(conn, client, &models.Team{ID: c.Query("team")})
+	if teamErr != nil {
+		return nil, teamErr
+	}
+
+	if !CanManageTeam(conn, client, request.User, team) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the team invitations",
+		}
+	}
+
+	invitations, getErr := GetTeamInvitations(conn, client, team)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Invitations found", "invitations": invitations},
+	}, nil
+}
+
+// Get the invitation of a request by its token or id
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] params | *InvitationRequest: request with the id or token
+//
+// [return] *models.Invitation: invitation found --> *models.Error: error if any
+func resolveInvitation(conn context.Context, client *mongo.Client, params *InvitationRequest) (*models.Invitation, *models.Error) {
+
+	id := params.ID
+
+	if params.Token != "" {
+		tokenID, tokenErr := parseInvitationToken(params.Token, utils.GetCurrentMillis())
+		if tokenErr != nil {
+			return nil, tokenErr
+		}
+
+		id = tokenID
+	}
+
+	return GetInvitation(conn, client, id)
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/akrck02/valhalla-core/configuration"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+)
+
+func TestInvitationToken(t *testing.T) {
+
+	var now = utils.GetCurrentMillis()
+	var invitation = &models.Invitation{
+		ID:             "65a1b2c3d4e5f6a7b8c9d0e1",
+		ExpirationDate: now + INVITATION_EXPIRATION,
+	}
+
+	token := invitationToken(invitation)
+	id, err := parseInvitationToken(token, now)
+
+	if err != nil || id != invitation.ID {
+		t.Error("The invitation token must be valid", err)
+		return
+	}
+
+	_, err = parseInvitationToken(token, invitation.ExpirationDate)
+	if err == nil || err.Error != error.INVITATION_EXPIRED {
+		t.Error("The invitation token must expire")
+		return
+	}
+
+	tampered := invitationToken(&models.Invitation{ID: "65a1b2c3d4e5f6a7b8c9d0e2", ExpirationDate: invitation.ExpirationDate})
+	tampered = tampered[:len(tampered)-4] + token[len(token)-4:]
+
+	_, err = parseInvitationToken(tampered, now)
+	if err == nil || err.Error != error.INVALID_INVITATION_TOKEN {
+		t.Error("A tampered invitation token must not be valid")
+		return
+	}
+
+	log.Info("Invitation tokens checked")
+}
+
+func TestInvitee(t *testing.T) {
+
+	var user = &models.User{ID: "user", Email: "Invitee@valhalla.org", Validated: true}
+
+	if !isInvitee(&models.Invitation{User: "user"}, user) {
+		t.Error("Invitations by id must match the user")
+		return
+	}
+
+	if !isInvitee(&models.Invitation{Email: "invitee@valhalla.org"}, user) {
+		t.Error("Invitations by email must match the user email")
+		return
+	}
+
+	if isInvitee(&models.Invitation{User: "other", Email: "invitee@valhalla.org"}, user) {
+		t.Error("Invitations bound to another user must not match")
+		return
+	}
+
+	user.Validated = false
+	if isInvitee(&models.Invitation{Email: "invitee@valhalla.org"}, user) {
+		t.Error("Invitations by email must not match unvalidated emails")
+		return
+	}
+
+	log.Info("Invitees checked")
+}
+
+func TestPublicLink(t *testing.T) {
+
+	var previous = configuration.Params.PublicURL
+	defer func() { configuration.Params.PublicURL = previous }()
+
+	configuration.Params.PublicURL = "https://valhalla.example.com"
+	link := publicLink("team/invitation/preview", url.Values{"token": {"a+b"}})
+
+	if link != "https://valhalla.example.com"+API_COMPLETE+"team/invitation/preview?token=a%2Bb" {
+		t.Error("Links must be absolute and escaped", link)
+		return
+	}
+
+	log.Info("Public links checked")
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"context"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Request to join a public or discoverable team, requests
+// to public teams are approved as soon as they are made
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user requesting to join
+// [param] team | *models.Team: team to join
+// [param] message | string: message for the team managers
+//
+// [return] *models.JoinRequest: join request --> *models.Error: error if any
+func RequestToJoinTeam(conn context.Context, client *mongo.Client, user *models.User, team *models.Team, message string) (*models.JoinRequest, *models.Error) {
+
+	if !team.IsListed() {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   int(error.TEAM_NOT_JOINABLE),
+			Message: "Private teams can only be joined by invitation",
+		}
+	}
+
+	if user.ID == team.Owner {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.USER_IS_OWNER),
+			Message: "User is owner of the team",
+		}
+	}
+
+	if isTeamMember(team, user.ID) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.USER_ALREADY_MEMBER),
+			Message: "User is already a member of the team",
+		}
+	}
+
+	requests := client.Database(db.CurrentDatabase).Collection(db.TEAM_JOIN_REQUEST)
+	pending, err := requests.CountDocuments(conn, bson.M{
+		"team":   team.ID,
+		"user":   user.ID,
+		"status": models.JOIN_REQUEST_PENDING,
+	})
+
+	if err != nil || pending > 0 {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.JOIN_REQUEST_PENDING),
+			Message: "There is already a pending join request",
+		}
+	}
+
+	joinRequest := &models.JoinRequest{
+		Team:         team.ID,
+		User:         user.ID,
+		Message:      message,
+		Status:       models.JOIN_REQUEST_PENDING,
+		CreationDate: utils.GetCurrentMillis(),
+	}
+
+	result, err := requests.InsertOne(conn, joinRequest)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.JOIN_REQUEST_NOT_CREATED),
+			Message: "Join request not created",
+		}
+	}
+
+	joinRequest.ID = result.InsertedID.(primitive.ObjectID).Hex()
+
+	if team.Visibility == models.TEAM_VISIBILITY_PUBLIC {
+		approveErr := RespondJoinRequest(conn, client, user, joinRequest, true)
+		if approveErr != nil {
+			return nil, approveErr
+		}
+
+		return joinRequest, nil
+	}
+
+	NotifyUser(conn, client, &models.Notification{
+		User:    team.Owner,
+		Type:    models.NOTIFICATION_JOIN,
+		Title:   user.Username + " wants to join " + team.Name,
+		Message: message,
+		Source:  joinRequest.ID,
+	})
+
+	return joinRequest, nil
+}
+
+// Get join request logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] id | string: id of the join request
+//
+// [return] *models.JoinRequest: join request found --> *models.Error: error if any
+func GetJoinRequest(conn context.Context, client *mongo.Client, id string) (*models.JoinRequest, *models.Error) {
+
+	objID, err := utils.StringToObjectId(id)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.BAD_OBJECT_ID),
+			Message: "Bad object id",
+		}
+	}
+
+	requests := client.Database(db.CurrentDatabase).Collection(db.TEAM_JOIN_REQUEST)
+
+	var found models.JoinRequest
+	err = requests.FindOne(conn, bson.M{"_id": objID}).Decode(&found)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.JOIN_REQUEST_NOT_FOUND),
+			Message: "Join request not found",
+		}
+	}
+
+	return &found, nil
+}
+
+// Approve or reject a join request, approving it
+// adds the user to the members of the team
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] responder | *models.User: owner or admin answering the request
+// [param] joinRequest | *models.JoinRequest: join request to answer
+// [param] approve | bool: true to approve the request
+//
+// [return] *models.Error: error if any
+func RespondJoinRequest(conn context.Context, client *mongo.Client, responder *models.User, joinRequest *models.JoinRequest, approve bool) *models.Error {
+
+	pendingErr := checkJoinRequestPending(joinRequest)
+	if pendingErr != nil {
+		return pendingErr
+	}
+
+	status := models.JOIN_REQUEST_REJECTED
+	if approve {
+		status = models.JOIN_REQUEST_APPROVED
+	}
+
+	updateErr := updateJoinRequestStatus(conn, client, joinRequest, bson.M{
+		"status":        status,
+		"responder":     responder.ID,
+		"response_date": utils.GetCurrentMillis(),
+	})
+
+	if updateErr != nil {
+		return updateErr
+	}
+
+	joinRequest.Status = status
+
+	if approve {
+		teamID, _ := utils.StringToObjectId(joinRequest.Team)
+		teams := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+		result, err := teams.UpdateOne(conn, bson.M{"_id": teamID}, bson.M{"$addToSet": bson.M{
+			"members": joinRequest.User,
+		}})
+
+		// The request can be answered again if the member is not added
+		if err != nil || result.MatchedCount == 0 {
+			revertJoinRequestStatus(conn, client, joinRequest)
+			return &models.Error{
+				Status:  utils.HTTP_STATUS_BAD_REQUEST,
+				Error:   int(error.UPDATE_ERROR),
+				Message: "Could not add member",
+			}
+		}
+
+		// The member already gets the join request answer
+		notifyMemberAdded(conn, client, joinRequest.Team, joinRequest.User, joinRequest.User)
+	}
+
+	// Requests approved on the spot need no notification
+	if responder.ID != joinRequest.User {
+		NotifyUser(conn, client, &models.Notification{
+			User:   joinRequest.User,
+			Type:   models.NOTIFICATION_JOIN,
+			Title:  "Your join request was " + status,
+			Source: joinRequest.ID,
+		})
+	}
+
+	return nil
+}
+
+// Cancel a pending join request
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] joinRequest | *models.JoinRequest: join request to cancel
+//
+// [return] *models.Error: error if any
+func CancelJoinRequest(conn context.Context, client *mongo.Client, joinRequest *models.JoinRequest) *models.Error {
+
+	pendingErr := checkJoinRequestPending(joinRequest)
+	if pendingErr != nil {
+		return pendingErr
+	}
+
+	return updateJoinRequestStatus(conn, client, joinRequest, bson.M{
+		"status":        models.JOIN_REQUEST_CANCELLED,
+		"response_date": utils.GetCurrentMillis(),
+	})
+}
+
+// Get the pending join requests of a team
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] team | *models.Team: team of the join requests
+//
+// [return] []models.JoinRequest: pending join requests --> *models.Error: error if any
+func GetTeamJoinRequests(conn context.Context, client *mongo.Client, team *models.Team) ([]models.JoinRequest, *models.Error) {
+	return findPendingJoinRequests(conn, client, bson.M{"team": team.ID})
+}
+
+// Get the pending join requests of a user
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user who made the join requests
+//
+// [return] []models.JoinRequest: pending join requests --> *models.Error: error if any
+func GetUserJoinRequests(conn context.Context, client *mongo.Client, user *models.User) ([]models.JoinRequest, *models.Error) {
+	return findPendingJoinRequests(conn, client, bson.M{"user": user.ID})
+}
+
+func findPendingJoinRequests(conn context.Context, client *mongo.Client, filter bson.M) ([]models.JoinRequest, *models.Error) {
+
+	filter["status"] = models.JOIN_REQUEST_PENDING
+
+	requests := client.Database(db.CurrentDatabase).Collection(db.TEAM_JOIN_REQUEST)
+	cursor, err := requests.Find(conn, filter)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get join requests",
+		}
+	}
+
+	found := []models.JoinRequest{}
+	err = cursor.All(conn, &found)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get join requests",
+		}
+	}
+
+	return found, nil
+}
+
+// Change the status of a join request only if it is still pending
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] joinRequest | *models.JoinRequest: join request to update
+// [param] update | bson.M: fields to set
+//
+// [return] *models.Error: error if any
+func updateJoinRequestStatus(conn context.Context, client *mongo.Client, joinRequest *models.JoinRequest, update bson.M) *models.Error {
+
+	objID, _ := utils.StringToObjectId(joinRequest.ID)
+	requests := client.Database(db.CurrentDatabase).Collection(db.TEAM_JOIN_REQUEST)
+	result, err := requests.UpdateOne(conn,
+		bson.M{"_id": objID, "status": models.JOIN_REQUEST_PENDING},
+		bson.M{"$set": update},
+	)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.JOIN_REQUEST_NOT_UPDATED),
+			Message: "Join request not updated",
+		}
+	}
+
+	if result.ModifiedCount == 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.JOIN_REQUEST_NOT_PENDING),
+			Message: "Join request is not pending",
+		}
+	}
+
+	return nil
+}
+
+// Set an approved join request as pending again, for
+// members that could not be added
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] joinRequest | *models.JoinRequest: join request to revert
+func revertJoinRequestStatus(conn context.Context, client *mongo.Client, joinRequest *models.JoinRequest) {
+
+	objID, _ := utils.StringToObjectId(joinRequest.ID)
+	requests := client.Database(db.CurrentDatabase).Collection(db.TEAM_JOIN_REQUEST)
+	_, err := requests.UpdateOne(conn,
+		bson.M{"_id": objID, "status": models.JOIN_REQUEST_APPROVED},
+		bson.M{"$set": bson.M{"status": models.JOIN_REQUEST_PENDING}, "$unset": bson.M{"responder": "", "response_date": ""}},
+	)
+
+	if err != nil {
+		log.FormattedError("Cannot set join request ${0} as pending again: ${1}", joinRequest.ID, err.Error())
+		return
+	}
+
+	joinRequest.Status = models.JOIN_REQUEST_PENDING
+}
+
+// Check that a join request can still be answered
+//
+// [param] joinRequest | *models.JoinRequest: join request to check
+//
+// [return] *models.Error: error if the join request is not pending
+func checkJoinRequestPending(joinRequest *models.JoinRequest) *models.Error {
+
+	if joinRequest.Status != models.JOIN_REQUEST_PENDING {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.JOIN_REQUEST_NOT_PENDING),
+			Message: "Join request was already " + joinRequest.Status,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type JoinTeamRequest struct {
+	ID      string `json:"id"`
+	Team    string `json:"teamid"`
+	Message string `json:"message"`
+}
+
+// Request to join a team HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func RequestToJoinTeamHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *JoinTeamRequest = &JoinTeamRequest{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	team, getErr := GetTeam(conn, client, &models.Team{ID: params.Team})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	joinRequest, joinErr := RequestToJoinTeam(conn, client, request.User, team, params.Message)
+	if joinErr != nil {
+		return nil, joinErr
+	}
+
+	message := "Join request sent"
+	if joinRequest.Status == models.JOIN_REQUEST_APPROVED {
+		message = "Team joined"
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": message, "id": joinRequest.ID, "status": joinRequest.Status},
+	}, nil
+}
+
+// Get join request HTTP API endpoint, by ?id=
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetJoinRequestHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	joinRequest, getErr := GetJoinRequest(conn, client, c.Query("id"))
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	team, teamErr := GetTeam(conn, client, &models.Team{ID: joinRequest.Team})
+	if teamErr != nil {
+		return nil, teamErr
+	}
+
+	if joinRequest.User != request.User.ID && !CanManageTeam(conn, client, request.User, team) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the join request",
+		}
+	}
+
+	return &models.Response{
+		Code: utils.HTTP_STATUS_OK,
+		Response: gin.H{
+			"message": "Join request found",
+			"request": joinRequest,
+			"team":    gin.H{"id": team.ID, "name": team.Name, "description": team.Description},
+		},
+	}, nil
+}
+
+// Approve join request HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func ApproveJoinRequestHttp(c *gin.Context) (*models.Response, *models.Error) {
+	return respondJoinRequestHttp(c, true)
+}
+
+// Reject join request HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func RejectJoinRequestHttp(c *gin.Context) (*models.Response, *models.Error) {
+	return respondJoinRequestHttp(c, false)
+}
+
+func respondJoinRequestHttp(c *gin.Context, approve bool) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *JoinTeamRequest = &JoinTeamRequest{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	joinRequest, getErr := GetJoinRequest(conn, client, params.ID)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	team, teamErr := GetTeam(conn, client, &models.Team{ID: joinRequest.Team})
+	if teamErr != nil {
+		return nil, teamErr
+	}
+
+	if !CanManageTeam(conn, client, request.User, team) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot answer the join request",
+		}
+	}
+
+	respondErr := RespondJoinRequest(conn, client, request.User, joinRequest, approve)
+	if respondErr != nil {
+		return nil, respondErr
+	}
+
+	message := "Join request rejected"
+	if approve {
+		message = "Join request approved"
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": message, "team": joinRequest.Team},
+	}, nil
+}
+
+// Cancel join request HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func CancelJoinRequestHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *JoinTeamRequest = &JoinTeamRequest{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	joinRequest, getErr := GetJoinRequest(conn, client, params.ID)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if joinRequest.User != request.User.ID {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Only the requester can cancel the join request",
+		}
+	}
+
+	cancelErr := CancelJoinRequest(conn, client, joinRequest)
+	if cancelErr != nil {
+		return nil, cancelErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Join request cancelled"},
+	}, nil
+}
+
+// Get pending join requests HTTP API endpoint, with ?team=
+// the requests to the team, otherwise the requests of the user
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetJoinRequestsHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	if c.Query("team") == "" {
+		requests, getErr := GetUserJoinRequests(conn, client, request.User)
+		if getErr != nil {
+			return nil, getErr
+		}
+
+		return &models.Response{
+			Code:     utils.HTTP_STATUS_OK,
+			Response: gin.H{"message": "Join requests found", "requests": requests},
+		}, nil
+	}
+
+	team, teamErr := GetTeam(conn, client, &models.Team{ID: c.Query("team")})
+	if teamErr != nil {
+		return nil, teamErr
+	}
+
+	if !CanManageTeam(conn, client, request.User, team) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the team join requests",
+		}
+	}
+
+	requests, getErr := GetTeamJoinRequests(conn, client, team)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Join requests found", "requests": requests},
+	}, nil
+}
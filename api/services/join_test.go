@@ -0,0 +1,55 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+)
+
+func TestTeamVisibility(t *testing.T) {
+
+	for _, visibility := range []string{models.TEAM_VISIBILITY_PUBLIC, models.TEAM_VISIBILITY_DISCOVERABLE, models.TEAM_VISIBILITY_PRIVATE} {
+		if validateTeamVisibility(visibility) != nil {
+			t.Error("The visibility must be valid", visibility)
+			return
+		}
+	}
+
+	if err := validateTeamVisibility("secret"); err == nil || err.Error != error.INVALID_TEAM_VISIBILITY {
+		t.Error("Unknown visibilities must not be valid")
+		return
+	}
+
+	var stranger = &models.User{ID: "stranger"}
+
+	// Public teams are seen without looking for the membership
+	if !CanSeeTeam(nil, nil, stranger, &models.Team{Visibility: models.TEAM_VISIBILITY_PUBLIC}) {
+		t.Error("Public teams must be seen by everyone")
+		return
+	}
+
+	if (&models.Team{}).IsListed() || (&models.Team{Visibility: models.TEAM_VISIBILITY_PRIVATE}).IsListed() {
+		t.Error("Private teams must not be listed")
+		return
+	}
+
+	log.Info("Team visibility checked")
+}
+
+func TestJoinRequestPending(t *testing.T) {
+
+	if checkJoinRequestPending(&models.JoinRequest{Status: models.JOIN_REQUEST_PENDING}) != nil {
+		t.Error("Pending join requests must be answered")
+		return
+	}
+
+	err := checkJoinRequestPending(&models.JoinRequest{Status: models.JOIN_REQUEST_APPROVED})
+	if err == nil || err.Error != error.JOIN_REQUEST_NOT_PENDING {
+		t.Error("Answered join requests must not be answered again")
+		return
+	}
+
+	log.Info("Join requests checked")
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/lang"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/mail"
+)
+
+func TestLangNegotiation(t *testing.T) {
+
+	var expected = map[string]string{
+		"":                          lang.DEFAULT_LOCALE,
+		"es":                        "es",
+		"es_es":                     "es-ES",
+		"fr-FR,es;q=0.8,en;q=0.9":   "en",
+		"fr-FR, es-MX;q=0.5, *":     "es-MX",
+		"en;q=0, es;q=0.1":          "es",
+		"de, fr;q=0.7":              lang.DEFAULT_LOCALE,
+		"en-GB,en;q=0.9,es;q=0.8":   "en-GB",
+		"es;q=invalid, en;q=0.9999": "es",
+	}
+
+	for header, locale := range expected {
+		if negotiated := lang.Negotiate(header); negotiated != locale {
+			t.Error("Unexpected locale for", header, negotiated)
+			return
+		}
+	}
+
+	log.Info("Locale negotiation checked")
+}
+
+func TestLangFallbacks(t *testing.T) {
+
+	chain := lang.Fallbacks("es-MX")
+	if strings.Join(chain, ",") != "es-MX,es,en" {
+		t.Error("Unexpected fallback chain", chain)
+		return
+	}
+
+	if lang.Resolve("es-MX") != "es" || lang.Resolve("fr") != lang.DEFAULT_LOCALE {
+		t.Error("Locales must resolve to a catalog")
+		return
+	}
+
+	if lang.Translate("es-MX", "mail.greeting", "Odin") != "Hola Odin," {
+		t.Error("Regions must fall back to their language")
+		return
+	}
+
+	if lang.Translate("fr", "mail.greeting", "Odin") != "Hi Odin," {
+		t.Error("Unknown locales must fall back to the default one")
+		return
+	}
+
+	if lang.Translate("es", "mail.unknown") != "mail.unknown" {
+		t.Error("Missing messages must show their key")
+		return
+	}
+
+	log.Info("Locale fallbacks checked")
+}
+
+func TestLangErrorMessages(t *testing.T) {
+
+	if lang.ErrorMessage("es-ES", error.USER_NOT_FOUND, "User not found") != "Usuario no encontrado" {
+		t.Error("Error messages must be translated")
+		return
+	}
+
+	if lang.ErrorMessage("en", error.USER_NOT_FOUND, "User not found") != "User not found" {
+		t.Error("English error messages must be the ones of the services")
+		return
+	}
+
+	// Every catalog must translate the same messages as the default
+	for _, locale := range lang.Locales() {
+		for _, key := range []string{"mail.greeting", "mail.footer", "mail.invitation.subject"} {
+			if _, found := lang.Lookup(locale, key); !found {
+				t.Error("Missing message", locale, key)
+				return
+			}
+		}
+	}
+
+	log.Info("Error messages checked")
+}
+
+func TestLangMailTemplates(t *testing.T) {
+
+	message, err := mail.Render(mail.TEMPLATE_VALIDATION, "es-ES", "thor@valhalla.local", map[string]interface{}{
+		"Username": "Thor",
+		"Link":     "https://valhalla.local/validate",
+	})
+
+	if err != nil {
+		t.Error("The template was not rendered", err)
+		return
+	}
+
+	if message.Subject != "Valida tu cuenta de Valhalla" || !strings.Contains(message.Text, "Hola Thor,") {
+		t.Error("The email must be in the locale of the recipient", message.Subject)
+		return
+	}
+
+	if !strings.Contains(message.HTML, `lang="es"`) {
+		t.Error("The html must declare its language")
+		return
+	}
+
+	english, _ := mail.Render(mail.TEMPLATE_VALIDATION, lang.DEFAULT_LOCALE, "thor@valhalla.local", map[string]interface{}{})
+	if english.Subject != "Validate your Valhalla account" {
+		t.Error("Rendering a locale must not change the others", english.Subject)
+		return
+	}
+
+	log.Info("Localized mail templates checked")
+}
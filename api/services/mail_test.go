@@ -0,0 +1,168 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akrck02/valhalla-core/lang"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/mail"
+)
+
+func TestMailEncode(t *testing.T) {
+
+	message := &mail.Message{
+		From:    "Valhalla <no-reply@valhalla.local>",
+		To:      "odin@valhalla.local",
+		Subject: "Välkommen",
+		Text:    "Hi Odin,\nwelcome.",
+		HTML:    "<p>Hi Odin, welcome.</p>",
+	}
+
+	encoded, err := mail.Encode(message, time.Unix(0, 0))
+	if err != nil {
+		t.Error("The message was not encoded", err)
+		return
+	}
+
+	parsed, err := netmail.ReadMessage(bytes.NewReader(encoded))
+	if err != nil {
+		t.Error("The message is not a valid email", err)
+		return
+	}
+
+	subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if subject != message.Subject {
+		t.Error("The subject changed", subject)
+		return
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if mediaType != "multipart/alternative" {
+		t.Error("Html messages must have a text alternative", mediaType)
+		return
+	}
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	expected := []string{"text/plain", "text/html"}
+
+	for _, contentType := range expected {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Error("A part is missing", contentType)
+			return
+		}
+
+		if !strings.HasPrefix(part.Header.Get("Content-Type"), contentType) {
+			t.Error("The parts are not in order", part.Header.Get("Content-Type"))
+			return
+		}
+
+		// Quoted-printable parts are decoded by the reader
+		content, _ := io.ReadAll(part)
+		if contentType == "text/plain" && string(content) != "Hi Odin,\r\nwelcome." {
+			t.Error("The text changed", string(content))
+			return
+		}
+	}
+
+	message.Subject = "Hi\r\nBcc: loki@valhalla.local"
+	if _, err := mail.Encode(message, time.Unix(0, 0)); err != mail.ErrInvalidHeader {
+		t.Error("Headers must not be injected")
+		return
+	}
+
+	log.Info("Mail encoding checked")
+}
+
+func TestMailTemplates(t *testing.T) {
+
+	message, err := mail.Render(mail.TEMPLATE_INVITATION, lang.DEFAULT_LOCALE, "thor@valhalla.local", map[string]interface{}{
+		"Inviter": "Odin",
+		"Team":    "<Asgard>",
+		"Link":    "https://valhalla.local/invitation",
+	})
+
+	if err != nil {
+		t.Error("The template was not rendered", err)
+		return
+	}
+
+	if message.Subject != "Odin invited you to <Asgard>" {
+		t.Error("The subject is not the expected", message.Subject)
+		return
+	}
+
+	if !strings.Contains(message.Text, "<Asgard>") || !strings.Contains(message.HTML, "&lt;Asgard&gt;") {
+		t.Error("Only html bodies must be escaped")
+		return
+	}
+
+	for _, template := range []string{mail.TEMPLATE_VALIDATION, mail.TEMPLATE_EMAIL_CHANGE, mail.TEMPLATE_EMAIL_CHANGE_NOTICE} {
+		if _, err := mail.Render(template, lang.DEFAULT_LOCALE, "thor@valhalla.local", map[string]interface{}{}); err != nil {
+			t.Error("The template was not rendered", template, err)
+			return
+		}
+	}
+
+	log.Info("Mail templates checked")
+}
+
+func TestMailMbox(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "outbox.mbox")
+	sink := mail.NewFile(path)
+
+	message := &mail.Message{
+		From:    mail.DEFAULT_FROM,
+		To:      "thor@valhalla.local",
+		Subject: "Hammer",
+		Text:    "From now on\nthe hammer is yours.",
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := sink.Send(context.Background(), message); err != nil {
+			t.Error("The message was not written", err)
+			return
+		}
+	}
+
+	content, _ := os.ReadFile(path)
+	entries := strings.Count(string(content), "\nFrom no-reply@valhalla.local ")
+
+	if !strings.HasPrefix(string(content), "From no-reply@valhalla.local ") || entries != 1 {
+		t.Error("Every message must be an mbox entry")
+		return
+	}
+
+	if !strings.Contains(string(content), "\n>From now on") {
+		t.Error("Lines starting like an entry must be quoted")
+		return
+	}
+
+	log.Info("Mail mbox checked")
+}
+
+func TestOutboxBackoff(t *testing.T) {
+
+	if outboxBackoff(1) != OUTBOX_BACKOFF || outboxBackoff(2) != 2*OUTBOX_BACKOFF || outboxBackoff(3) != 4*OUTBOX_BACKOFF {
+		t.Error("The wait must double on every attempt")
+		return
+	}
+
+	if outboxBackoff(OUTBOX_MAX_ATTEMPTS*4) != OUTBOX_MAX_BACKOFF {
+		t.Error("The wait must be limited")
+		return
+	}
+
+	log.Info("Outbox backoff checked")
+}
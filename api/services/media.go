@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/imaging"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/storage"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Namespace of the images on the blob storage
+const IMAGES_NAMESPACE = "images"
+
+// Process a profile picture and store it with its thumbnails as a new
+// media, the caller deletes the previous media once it is replaced
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] kind | string: kind of the picture owner, user or team
+// [param] owner | string: id of the picture owner
+// [param] picture | []byte: uploaded picture
+//
+// [return] *models.Media: media stored --> *models.Error: error if any
+func savePictureMedia(conn context.Context, client *mongo.Client, kind string, owner string, picture []byte) (*models.Media, *models.Error) {
+
+	processed, processErr := imaging.Process(picture)
+	if processErr != nil {
+		return nil, processErr
+	}
+
+	files := map[string][]byte{models.MEDIA_ORIGINAL: processed.Original}
+	for size, thumbnail := range processed.Thumbnails {
+		files[strconv.Itoa(size)] = thumbnail
+	}
+
+	hash := sha256.Sum256(processed.Original)
+	media := &models.Media{
+		Kind:         kind,
+		Owner:        owner,
+		ContentType:  processed.ContentType,
+		Hash:         hex.EncodeToString(hash[:]),
+		Objects:      map[string]string{},
+		CreationDate: utils.GetCurrentMillis(),
+	}
+
+	notSaved := &models.Error{
+		Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+		Error:   int(error.IMAGE_NOT_SAVED),
+		Message: "Image not saved",
+	}
+
+	// Objects are stored before the media references them
+	for variant, content := range files {
+		key := storage.ContentAddress(IMAGES_NAMESPACE, content, processed.Extension)
+
+		if storage.Current.Put(conn, key, content, processed.ContentType) != nil {
+			return nil, notSaved
+		}
+
+		media.Objects[variant] = key
+	}
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.MEDIA)
+	result, err := coll.InsertOne(conn, media)
+
+	if err != nil {
+		return nil, notSaved
+	}
+
+	media.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return media, nil
+}
+
+// Get media logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] id | string: id of the media
+//
+// [return] *models.Media: media found --> *models.Error: error if any
+func GetMedia(conn context.Context, client *mongo.Client, id string) (*models.Media, *models.Error) {
+
+	notFound := &models.Error{
+		Status:  utils.HTTP_STATUS_NOT_FOUND,
+		Error:   int(error.MEDIA_NOT_FOUND),
+		Message: "Media not found",
+	}
+
+	objID, err := utils.StringToObjectId(id)
+	if err != nil {
+		return nil, notFound
+	}
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.MEDIA)
+
+	var found models.Media
+	if coll.FindOne(conn, bson.M{"_id": objID}).Decode(&found) != nil {
+		return nil, notFound
+	}
+
+	return &found, nil
+}
+
+// Delete a media and the objects no other media uses,
+// failures are logged as the media is no longer referenced.
+// Only the picture of the given user or team is deleted
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] id | string: id of the media
+// [param] kind | string: kind of the owner of the media
+// [param] owner | string: id of the user or team whose picture it is
+func deleteMedia(conn context.Context, client *mongo.Client, id string, kind string, owner string) {
+
+	media, getErr := GetMedia(conn, client, id)
+	if getErr != nil {
+		return
+	}
+
+	if media.Kind != kind || media.Owner != owner {
+		log.FormattedError("Media ${0} is not the picture of ${1}", id, owner)
+		return
+	}
+
+	objID, _ := utils.StringToObjectId(id)
+	coll := client.Database(db.CurrentDatabase).Collection(db.MEDIA)
+	_, err := coll.DeleteOne(conn, bson.M{"_id": objID})
+
+	if err != nil {
+		log.FormattedError("Cannot delete media ${0}: ${1}", id, err.Error())
+		return
+	}
+
+	// The same picture uploaded again shares its objects
+	shared, err := coll.CountDocuments(conn, bson.M{"hash": media.Hash})
+	if err != nil || shared > 0 {
+		return
+	}
+
+	for _, key := range media.Objects {
+		err = storage.Current.Delete(conn, key)
+
+		if err != nil {
+			log.FormattedError("Cannot delete object ${0}: ${1}", key, err.Error())
+		}
+	}
+}
+
+// Parse the path of a media url, /{id} for the
+// original picture or /{id}/{size} for a thumbnail
+//
+// [param] path | string: path after the media endpoint
+//
+// [return] string: media id --> int: thumbnail size, 0 for the original --> *models.Error: error if the path is not valid
+func parseMediaPath(path string) (string, int, *models.Error) {
+
+	notFound := &models.Error{
+		Status:  utils.HTTP_STATUS_NOT_FOUND,
+		Error:   int(error.MEDIA_NOT_FOUND),
+		Message: "Media not found",
+	}
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if parts[0] == "" || len(parts) > 2 {
+		return "", 0, notFound
+	}
+
+	if len(parts) == 1 {
+		return parts[0], 0, nil
+	}
+
+	size, err := strconv.Atoi(parts[1])
+	if err != nil || size <= 0 {
+		return "", 0, notFound
+	}
+
+	return parts[0], size, nil
+}
+
+// Get the object key of a media file
+//
+// [param] media | *models.Media: media of the file
+// [param] size | int: thumbnail size, 0 for the original
+//
+// [return] string: key of the object --> bool: true if the media has the file
+func mediaObjectKey(media *models.Media, size int) (string, bool) {
+
+	variant := models.MEDIA_ORIGINAL
+	if size != 0 {
+		variant = strconv.Itoa(size)
+	}
+
+	key, found := media.Objects[variant]
+	return key, found
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/storage"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Time clients can cache media without revalidating
+const MEDIA_MAX_AGE = 24 * 60 * 60
+
+// Get media HTTP API endpoint, streams media/{id} or the thumbnail
+// media/{id}/{size} supporting ranges and conditional requests
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetMediaHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	id, size, pathErr := parseMediaPath(c.Param("path"))
+	if pathErr != nil {
+		return nil, pathErr
+	}
+
+	media, getErr := GetMedia(conn, client, id)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if !CanSeeMedia(conn, client, request.User, media) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the media",
+		}
+	}
+
+	serveErr := serveMedia(c, media, size)
+	if serveErr != nil {
+		return nil, serveErr
+	}
+
+	return nil, nil
+}
+
+// Stream a media file supporting ranges and conditional requests
+//
+// [param] c | *gin.Context: context
+// [param] media | *models.Media: media to stream
+// [param] size | int: thumbnail size, 0 for the original
+//
+// [return] *models.Error: error if the file is not found
+func serveMedia(c *gin.Context, media *models.Media, size int) *models.Error {
+
+	notFound := &models.Error{
+		Status:  utils.HTTP_STATUS_NOT_FOUND,
+		Error:   error.MEDIA_NOT_FOUND,
+		Message: "Media not found",
+	}
+
+	key, found := mediaObjectKey(media, size)
+	if !found {
+		return notFound
+	}
+
+	content, err := storage.Current.Get(c.Request.Context(), key)
+	if err != nil {
+		return notFound
+	}
+
+	// Media never changes, a new upload is a new media
+	c.Header("ETag", "\""+media.Hash+"-"+strconv.Itoa(size)+"\"")
+	c.Header("Cache-Control", "private, max-age="+strconv.Itoa(MEDIA_MAX_AGE))
+	c.Header("Content-Type", media.ContentType)
+	http.ServeContent(c.Writer, c.Request, "", time.UnixMilli(media.CreationDate), bytes.NewReader(content))
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/imaging"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/mock"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestProcessPicture(t *testing.T) {
+
+	picture, readErr := utils.ReadFile(mock.ProfilePicture())
+
+	if readErr != nil {
+		t.Error("The file was not read", readErr)
+		return
+	}
+
+	processed, err := imaging.Process(picture)
+
+	if err != nil {
+		t.Error("The picture was not processed", err)
+		return
+	}
+
+	if processed.ContentType != imaging.CONTENT_TYPE_JPEG || bytes.Contains(processed.Original, []byte("Exif")) {
+		t.Error("The picture must be re-encoded as jpeg without metadata")
+		return
+	}
+
+	for _, size := range imaging.THUMBNAIL_SIZES {
+		config, _, decodeErr := image.DecodeConfig(bytes.NewReader(processed.Thumbnails[size]))
+
+		if decodeErr != nil || config.Width != size || config.Height != size {
+			t.Error("Unexpected thumbnail of size", size)
+			return
+		}
+	}
+
+	log.Info("Picture processed")
+}
+
+func TestRejectPicture(t *testing.T) {
+
+	if _, err := imaging.Process([]byte("<html>not an image</html>")); err == nil || err.Error != error.UNSUPPORTED_IMAGE {
+		t.Error("Only images must be accepted")
+		return
+	}
+
+	var tiny bytes.Buffer
+	png.Encode(&tiny, image.NewRGBA(image.Rect(0, 0, 8, 8)))
+
+	if _, err := imaging.Process(tiny.Bytes()); err == nil || err.Error != error.IMAGE_DIMENSIONS {
+		t.Error("Small images must be rejected")
+		return
+	}
+
+	if _, err := imaging.Process(make([]byte, imaging.MAX_IMAGE_BYTES+1)); err == nil || err.Error != error.IMAGE_TOO_LARGE {
+		t.Error("Big images must be rejected")
+		return
+	}
+
+	log.Info("Pictures rejected")
+}
+
+func TestMediaPath(t *testing.T) {
+
+	if id, size, err := parseMediaPath("/65a1b2c3d4e5f6a7b8c9d0e1"); err != nil || id != "65a1b2c3d4e5f6a7b8c9d0e1" || size != 0 {
+		t.Error("Media paths without size are the original", id, size, err)
+		return
+	}
+
+	if id, size, err := parseMediaPath("/65a1b2c3d4e5f6a7b8c9d0e1/64"); err != nil || id != "65a1b2c3d4e5f6a7b8c9d0e1" || size != 64 {
+		t.Error("Media paths with size are thumbnails", id, size, err)
+		return
+	}
+
+	for _, path := range []string{"/", "/id/big", "/id/-1", "/id/64/more"} {
+		if _, _, err := parseMediaPath(path); err == nil || err.Error != error.MEDIA_NOT_FOUND {
+			t.Error("Invalid media paths must not be found", path)
+			return
+		}
+	}
+
+	var media = &models.Media{Objects: map[string]string{
+		models.MEDIA_ORIGINAL: "images/ab/ab.png",
+		"64":                  "images/cd/cd.png",
+	}}
+
+	if key, found := mediaObjectKey(media, 0); !found || key != "images/ab/ab.png" {
+		t.Error("The original must be found", key)
+		return
+	}
+
+	if key, found := mediaObjectKey(media, 64); !found || key != "images/cd/cd.png" {
+		t.Error("The thumbnail must be found", key)
+		return
+	}
+
+	if _, found := mediaObjectKey(media, 128); found {
+		t.Error("Missing thumbnails must not be found")
+		return
+	}
+
+	log.Info("Media paths checked")
+}
+
+func TestDeleteOtherMedia(t *testing.T) {
+
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	picture, readErr := utils.ReadFile(mock.ProfilePicture())
+	if readErr != nil {
+		t.Error("The file was not read", readErr)
+		return
+	}
+
+	var owner = primitive.NewObjectID().Hex()
+	media, err := savePictureMedia(conn, client, models.MEDIA_OF_TEAM, owner, picture)
+	if err != nil {
+		t.Error("The media was not saved", err)
+		return
+	}
+
+	defer deleteMedia(conn, client, media.ID, models.MEDIA_OF_TEAM, owner)
+
+	deleteMedia(conn, client, media.ID, models.MEDIA_OF_USER, owner)
+	deleteMedia(conn, client, media.ID, models.MEDIA_OF_TEAM, primitive.NewObjectID().Hex())
+
+	if _, err = GetMedia(conn, client, media.ID); err != nil {
+		t.Error("Only the picture of the given user or team must be deleted")
+		return
+	}
+
+	log.Info("Media of others kept")
+}
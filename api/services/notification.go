@@ -3,19 +3,343 @@ package services
 import (
 	"context"
 
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/events"
+	"github.com/akrck02/valhalla-core/log"
 	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type Alert struct {
-	Title   string
-	Message string
+// Notify a user storing the notification on database, users
+// who turned that kind of notifications off do not get it
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] notification | *models.Notification: notification to store
+//
+// [return] *models.Error: error if any
+func NotifyUser(conn context.Context, client *mongo.Client, notification *models.Notification) *models.Error {
+
+	preferences, _, preferencesErr := findPreferences(conn, client, &models.User{ID: notification.User})
+	if preferencesErr == nil && !wantsNotification(preferences, notification.Type) {
+		return nil
+	}
+
+	notification.Read = false
+	notification.ReadDate = 0
+	notification.CreationDate = utils.GetCurrentMillis()
+
+	notifications := client.Database(db.CurrentDatabase).Collection(db.NOTIFICATION)
+	result, err := notifications.InsertOne(conn, notification)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Notification not created",
+		}
+	}
+
+	notification.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	events.Publish(events.EVENT_NOTIFICATION, notification, []string{notification.User})
+	return nil
+}
+
+// Notify the owner and members of a team, failures
+// are logged so the rest still get the notification
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] team | *models.Team: team to notify
+// [param] notification | models.Notification: notification to send, without user
+// [param] except | ...string: ids of the users not to notify
+func NotifyTeam(conn context.Context, client *mongo.Client, team *models.Team, notification models.Notification, except ...string) {
+
+	skip := map[string]bool{}
+	for _, user := range except {
+		skip[user] = true
+	}
+
+	for _, user := range append([]string{team.Owner}, team.Members...) {
+		if user == "" || skip[user] {
+			continue
+		}
+
+		skip[user] = true
+		userNotification := notification
+		userNotification.User = user
+
+		notifyErr := NotifyUser(conn, client, &userNotification)
+		if notifyErr != nil {
+			log.FormattedError("Cannot notify ${0} of team ${1}: ${2}", user, team.ID, notifyErr.Message)
+		}
+	}
+}
+
+// Notify a new member of a team and the rest of it, members
+// joining by themselves are not notified but get the event
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] teamID | string: id of the team
+// [param] member | string: id of the new member
+// [param] author | string: id of the user adding the member
+func notifyMemberAdded(conn context.Context, client *mongo.Client, teamID string, member string, author string) {
+
+	team, teamErr := GetTeam(conn, client, &models.Team{ID: teamID})
+	if teamErr != nil {
+		return
+	}
+
+	user, userErr := GetUserById(conn, client, member)
+	if userErr != nil {
+		return
+	}
+
+	if author != member {
+		NotifyUser(conn, client, &models.Notification{
+			User:   member,
+			Type:   models.NOTIFICATION_MEMBER,
+			Title:  "You were added to " + team.Name,
+			Source: team.ID,
+		})
+	}
+
+	NotifyTeam(conn, client, team, models.Notification{
+		Type:   models.NOTIFICATION_MEMBER,
+		Title:  user.Username + " joined " + team.Name,
+		Source: team.ID,
+	}, member, author)
+
+	publishTeamEvent(conn, client, team, events.EVENT_TEAM_MEMBER_ADDED, map[string]string{"team": team.ID, "user": member})
+}
+
+// Get if the preferences of a user let a notification through
+//
+// [param] preferences | *models.Preferences: preferences of the user
+// [param] kind | string: type of the notification
+//
+// [return] bool: true if the user wants the notification
+func wantsNotification(preferences *models.Preferences, kind string) bool {
+
+	if !preferences.Notifications.InApp {
+		return false
+	}
+
+	switch kind {
+	case models.NOTIFICATION_MENTION:
+		return preferences.Notifications.Mentions
+	case models.NOTIFICATION_REMINDER:
+		return preferences.Notifications.Reminders
+	case models.NOTIFICATION_INVITATION:
+		return preferences.Notifications.Invitations
+	case models.NOTIFICATION_MEMBER, models.NOTIFICATION_TRANSFER, models.NOTIFICATION_JOIN:
+		return preferences.Notifications.Teams
+	}
+
+	return true
+}
+
+// Get the notifications of a user, newest first. Notifications
+// stored before the inbox existed have no read field and are unread
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: owner of the notifications
+// [param] unread | bool: only get the unread notifications
+// [param] request | *models.PageRequest: page to get
+//
+// [return] *models.Page: page of notifications --> *models.Error: error if any
+func GetNotifications(conn context.Context, client *mongo.Client, user *models.User, unread bool, request *models.PageRequest) (*models.Page, *models.Error) {
+
+	cursor, cursorErr := decodeCursor(request.Cursor)
+	if cursorErr != nil {
+		return nil, cursorErr
+	}
+
+	notificationsErr := &models.Error{
+		Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+		Error:   int(error.UNEXPECTED_ERROR),
+		Message: "Cannot get notifications",
+	}
+
+	filter := bson.M{"user": user.ID}
+	if unread {
+		filter["read"] = bson.M{"$ne": true}
+	}
+
+	notifications := client.Database(db.CurrentDatabase).Collection(db.NOTIFICATION)
+	total, err := notifications.CountDocuments(conn, filter)
+
+	if err != nil {
+		return nil, notificationsErr
+	}
+
+	if cursor != nil {
+		after, keysetErr := keysetFilter("_id", true, cursor)
+		if keysetErr != nil {
+			return nil, keysetErr
+		}
+
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	found, err := notifications.Find(conn, filter, options.Find().
+		SetSort(keysetSort("_id", true)).
+		SetLimit(int64(request.Limit+1)))
+
+	items := []models.Notification{}
+	if err != nil || found.All(conn, &items) != nil {
+		return nil, notificationsErr
+	}
+
+	page := &models.Page{Total: total, Limit: request.Limit}
+
+	if len(items) > request.Limit {
+		items = items[:request.Limit]
+		page.NextCursor = encodeCursor(pageCursor{ID: items[len(items)-1].ID})
+	}
+
+	page.Items = items
+	return page, nil
+}
+
+// Count the unread notifications of a user
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: owner of the notifications
+//
+// [return] int64: unread notifications --> *models.Error: error if any
+func CountUnreadNotifications(conn context.Context, client *mongo.Client, user *models.User) (int64, *models.Error) {
+
+	notifications := client.Database(db.CurrentDatabase).Collection(db.NOTIFICATION)
+	unread, err := notifications.CountDocuments(conn, bson.M{"user": user.ID, "read": bson.M{"$ne": true}})
+
+	if err != nil {
+		return 0, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot count notifications",
+		}
+	}
+
+	return unread, nil
+}
+
+// Mark a notification of a user as read
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: owner of the notification
+// [param] notification | *models.Notification: notification to mark
+//
+// [return] *models.Error: error if any
+func MarkNotificationRead(conn context.Context, client *mongo.Client, user *models.User, notification *models.Notification) *models.Error {
+
+	objID, err := utils.StringToObjectId(notification.ID)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.BAD_OBJECT_ID),
+			Message: "Bad object id",
+		}
+	}
+
+	// Notifications already read keep their read date
+	notifications := client.Database(db.CurrentDatabase).Collection(db.NOTIFICATION)
+	result, err := notifications.UpdateOne(conn,
+		bson.M{"_id": objID, "user": user.ID, "read": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"read": true, "read_date": utils.GetCurrentMillis()}},
+	)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.NOTIFICATION_NOT_UPDATED),
+			Message: "Notification not updated",
+		}
+	}
+
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	found, err := notifications.CountDocuments(conn, bson.M{"_id": objID, "user": user.ID})
+
+	if err != nil || found == 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.NOTIFICATION_NOT_FOUND),
+			Message: "Notification not found",
+		}
+	}
+
+	return nil
 }
 
-func AlertTeam(conn context.Context, client *mongo.Client, team models.Team) models.Error {
+// Mark every unread notification of a user as read
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: owner of the notifications
+//
+// [return] int64: notifications marked --> *models.Error: error if any
+func MarkAllNotificationsRead(conn context.Context, client *mongo.Client, user *models.User) (int64, *models.Error) {
+
+	notifications := client.Database(db.CurrentDatabase).Collection(db.NOTIFICATION)
+	result, err := notifications.UpdateMany(conn,
+		bson.M{"user": user.ID, "read": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"read": true, "read_date": utils.GetCurrentMillis()}},
+	)
 
-	return models.Error{
-		Status:  200,
-		Message: "Ok.",
+	if err != nil {
+		return 0, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.NOTIFICATION_NOT_UPDATED),
+			Message: "Notifications not updated",
+		}
 	}
+
+	return result.ModifiedCount, nil
+}
+
+// Delete a notification of a user
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: owner of the notification
+// [param] notification | *models.Notification: notification to delete
+//
+// [return] *models.Error: error if any
+func DeleteNotification(conn context.Context, client *mongo.Client, user *models.User, notification *models.Notification) *models.Error {
+
+	objID, err := utils.StringToObjectId(notification.ID)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.BAD_OBJECT_ID),
+			Message: "Bad object id",
+		}
+	}
+
+	notifications := client.Database(db.CurrentDatabase).Collection(db.NOTIFICATION)
+	result, err := notifications.DeleteOne(conn, bson.M{"_id": objID, "user": user.ID})
+
+	if err != nil || result.DeletedCount == 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.NOTIFICATION_NOT_FOUND),
+			Message: "Notification not found",
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+)
+
+func TestNotificationPreferences(t *testing.T) {
+
+	var preferences = models.DefaultPreferences("user")
+
+	for _, kind := range []string{models.NOTIFICATION_MENTION, models.NOTIFICATION_MEMBER, models.NOTIFICATION_ASSIGNMENT, models.NOTIFICATION_EXPORT} {
+		if !wantsNotification(preferences, kind) {
+			t.Error("Default preferences must let every notification through", kind)
+			return
+		}
+	}
+
+	preferences.Notifications.Teams = false
+	if wantsNotification(preferences, models.NOTIFICATION_MEMBER) || wantsNotification(preferences, models.NOTIFICATION_JOIN) {
+		t.Error("Team notifications must be turned off")
+		return
+	}
+
+	if !wantsNotification(preferences, models.NOTIFICATION_ASSIGNMENT) {
+		t.Error("Only the team notifications must be turned off")
+		return
+	}
+
+	preferences.Notifications.InApp = false
+	if wantsNotification(preferences, models.NOTIFICATION_ASSIGNMENT) {
+		t.Error("Users without in app notifications must not get any")
+		return
+	}
+
+	log.Info("Notification preferences checked")
+}
@@ -0,0 +1,514 @@
+package services
+
+import (
+	"context"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TeamParentRequest struct {
+	Team   string `json:"teamid"`
+	Parent string `json:"parentid"`
+}
+
+// Get the role of a user on a team including the roles inherited
+// from the hierarchy: owners and admins of a parent team are owners
+// and admins of every child team, members of a child team are
+// members of every parent team
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] team | *models.Team: team to check
+// [param] user | string: id of the user
+//
+// [return] string: role of the user, empty if not a member
+func TeamRoleOf(conn context.Context, client *mongo.Client, team *models.Team, user string) string {
+
+	if team == nil {
+		return ""
+	}
+
+	role := inheritedTeamRole(team, getTeamAncestors(conn, client, team), user)
+	if role != "" {
+		return role
+	}
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+	count, err := coll.CountDocuments(conn, bson.M{
+		"ancestors": team.ID,
+		"$or": bson.A{
+			bson.M{"owner": user},
+			bson.M{"members": user},
+		},
+	})
+
+	if err != nil || count == 0 {
+		return ""
+	}
+
+	return models.TEAM_ROLE_MEMBER
+}
+
+// Get the role of a user on a team inherited from its ancestors,
+// the highest role of the user on the team or any ancestor wins
+// but ancestor members are not members of the children
+//
+// [param] team | *models.Team: team to check
+// [param] ancestors | []models.Team: ancestors of the team
+// [param] user | string: id of the user
+//
+// [return] string: role of the user, empty if not a member
+func inheritedTeamRole(team *models.Team, ancestors []models.Team, user string) string {
+
+	role := team.RoleOf(user)
+
+	for _, ancestor := range ancestors {
+		switch ancestor.RoleOf(user) {
+		case models.TEAM_ROLE_OWNER:
+			return models.TEAM_ROLE_OWNER
+		case models.TEAM_ROLE_ADMIN:
+			if role != models.TEAM_ROLE_OWNER {
+				role = models.TEAM_ROLE_ADMIN
+			}
+		}
+	}
+
+	return role
+}
+
+// Get the ancestors of a team, missing ancestors are skipped
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] team | *models.Team: team to get the ancestors of
+//
+// [return] []models.Team: ancestors of the team
+func getTeamAncestors(conn context.Context, client *mongo.Client, team *models.Team) []models.Team {
+
+	ancestors := []models.Team{}
+	objIDs := toObjectIds(team.Ancestors)
+
+	if len(objIDs) == 0 {
+		return ancestors
+	}
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+	found, err := coll.Find(conn, bson.M{"_id": bson.M{"$in": objIDs}})
+
+	if err != nil || found.All(conn, &ancestors) != nil {
+		return []models.Team{}
+	}
+
+	return ancestors
+}
+
+// Get a team and all its descendants
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] team | *models.Team: root of the tree
+//
+// [return] []models.Team: teams of the tree, the root first --> *models.Error: error if any
+func getTeamTree(conn context.Context, client *mongo.Client, team *models.Team) ([]models.Team, *models.Error) {
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+	found, err := coll.Find(conn, bson.M{"ancestors": team.ID})
+
+	descendants := []models.Team{}
+	if err != nil || found.All(conn, &descendants) != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.TEAM_SEARCH_ERROR),
+			Message: "Cannot get the child teams",
+		}
+	}
+
+	return append([]models.Team{*team}, descendants...), nil
+}
+
+// Get the teams of a tree a user has a role on, the same
+// rule TeamRoleOf follows: roles of the team, owners and admins
+// of its ancestors and members of its descendants
+//
+// [param] tree | []models.Team: teams of the tree
+// [param] ancestors | []models.Team: ancestors of the root of the tree
+// [param] user | string: id of the user
+//
+// [return] []string: ids of the teams the user has a role on
+func visibleTreeTeams(tree []models.Team, ancestors []models.Team, user string) []string {
+
+	teams := map[string]models.Team{}
+	for _, team := range append(append([]models.Team{}, ancestors...), tree...) {
+		teams[team.ID] = team
+	}
+
+	// Members of a team are members of all its ancestors
+	visible := map[string]bool{}
+	for i := range tree {
+		if tree[i].RoleOf(user) != "" {
+			visible[tree[i].ID] = true
+			for _, ancestor := range tree[i].Ancestors {
+				visible[ancestor] = true
+			}
+		}
+	}
+
+	ids := []string{}
+	for i := range tree {
+		if !visible[tree[i].ID] {
+			teamAncestors := []models.Team{}
+			for _, ancestor := range tree[i].Ancestors {
+				if found, ok := teams[ancestor]; ok {
+					teamAncestors = append(teamAncestors, found)
+				}
+			}
+
+			visible[tree[i].ID] = inheritedTeamRole(&tree[i], teamAncestors, user) != ""
+		}
+
+		if visible[tree[i].ID] {
+			ids = append(ids, tree[i].ID)
+		}
+	}
+
+	return ids
+}
+
+// Move a team under a new parent, or make it an organization
+// without parent. The ancestors of every descendant change too
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] team | *models.Team: team to move
+// [param] parent | *models.Team: new parent, nil to make the team an organization
+//
+// [return] *models.Error: error if any
+func SetTeamParent(conn context.Context, client *mongo.Client, team *models.Team, parent *models.Team) *models.Error {
+
+	if !isValidParent(team, parent) {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_TEAM_PARENT),
+			Message: "A team cannot be a child of itself or its children",
+		}
+	}
+
+	notUpdated := &models.Error{
+		Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+		Error:   int(error.UPDATE_ERROR),
+		Message: "Could not change the team parent",
+	}
+
+	lineage := teamLineage(parent)
+	coll := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+
+	var update bson.M
+	if parent == nil {
+		update = bson.M{"$unset": bson.M{"parent": "", "ancestors": ""}}
+	} else {
+		update = bson.M{"$set": bson.M{"parent": parent.ID, "ancestors": lineage}}
+	}
+
+	objID, _ := utils.StringToObjectId(team.ID)
+	_, err := coll.UpdateOne(conn, bson.M{"_id": objID}, update)
+
+	if err != nil {
+		return notUpdated
+	}
+
+	found, err := coll.Find(conn, bson.M{"ancestors": team.ID})
+
+	descendants := []models.Team{}
+	if err != nil || found.All(conn, &descendants) != nil {
+		return notUpdated
+	}
+
+	for _, descendant := range descendants {
+		descendantID, _ := utils.StringToObjectId(descendant.ID)
+		_, err = coll.UpdateOne(conn, bson.M{"_id": descendantID}, bson.M{"$set": bson.M{
+			"ancestors": rebaseAncestors(descendant.Ancestors, team.ID, lineage),
+		}})
+
+		if err != nil {
+			return notUpdated
+		}
+	}
+
+	team.Parent = ""
+	if parent != nil {
+		team.Parent = parent.ID
+	}
+
+	team.Ancestors = lineage
+	return nil
+}
+
+// Get a page of the direct children of a team. Users who do not
+// manage the team only get the public and discoverable children and
+// the ones they belong to, discoverable ones as a summary
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user getting the children
+// [param] team | *models.Team: parent team
+// [param] request | *models.PageRequest: cursor, limit and sort of the page
+//
+// [return] *models.Page: page of teams --> *models.Error: error if any
+func GetTeamChildren(conn context.Context, client *mongo.Client, user *models.User, team *models.Team, request *models.PageRequest) (*models.Page, *models.Error) {
+
+	filter := bson.M{"parent": team.ID}
+
+	if !CanManageTeam(conn, client, user, team) {
+		belonging, belongingErr := getBelongingTeams(conn, client, team, user.ID)
+		if belongingErr != nil {
+			return nil, belongingErr
+		}
+
+		filter = bson.M{"$and": bson.A{filter, bson.M{"$or": bson.A{
+			bson.M{"visibility": bson.M{"$in": bson.A{models.TEAM_VISIBILITY_PUBLIC, models.TEAM_VISIBILITY_DISCOVERABLE}}},
+			bson.M{"_id": bson.M{"$in": toObjectIds(belonging)}},
+		}}}}
+	}
+
+	page, findErr := findTeams(conn, client, filter, nil, request, []string{models.SORT_BY_NAME, models.SORT_BY_CREATION})
+	if findErr != nil {
+		return nil, findErr
+	}
+
+	page.Items = teamsSeenBy(conn, client, user, page.Items.([]models.Team))
+	return page, nil
+}
+
+// Get the descendants of a team a user belongs to, as owner or
+// member of them or of one of their own descendants
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] team | *models.Team: root of the tree
+// [param] user | string: id of the user
+//
+// [return] []string: ids of the teams --> *models.Error: error if any
+func getBelongingTeams(conn context.Context, client *mongo.Client, team *models.Team, user string) ([]string, *models.Error) {
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+	found, err := coll.Find(conn, bson.M{
+		"ancestors": team.ID,
+		"$or": bson.A{
+			bson.M{"owner": user},
+			bson.M{"members": user},
+		},
+	}, options.Find().SetProjection(bson.M{"_id": 1, "ancestors": 1}))
+
+	teams := []models.Team{}
+	if err != nil || found.All(conn, &teams) != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.TEAM_SEARCH_ERROR),
+			Message: "Cannot get the child teams",
+		}
+	}
+
+	return belongingTeams(teams), nil
+}
+
+// Get the ids of some teams and their ancestors without repeating them
+//
+// [param] teams | []models.Team: teams the user belongs to
+//
+// [return] []string: ids of the teams and their ancestors
+func belongingTeams(teams []models.Team) []string {
+
+	seen := map[string]bool{}
+	ids := []string{}
+
+	for _, team := range teams {
+		for _, id := range append([]string{team.ID}, team.Ancestors...) {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return ids
+}
+
+// Get a page of the projects of a team and all its descendants,
+// for an organization these are all the projects of the organization.
+// Only the projects the user can see are found, the ones the user
+// owns or of the teams of the tree the user has a role on
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user getting the projects
+// [param] team | *models.Team: root of the tree
+// [param] request | *models.PageRequest: cursor, limit and sort of the page
+//
+// [return] *models.Page: page of projects --> *models.Error: error if any
+func GetTeamTreeProjects(conn context.Context, client *mongo.Client, user *models.User, team *models.Team, request *models.PageRequest) (*models.Page, *models.Error) {
+
+	sort, descending, sortErr := parseSort(request.Sort, []string{models.SORT_BY_NAME, models.SORT_BY_CREATION})
+	if sortErr != nil {
+		return nil, sortErr
+	}
+
+	cursor, cursorErr := decodeCursor(request.Cursor)
+	if cursorErr != nil {
+		return nil, cursorErr
+	}
+
+	tree, treeErr := getTeamTree(conn, client, team)
+	if treeErr != nil {
+		return nil, treeErr
+	}
+
+	searchErr := &models.Error{
+		Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+		Error:   int(error.UNEXPECTED_ERROR),
+		Message: "Cannot get projects",
+	}
+
+	treeIDs := []string{}
+	for _, treeTeam := range tree {
+		treeIDs = append(treeIDs, treeTeam.ID)
+	}
+
+	visible := visibleTreeTeams(tree, getTeamAncestors(conn, client, team), user.ID)
+	filter := bson.M{
+		"teams": bson.M{"$in": treeIDs},
+		"$or": bson.A{
+			bson.M{"teams": bson.M{"$in": visible}},
+			bson.M{"owner": user.ID},
+		},
+	}
+	coll := client.Database(db.CurrentDatabase).Collection(db.PROJECT)
+	total, err := coll.CountDocuments(conn, filter)
+
+	if err != nil {
+		return nil, searchErr
+	}
+
+	field := "name"
+	if sort == models.SORT_BY_CREATION {
+		field = "_id"
+	}
+
+	if cursor != nil {
+		after, keysetErr := keysetFilter(field, descending, cursor)
+		if keysetErr != nil {
+			return nil, keysetErr
+		}
+
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	// One more project than the limit tells if there is a next page
+	projects := []models.Project{}
+	found, err := coll.Find(conn, filter, options.Find().
+		SetSort(keysetSort(field, descending)).
+		SetLimit(int64(request.Limit+1)))
+
+	if err != nil || found.All(conn, &projects) != nil {
+		return nil, searchErr
+	}
+
+	page := &models.Page{Total: total, Limit: request.Limit}
+
+	if len(projects) > request.Limit {
+		projects = projects[:request.Limit]
+		last := projects[len(projects)-1]
+		page.NextCursor = encodeCursor(pageCursor{Value: last.Name, ID: last.ID})
+	}
+
+	page.Items = projects
+	return page, nil
+}
+
+// Get if a team can be moved under a parent, a
+// team cannot be a child of itself or its descendants
+//
+// [param] team | *models.Team: team to move
+// [param] parent | *models.Team: new parent, nil for none
+//
+// [return] bool: true if the parent is valid
+func isValidParent(team *models.Team, parent *models.Team) bool {
+
+	if parent == nil {
+		return true
+	}
+
+	if parent.ID == team.ID {
+		return false
+	}
+
+	for _, ancestor := range parent.Ancestors {
+		if ancestor == team.ID {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Get the ancestors of the children of a team,
+// its own ancestors followed by the team
+//
+// [param] parent | *models.Team: parent team, nil for none
+//
+// [return] []string: ancestors of the children, organization first
+func teamLineage(parent *models.Team) []string {
+
+	if parent == nil {
+		return []string{}
+	}
+
+	lineage := make([]string, 0, len(parent.Ancestors)+1)
+	lineage = append(lineage, parent.Ancestors...)
+	return append(lineage, parent.ID)
+}
+
+// Replace the ancestors above a moved team
+//
+// [param] ancestors | []string: ancestors of a descendant of the moved team
+// [param] team | string: id of the moved team
+// [param] lineage | []string: new ancestors of the moved team
+//
+// [return] []string: new ancestors of the descendant
+func rebaseAncestors(ancestors []string, team string, lineage []string) []string {
+
+	rebased := append([]string{}, lineage...)
+
+	for i, ancestor := range ancestors {
+		if ancestor == team {
+			return append(rebased, ancestors[i:]...)
+		}
+	}
+
+	return ancestors
+}
+
+// Convert ids to object ids, skipping the invalid ones
+//
+// [param] ids | []string: ids to convert
+//
+// [return] []primitive.ObjectID: object ids
+func toObjectIds(ids []string) []primitive.ObjectID {
+
+	objIDs := []primitive.ObjectID{}
+	for _, id := range ids {
+		objID, err := utils.StringToObjectId(id)
+
+		if err == nil {
+			objIDs = append(objIDs, objID)
+		}
+	}
+
+	return objIDs
+}
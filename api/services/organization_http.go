@@ -0,0 +1,167 @@
+package services
+
+import (
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Change team parent HTTP API endpoint, an empty parentid
+// makes the team an organization
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func SetTeamParentHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *TeamParentRequest = &TeamParentRequest{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	team, getErr := GetTeam(conn, client, &models.Team{ID: params.Team})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	// Teams leave a hierarchy only by the hand of their managers, and
+	// join one only by the hand of their owner, who must manage the new
+	// parent too. Admins would become owners under a team of their own
+	if !CanManageTeam(conn, client, request.User, team) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot move the team",
+		}
+	}
+
+	var parent *models.Team
+	if params.Parent != "" {
+		parent, getErr = GetTeam(conn, client, &models.Team{ID: params.Parent})
+		if getErr != nil {
+			return nil, getErr
+		}
+
+		if team.Owner != request.User.ID || !CanManageTeam(conn, client, request.User, parent) {
+			return nil, &models.Error{
+				Status:  utils.HTTP_STATUS_FORBIDDEN,
+				Error:   error.ACCESS_DENIED,
+				Message: "Access denied: Cannot move teams under the parent",
+			}
+		}
+	}
+
+	parentErr := SetTeamParent(conn, client, team, parent)
+	if parentErr != nil {
+		return nil, parentErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Team parent changed", "parent": team.Parent, "ancestors": team.Ancestors},
+	}, nil
+}
+
+// Get child teams HTTP API endpoint, by ?id= paginated with ?cursor=, ?limit= and ?sort=
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetTeamChildrenHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	pageRequest, err := utils.GetPageRequest(c)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_PAGE,
+			Message: "Invalid page: " + err.Error(),
+		}
+	}
+
+	team, getErr := GetTeam(conn, client, &models.Team{ID: c.Query("id")})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if !CanSeeTeam(conn, client, request.User, team) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the team",
+		}
+	}
+
+	children, childrenErr := GetTeamChildren(conn, client, request.User, team, pageRequest)
+	if childrenErr != nil {
+		return nil, childrenErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Teams found", "page": children},
+	}, nil
+}
+
+// Get the projects of a team and its child teams HTTP API endpoint,
+// by ?id= paginated with ?cursor=, ?limit= and ?sort=
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetTeamTreeProjectsHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	pageRequest, err := utils.GetPageRequest(c)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_PAGE,
+			Message: "Invalid page: " + err.Error(),
+		}
+	}
+
+	team, getErr := GetTeam(conn, client, &models.Team{ID: c.Query("id")})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	// Projects of public teams are still only seen by the members
+	if TeamRoleOf(conn, client, team, request.User.ID) == "" {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the team",
+		}
+	}
+
+	projects, projectsErr := GetTeamTreeProjects(conn, client, request.User, team, pageRequest)
+	if projectsErr != nil {
+		return nil, projectsErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Projects found", "page": projects},
+	}, nil
+}
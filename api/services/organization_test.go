@@ -0,0 +1,137 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+)
+
+func TestInheritedTeamRole(t *testing.T) {
+
+	var organization = models.Team{
+		ID:      "org",
+		Owner:   "ceo",
+		Members: []string{"head", "employee"},
+		Roles:   map[string]string{"head": models.TEAM_ROLE_ADMIN},
+	}
+
+	var department = models.Team{
+		ID:        "department",
+		Owner:     "lead",
+		Members:   []string{"developer", "head"},
+		Parent:    "org",
+		Ancestors: []string{"org"},
+	}
+
+	var squad = &models.Team{
+		ID:        "squad",
+		Owner:     "developer",
+		Parent:    "department",
+		Ancestors: []string{"org", "department"},
+	}
+
+	ancestors := []models.Team{organization, department}
+
+	if role := inheritedTeamRole(squad, ancestors, "ceo"); role != models.TEAM_ROLE_OWNER {
+		t.Error("Organization owners must own every child team", role)
+		return
+	}
+
+	if role := inheritedTeamRole(squad, ancestors, "head"); role != models.TEAM_ROLE_ADMIN {
+		t.Error("Organization admins must manage every child team", role)
+		return
+	}
+
+	if role := inheritedTeamRole(squad, ancestors, "developer"); role != models.TEAM_ROLE_OWNER {
+		t.Error("Direct roles must not be lowered by the ancestors", role)
+		return
+	}
+
+	if role := inheritedTeamRole(squad, ancestors, "employee"); role != "" {
+		t.Error("Organization members must not join every child team", role)
+		return
+	}
+
+	log.Info("Inherited team roles checked")
+}
+
+func TestTeamParent(t *testing.T) {
+
+	var organization = &models.Team{ID: "org"}
+	var department = &models.Team{ID: "department", Parent: "org", Ancestors: []string{"org"}}
+	var squad = &models.Team{ID: "squad", Parent: "department", Ancestors: []string{"org", "department"}}
+
+	if !isValidParent(squad, department) || !isValidParent(department, nil) {
+		t.Error("Teams can be moved under other teams or out of the hierarchy")
+		return
+	}
+
+	if isValidParent(organization, organization) || isValidParent(organization, squad) {
+		t.Error("Teams cannot be moved under themselves or their children")
+		return
+	}
+
+	lineage := teamLineage(squad)
+	if len(lineage) != 3 || lineage[0] != "org" || lineage[2] != "squad" {
+		t.Error("Unexpected lineage", lineage)
+		return
+	}
+
+	// Moving the department out of the organization
+	rebased := rebaseAncestors(squad.Ancestors, "department", teamLineage(nil))
+	if len(rebased) != 1 || rebased[0] != "department" {
+		t.Error("Unexpected ancestors", rebased)
+		return
+	}
+
+	log.Info("Team parents checked")
+}
+
+func TestBelongingTeams(t *testing.T) {
+
+	teams := []models.Team{
+		{ID: "squad-a", Ancestors: []string{"org", "department"}},
+		{ID: "department", Ancestors: []string{"org"}},
+	}
+
+	ids := belongingTeams(teams)
+
+	if len(ids) != 3 || ids[0] != "squad-a" || ids[1] != "org" || ids[2] != "department" {
+		t.Error("The teams and their ancestors must be found once", ids)
+		return
+	}
+
+	log.Info("Belonging teams checked")
+}
+
+func TestVisibleTreeTeams(t *testing.T) {
+
+	var organization = models.Team{ID: "org", Owner: "ceo", Members: []string{"head"}, Roles: map[string]string{"head": models.TEAM_ROLE_ADMIN}}
+	var squadA = models.Team{ID: "squad-a", Owner: "lead-a", Members: []string{"developer"}, Parent: "org", Ancestors: []string{"org"}}
+	var squadB = models.Team{ID: "squad-b", Owner: "lead-b", Parent: "org", Ancestors: []string{"org"}}
+	var tree = []models.Team{organization, squadA, squadB}
+
+	if ids := visibleTreeTeams(tree, []models.Team{}, "developer"); len(ids) != 2 || ids[0] != "org" || ids[1] != "squad-a" {
+		t.Error("Members of a squad must not see sibling squads", ids)
+		return
+	}
+
+	if ids := visibleTreeTeams(tree, []models.Team{}, "head"); len(ids) != 3 {
+		t.Error("Organization admins must see every squad", ids)
+		return
+	}
+
+	// The tree of a squad whose organization is not on it
+	if ids := visibleTreeTeams([]models.Team{squadB}, []models.Team{organization}, "ceo"); len(ids) != 1 {
+		t.Error("Ancestor owners must see the squad", ids)
+		return
+	}
+
+	if ids := visibleTreeTeams(tree, []models.Team{}, "stranger"); len(ids) != 0 {
+		t.Error("Strangers must not see any team", ids)
+		return
+	}
+
+	log.Info("Visible tree teams checked")
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/akrck02/valhalla-core/configuration"
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/mail"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Deliveries of an email before it is marked as failed
+const OUTBOX_MAX_ATTEMPTS = 8
+
+// Wait after the first failed delivery, doubled on every retry
+const OUTBOX_BACKOFF = 60 * 1000
+
+// Longest wait between two deliveries
+const OUTBOX_MAX_BACKOFF = 6 * 60 * 60 * 1000
+
+// Emails delivered on every run of the outbox job
+const OUTBOX_BATCH = 50
+
+// Get the absolute url of an endpoint for the links sent by email
+//
+// [param] path | string: path of the endpoint
+// [param] query | url.Values: query of the link
+//
+// [return] string: absolute url of the link
+func publicLink(path string, query url.Values) string {
+	return configuration.Params.PublicURL + API_COMPLETE + path + "?" + query.Encode()
+}
+
+// Queue an email on the outbox, it is delivered in background
+// so a failed delivery never fails the request sending it
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] locale | string: locale of the recipient
+// [param] to | string: address of the recipient
+// [param] template | string: name of the mail template
+// [param] data | map[string]interface{}: values of the template
+func sendEmail(conn context.Context, client *mongo.Client, locale string, to string, template string, data map[string]interface{}) {
+
+	message, err := mail.Render(template, locale, to, data)
+	if err != nil {
+		log.FormattedError("Cannot render email ${0}: ${1}", template, err.Error())
+		return
+	}
+
+	now := utils.GetCurrentMillis()
+	outbox := client.Database(db.CurrentDatabase).Collection(db.MAIL_OUTBOX)
+	_, err = outbox.InsertOne(conn, models.OutboxEmail{
+		To:           message.To,
+		Template:     template,
+		Subject:      message.Subject,
+		Text:         message.Text,
+		HTML:         message.HTML,
+		Status:       models.OUTBOX_PENDING,
+		NextAttempt:  now,
+		CreationDate: now,
+	})
+
+	if err != nil {
+		log.FormattedError("Cannot queue email ${0} to ${1}: ${2}", template, to, err.Error())
+	}
+}
+
+// Deliver the emails of the outbox whose next attempt has been reached
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+//
+// [return] *models.Error: error if any
+func DeliverQueuedEmails(conn context.Context, client *mongo.Client) *models.Error {
+
+	now := utils.GetCurrentMillis()
+	outbox := client.Database(db.CurrentDatabase).Collection(db.MAIL_OUTBOX)
+	found, err := outbox.Find(conn,
+		bson.M{"status": models.OUTBOX_PENDING, "next_attempt": bson.M{"$lte": now}},
+		options.Find().SetSort(bson.M{"next_attempt": 1}).SetLimit(OUTBOX_BATCH),
+	)
+
+	due := []models.OutboxEmail{}
+	if err != nil || found.All(conn, &due) != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get the queued emails",
+		}
+	}
+
+	for i := range due {
+		deliverEmail(conn, client, &due[i], now)
+	}
+
+	return nil
+}
+
+// Try to deliver an email of the outbox, failures are
+// scheduled again after a growing wait
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] email | *models.OutboxEmail: email to deliver
+// [param] now | int64: current time in milliseconds
+func deliverEmail(conn context.Context, client *mongo.Client, email *models.OutboxEmail, now int64) {
+
+	err := mail.Current.Send(conn, &mail.Message{
+		From:    mail.From,
+		To:      email.To,
+		Subject: email.Subject,
+		Text:    email.Text,
+		HTML:    email.HTML,
+	})
+
+	objID, _ := utils.StringToObjectId(email.ID)
+	outbox := client.Database(db.CurrentDatabase).Collection(db.MAIL_OUTBOX)
+
+	// Bodies carry validation and email change codes, they
+	// are only kept while the email can still be delivered
+	if err == nil {
+		outbox.UpdateOne(conn, bson.M{"_id": objID}, bson.M{
+			"$set":   bson.M{"status": models.OUTBOX_SENT, "sent_date": now},
+			"$inc":   bson.M{"attempts": 1},
+			"$unset": bson.M{"next_attempt": "", "last_error": "", "text": "", "html": ""},
+		})
+		return
+	}
+
+	attempts := email.Attempts + 1
+	update := bson.M{
+		"attempts":     attempts,
+		"last_error":   err.Error(),
+		"next_attempt": now + outboxBackoff(attempts),
+	}
+
+	if attempts >= OUTBOX_MAX_ATTEMPTS || err == mail.ErrInvalidHeader {
+		update["status"] = models.OUTBOX_FAILED
+		delete(update, "next_attempt")
+		log.FormattedError("Email ${0} to ${1} failed: ${2}", email.ID, email.To, err.Error())
+
+		outbox.UpdateOne(conn, bson.M{"_id": objID}, bson.M{
+			"$set":   update,
+			"$unset": bson.M{"next_attempt": "", "text": "", "html": ""},
+		})
+		return
+	}
+
+	outbox.UpdateOne(conn, bson.M{"_id": objID}, bson.M{"$set": update})
+}
+
+// Get the wait before the next delivery of an email,
+// doubled on every failed attempt up to a limit
+//
+// [param] attempts | int: failed attempts so far
+//
+// [return] int64: wait in milliseconds
+func outboxBackoff(attempts int) int64 {
+	return exponentialBackoff(attempts, OUTBOX_BACKOFF, OUTBOX_MAX_BACKOFF)
+}
+
+// Get a wait doubled on every failed attempt up to a limit
+//
+// [param] attempts | int: failed attempts so far
+// [param] first | int64: wait after the first attempt in milliseconds
+// [param] limit | int64: longest wait in milliseconds
+//
+// [return] int64: wait in milliseconds
+func exponentialBackoff(attempts int, first int64, limit int64) int64 {
+
+	backoff := first
+	for i := 1; i < attempts && backoff < limit; i++ {
+		backoff *= 2
+	}
+
+	if backoff > limit {
+		return limit
+	}
+
+	return backoff
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Position of the last item of a page, sorted lists use
+// the sort value and id, the rest the offset
+type pageCursor struct {
+	Value  string `json:"v,omitempty"`
+	ID     string `json:"id,omitempty"`
+	Offset int    `json:"o,omitempty"`
+}
+
+// Encode a page cursor as an opaque url safe string
+//
+// [param] cursor | pageCursor: cursor to encode
+//
+// [return] string: encoded cursor
+func encodeCursor(cursor pageCursor) string {
+	encoded, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// Decode a page cursor, empty cursors are the first page
+//
+// [param] encoded | string: encoded cursor
+//
+// [return] *pageCursor: cursor, nil for the first page --> *models.Error: error if the cursor is not valid
+func decodeCursor(encoded string) (*pageCursor, *models.Error) {
+
+	if encoded == "" {
+		return nil, nil
+	}
+
+	invalid := &models.Error{
+		Status:  utils.HTTP_STATUS_BAD_REQUEST,
+		Error:   int(error.INVALID_PAGE),
+		Message: "Invalid page cursor",
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, invalid
+	}
+
+	var cursor pageCursor
+	if json.Unmarshal(decoded, &cursor) != nil || cursor.Offset < 0 {
+		return nil, invalid
+	}
+
+	return &cursor, nil
+}
+
+// Parse a sort parameter like name or -name
+//
+// [param] sort | string: sort parameter
+// [param] allowed | []string: allowed sort fields, the first one is the default
+//
+// [return] string: sort field --> bool: descending order --> *models.Error: error if the field is not allowed
+func parseSort(sort string, allowed []string) (string, bool, *models.Error) {
+
+	descending := strings.HasPrefix(sort, "-")
+	field := strings.TrimPrefix(sort, "-")
+
+	if field == "" {
+		return allowed[0], descending, nil
+	}
+
+	for _, candidate := range allowed {
+		if candidate == field {
+			return field, descending, nil
+		}
+	}
+
+	return "", false, &models.Error{
+		Status:  utils.HTTP_STATUS_BAD_REQUEST,
+		Error:   int(error.INVALID_PAGE),
+		Message: "Results can only be sorted by " + strings.Join(allowed, ", "),
+	}
+}
+
+// Get the filter of the items after a keyset cursor, items are
+// sorted by the given field and then by id to break ties
+//
+// [param] field | string: sort field on database
+// [param] descending | bool: descending order
+// [param] cursor | *pageCursor: cursor of the last item
+//
+// [return] bson.M: filter of the next items --> *models.Error: error if the cursor is not valid
+func keysetFilter(field string, descending bool, cursor *pageCursor) (bson.M, *models.Error) {
+
+	objID, err := utils.StringToObjectId(cursor.ID)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_PAGE),
+			Message: "Invalid page cursor",
+		}
+	}
+
+	operator := "$gt"
+	if descending {
+		operator = "$lt"
+	}
+
+	if field == "_id" {
+		return bson.M{"_id": bson.M{operator: objID}}, nil
+	}
+
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{operator: cursor.Value}},
+		bson.M{field: cursor.Value, "_id": bson.M{operator: objID}},
+	}}, nil
+}
+
+// Get the bson sort of a keyset paginated list
+//
+// [param] field | string: sort field on database
+// [param] descending | bool: descending order
+//
+// [return] bson.D: sort by the field and the id
+func keysetSort(field string, descending bool) bson.D {
+
+	direction := 1
+	if descending {
+		direction = -1
+	}
+
+	if field == "_id" {
+		return bson.D{{Key: "_id", Value: direction}}
+	}
+
+	return bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+)
+
+func TestPageCursor(t *testing.T) {
+
+	var cursor = pageCursor{Value: "Valhalla", ID: "65f1c0ffee0000000000beef"}
+	decoded, err := decodeCursor(encodeCursor(cursor))
+
+	if err != nil || decoded == nil || *decoded != cursor {
+		t.Error("The cursor must be decoded as encoded", decoded, err)
+		return
+	}
+
+	if decoded, err := decodeCursor(""); decoded != nil || err != nil {
+		t.Error("Empty cursors are the first page", decoded, err)
+		return
+	}
+
+	if _, err := decodeCursor("not a cursor!"); err == nil {
+		t.Error("Invalid cursors must be rejected")
+		return
+	}
+
+	if _, err := decodeCursor(encodeCursor(pageCursor{Offset: -1})); err == nil {
+		t.Error("Negative offsets must be rejected")
+		return
+	}
+
+	log.Info("Page cursors checked")
+}
+
+func TestParseSort(t *testing.T) {
+
+	var allowed = []string{models.SORT_BY_NAME, models.SORT_BY_CREATION}
+
+	if field, descending, err := parseSort("", allowed); err != nil || field != models.SORT_BY_NAME || descending {
+		t.Error("The first field must be the default", field, descending, err)
+		return
+	}
+
+	if field, descending, err := parseSort("-created", allowed); err != nil || field != models.SORT_BY_CREATION || !descending {
+		t.Error("Prefixed fields must be descending", field, descending, err)
+		return
+	}
+
+	if _, _, err := parseSort(models.SORT_BY_RELEVANCE, allowed); err == nil {
+		t.Error("Fields not allowed must be rejected")
+		return
+	}
+
+	log.Info("Sorts checked")
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	_ "time/tzdata"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/lang"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Language with an optional region, like en or es-ES
+var LOCALE_PATTERN = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z]{2})?$`)
+
+// Get the preferences of a user, the defaults if the user
+// never changed them
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: owner of the preferences
+//
+// [return] *models.Preferences: preferences --> *models.Error: error if any
+func GetPreferences(conn context.Context, client *mongo.Client, user *models.User) (*models.Preferences, *models.Error) {
+
+	preferences, _, err := findPreferences(conn, client, user)
+	return preferences, err
+}
+
+// Change some preferences of a user, the rest are kept
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: owner of the preferences
+// [param] patch | *models.PreferencesPatch: preferences to change
+//
+// [return] *models.Preferences: preferences changed --> *models.Error: error if any
+func UpdatePreferences(conn context.Context, client *mongo.Client, user *models.User, patch *models.PreferencesPatch) (*models.Preferences, *models.Error) {
+
+	preferences, stored, getErr := findPreferences(conn, client, user)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	preferences.Apply(patch)
+
+	validationErr := validatePreferences(preferences)
+	if validationErr != nil {
+		return nil, validationErr
+	}
+
+	if patch.DefaultTeam != nil && *patch.DefaultTeam != "" {
+		team, teamErr := GetTeam(conn, client, &models.Team{ID: *patch.DefaultTeam})
+
+		if teamErr != nil || TeamRoleOf(conn, client, team, user.ID) == "" {
+			return nil, &models.Error{
+				Status:  utils.HTTP_STATUS_BAD_REQUEST,
+				Error:   int(error.INVALID_DEFAULT_TEAM),
+				Message: "The default team must be one of your teams",
+			}
+		}
+	}
+
+	// New and upgraded preferences are stored whole,
+	// the rest only change the patched keys
+	update := patch.PurgedBson()
+	if !stored {
+		update = preferences.FlatBson()
+	}
+
+	preferences.Version = models.PREFERENCES_VERSION
+	preferences.UpdateDate = utils.GetCurrentMillis()
+	update["version"] = preferences.Version
+	update["update_date"] = preferences.UpdateDate
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.PREFERENCES)
+	_, err := coll.UpdateOne(conn,
+		bson.M{"user": user.ID},
+		bson.M{"$set": update},
+		options.Update().SetUpsert(true),
+	)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.PREFERENCES_NOT_UPDATED),
+			Message: "Preferences not updated",
+		}
+	}
+
+	return preferences, nil
+}
+
+// Get the locale of a user to write to them,
+// the default locale if it cannot be read
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user to write to
+//
+// [return] string: locale of the user
+func userLocale(conn context.Context, client *mongo.Client, user *models.User) string {
+
+	preferences, _, err := findPreferences(conn, client, user)
+	if err != nil || preferences.Locale == "" {
+		return lang.DEFAULT_LOCALE
+	}
+
+	return preferences.Locale
+}
+
+// Find the preferences of a user upgraded to the current schema
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: owner of the preferences
+//
+// [return] *models.Preferences: preferences --> bool: true if they are stored with the current schema --> *models.Error: error if any
+func findPreferences(conn context.Context, client *mongo.Client, user *models.User) (*models.Preferences, bool, *models.Error) {
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.PREFERENCES)
+
+	var found models.Preferences
+	err := coll.FindOne(conn, bson.M{"user": user.ID}).Decode(&found)
+
+	if err == mongo.ErrNoDocuments {
+		return models.DefaultPreferences(user.ID), false, nil
+	}
+
+	if err != nil {
+		return nil, false, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get preferences",
+		}
+	}
+
+	current := found.Version == models.PREFERENCES_VERSION
+	upgradePreferences(&found)
+	return &found, current, nil
+}
+
+// Upgrade preferences stored with an older schema,
+// the fields they miss get their default value
+//
+// [param] preferences | *models.Preferences: preferences to upgrade
+func upgradePreferences(preferences *models.Preferences) {
+
+	if preferences.Version == models.PREFERENCES_VERSION {
+		return
+	}
+
+	defaults := models.DefaultPreferences(preferences.User)
+
+	if preferences.Version < 1 {
+		if preferences.Timezone == "" {
+			preferences.Timezone = defaults.Timezone
+		}
+
+		if preferences.Theme == "" {
+			preferences.Theme = defaults.Theme
+		}
+
+		if preferences.DateFormat == "" {
+			preferences.DateFormat = defaults.DateFormat
+		}
+
+		preferences.Notifications = defaults.Notifications
+	}
+
+	preferences.Version = models.PREFERENCES_VERSION
+}
+
+// Check the preferences against their schema
+//
+// [param] preferences | *models.Preferences: preferences to check
+//
+// [return] *models.Error: error if any preference is not valid
+func validatePreferences(preferences *models.Preferences) *models.Error {
+
+	if preferences.Locale != "" && !LOCALE_PATTERN.MatchString(preferences.Locale) {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_LOCALE),
+			Message: "Locales must be a language with an optional region, like en or es-ES",
+		}
+	}
+
+	// Local is not a real zone but the zone of the server
+	_, err := time.LoadLocation(preferences.Timezone)
+	if err != nil || preferences.Timezone == "" || preferences.Timezone == "Local" {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_TIMEZONE),
+			Message: "Timezones must be IANA zones, like Europe/Madrid",
+		}
+	}
+
+	switch preferences.Theme {
+	case models.THEME_SYSTEM, models.THEME_LIGHT, models.THEME_DARK:
+	default:
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_THEME),
+			Message: "Themes can only be system, light or dark",
+		}
+	}
+
+	for _, format := range models.DATE_FORMATS {
+		if preferences.DateFormat == format {
+			return nil
+		}
+	}
+
+	return &models.Error{
+		Status:  utils.HTTP_STATUS_BAD_REQUEST,
+		Error:   int(error.INVALID_DATE_FORMAT),
+		Message: "Date formats can only be " + strings.Join(models.DATE_FORMATS, ", "),
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+)
+
+func TestPreferencesPatch(t *testing.T) {
+
+	var preferences = models.DefaultPreferences("user")
+	if validatePreferences(preferences) != nil {
+		t.Error("The default preferences must be valid")
+		return
+	}
+
+	var theme = models.THEME_DARK
+	var mentions = false
+	var patch = &models.PreferencesPatch{
+		Theme:         &theme,
+		Notifications: &models.NotificationPreferencesPatch{Mentions: &mentions},
+	}
+
+	update := patch.PurgedBson()
+	if len(update) != 2 || update["theme"] != theme || update["notifications.mentions"] != false {
+		t.Error("Only the patched keys must be updated", update)
+		return
+	}
+
+	preferences.Apply(patch)
+	if preferences.Theme != theme || preferences.Notifications.Mentions || !preferences.Notifications.InApp || preferences.Locale != "" {
+		t.Error("Unexpected preferences", preferences)
+		return
+	}
+
+	log.Info("Preferences patch checked")
+}
+
+func TestPreferencesValidation(t *testing.T) {
+
+	var invalid = map[int]func(*models.Preferences){
+		error.INVALID_LOCALE:      func(p *models.Preferences) { p.Locale = "english" },
+		error.INVALID_TIMEZONE:    func(p *models.Preferences) { p.Timezone = "Mars/Olympus_Mons" },
+		error.INVALID_THEME:       func(p *models.Preferences) { p.Theme = "pink" },
+		error.INVALID_DATE_FORMAT: func(p *models.Preferences) { p.DateFormat = "YY" },
+	}
+
+	for code, change := range invalid {
+		preferences := models.DefaultPreferences("user")
+		change(preferences)
+
+		if err := validatePreferences(preferences); err == nil || err.Error != code {
+			t.Error("The preferences must not be valid", code, err)
+			return
+		}
+	}
+
+	var preferences = models.DefaultPreferences("user")
+	preferences.Locale = "es-ES"
+	preferences.Timezone = "Europe/Madrid"
+
+	if validatePreferences(preferences) != nil {
+		t.Error("Regional locales and IANA timezones must be valid")
+		return
+	}
+
+	log.Info("Preferences validation checked")
+}
+
+func TestPreferencesUpgrade(t *testing.T) {
+
+	var preferences = &models.Preferences{User: "user", Theme: models.THEME_LIGHT}
+	upgradePreferences(preferences)
+
+	if preferences.Version != models.PREFERENCES_VERSION || preferences.Theme != models.THEME_LIGHT || preferences.Locale != "" || !preferences.Notifications.InApp {
+		t.Error("Unexpected upgraded preferences", preferences)
+		return
+	}
+
+	log.Info("Preferences upgrade checked")
+}
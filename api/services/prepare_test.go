@@ -9,6 +9,8 @@ import (
 	"github.com/akrck02/valhalla-core/configuration"
 	"github.com/akrck02/valhalla-core/db"
 	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/mail"
+	"github.com/akrck02/valhalla-core/storage"
 )
 
 var setupDone bool = false
@@ -37,6 +39,8 @@ func setup() {
 	log.Jump()
 	log.Info("Setting up test environment...")
 	db.SetupTest()
+	storage.Setup()
+	mail.Setup()
 	setupDone = true
 	log.Jump()
 }
@@ -143,3 +143,38 @@ func nameExists(name string, conn context.Context, coll *mongo.Collection) model
 
 	return result
 }
+
+// Get project by id logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] id | string: id of the project
+//
+// [return] *models.Project: project found --> *models.Error: error if any
+func getProjectById(conn context.Context, client *mongo.Client, id string) (*models.Project, *models.Error) {
+
+	objID, err := utils.StringToObjectId(id)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.BAD_OBJECT_ID),
+			Message: "Bad object id",
+		}
+	}
+
+	projects := client.Database(db.CurrentDatabase).Collection(db.PROJECT)
+
+	var found models.Project
+	err = projects.FindOne(conn, bson.M{"_id": objID}).Decode(&found)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.PROJECT_NOT_FOUND),
+			Message: "Project not found",
+		}
+	}
+
+	return &found, nil
+}
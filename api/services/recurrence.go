@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Maximum candidate dates checked when looking for the next occurrence
+const MAX_RECURRENCE_STEPS = 1000
+
+// Check a recurrence rule, filling the defaults
+//
+// [param] rule | *models.Recurrence: rule to check
+// [param] dueDate | int64: due date of the first occurrence
+//
+// [return] *models.Error: error if any
+func checkRecurrence(rule *models.Recurrence, dueDate int64) *models.Error {
+
+	if dueDate <= 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.MISSING_DUE_DATE),
+			Message: "Recurring tasks require a due date",
+		}
+	}
+
+	invalid := func(message string) *models.Error {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_RECURRENCE),
+			Message: message,
+		}
+	}
+
+	switch rule.Frequency {
+	case models.RECURRENCE_DAILY, models.RECURRENCE_WEEKLY:
+	case models.RECURRENCE_MONTHLY:
+		if len(rule.Weekdays) > 0 {
+			return invalid("Monthly recurrences cannot have weekdays")
+		}
+	default:
+		return invalid("Frequency must be daily, weekly or monthly")
+	}
+
+	if rule.Interval == 0 {
+		rule.Interval = 1
+	}
+
+	if rule.Interval < 0 {
+		return invalid("Interval must be positive")
+	}
+
+	for _, weekday := range rule.Weekdays {
+		if weekday < 0 || weekday > 6 {
+			return invalid("Weekdays go from 0 (sunday) to 6 (saturday)")
+		}
+	}
+
+	if rule.Count < 0 {
+		return invalid("Count must be positive")
+	}
+
+	if rule.Count > 0 && rule.Until > 0 {
+		return invalid("Recurrences can end by count or by date, not both")
+	}
+
+	if rule.Until > 0 && rule.Until < dueDate {
+		return invalid("Recurrences cannot end before the first due date")
+	}
+
+	if rule.Mode == "" {
+		rule.Mode = models.RECURRENCE_ON_COMPLETION
+	}
+
+	if rule.Mode != models.RECURRENCE_ON_COMPLETION && rule.Mode != models.RECURRENCE_ON_SCHEDULE {
+		return invalid("Mode must be completion or schedule")
+	}
+
+	return nil
+}
+
+// Get the next occurrence of a recurrence rule. Occurrences keep the
+// time of the day of the first due date and are calculated in UTC.
+//
+// [param] rule | *models.Recurrence: rule with the first due date as start
+// [param] previous | int64: due date of the previous occurrence
+// [param] occurrence | int: number of the previous occurrence, starting at 1
+//
+// [return] int64: due date of the next occurrence --> bool: false if the recurrence ended
+func nextOccurrence(rule *models.Recurrence, previous int64, occurrence int) (int64, bool) {
+
+	if rule.Count > 0 && occurrence >= rule.Count {
+		return 0, false
+	}
+
+	interval := rule.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	last := time.UnixMilli(previous).UTC()
+	start := time.UnixMilli(rule.Start).UTC()
+
+	var next time.Time
+	found := false
+
+	switch rule.Frequency {
+	case models.RECURRENCE_DAILY:
+		for i := 1; i <= MAX_RECURRENCE_STEPS && !found; i++ {
+			next = last.AddDate(0, 0, i*interval)
+			found = matchesWeekday(rule.Weekdays, next)
+		}
+
+	case models.RECURRENCE_WEEKLY:
+		weekdays := rule.Weekdays
+		if len(weekdays) == 0 {
+			weekdays = []int{int(start.Weekday())}
+		}
+
+		firstWeek := utils.StartOfWeek(start.UnixMilli())
+		for i := 1; i <= MAX_RECURRENCE_STEPS && !found; i++ {
+			next = last.AddDate(0, 0, i)
+			weeks := (utils.StartOfWeek(next.UnixMilli()) - firstWeek) / (7 * utils.MILLIS_PER_DAY)
+			found = weeks%int64(interval) == 0 && matchesWeekday(weekdays, next)
+		}
+
+	case models.RECURRENCE_MONTHLY:
+		months := (last.Year()-start.Year())*12 + int(last.Month()-start.Month())
+
+		// Months without the day of the first due date are skipped
+		for i := 1; i <= MAX_RECURRENCE_STEPS && !found; i++ {
+			next = time.Date(start.Year(), start.Month()+time.Month(months+i*interval), start.Day(),
+				start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), time.UTC)
+			found = next.Day() == start.Day()
+		}
+	}
+
+	if !found || (rule.Until > 0 && next.UnixMilli() > rule.Until) {
+		return 0, false
+	}
+
+	return next.UnixMilli(), true
+}
+
+func matchesWeekday(weekdays []int, date time.Time) bool {
+
+	if len(weekdays) == 0 {
+		return true
+	}
+
+	for _, weekday := range weekdays {
+		if int(date.Weekday()) == weekday {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Create the next occurrence of a recurring task. Every task spawns
+// at most once, so it is safe to call from several places.
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] task | *models.Task: current occurrence
+//
+// [return] *models.Task: next occurrence, nil if the recurrence ended --> *models.Error: error if any
+func spawnNextOccurrence(conn context.Context, client *mongo.Client, task *models.Task) (*models.Task, *models.Error) {
+
+	if task.Recurrence == nil {
+		return nil, nil
+	}
+
+	objID, _ := utils.StringToObjectId(task.ID)
+	tasks := client.Database(db.CurrentDatabase).Collection(db.TASK)
+	result, err := tasks.UpdateOne(conn,
+		bson.M{"_id": objID, "spawned": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"spawned": true}},
+	)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.TASK_NOT_UPDATED),
+			Message: "Next occurrence not created",
+		}
+	}
+
+	if result.ModifiedCount == 0 {
+		return nil, nil
+	}
+
+	dueDate, recurring := nextOccurrence(task.Recurrence, task.DueDate, task.Occurrence)
+
+	if !recurring {
+		return nil, nil
+	}
+
+	series := task.Series
+	if series == "" {
+		series = task.ID
+	}
+
+	next := &models.Task{
+		Name:        task.Name,
+		Description: task.Description,
+		Project:     task.Project,
+		Owner:       task.Owner,
+		Points:      task.Points,
+		Duration:    task.Duration,
+		DueDate:     dueDate,
+		Recurrence:  task.Recurrence,
+		Series:      series,
+		Occurrence:  task.Occurrence + 1,
+	}
+
+	createErr := CreateTask(conn, client, next)
+
+	// Let a later call retry the occurrence
+	if createErr != nil {
+		tasks.UpdateOne(conn, bson.M{"_id": objID}, bson.M{"$set": bson.M{"spawned": false}})
+		return nil, createErr
+	}
+
+	copyErr := copyTaskReminders(conn, client, task, next)
+
+	if copyErr != nil {
+		return nil, copyErr
+	}
+
+	return next, nil
+}
+
+// Spawn the next occurrence of the scheduled recurring tasks
+// whose due date has been reached, done or not
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+//
+// [return] *models.Error: error if any
+func SpawnScheduledTasks(conn context.Context, client *mongo.Client) *models.Error {
+
+	tasks := client.Database(db.CurrentDatabase).Collection(db.TASK)
+	cursor, err := tasks.Find(conn, bson.M{
+		"recurrence.mode": models.RECURRENCE_ON_SCHEDULE,
+		"spawned":         bson.M{"$ne": true},
+		"due_date":        bson.M{"$lte": utils.GetCurrentMillis()},
+	})
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get recurring tasks",
+		}
+	}
+
+	due := []models.Task{}
+	err = cursor.All(conn, &due)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get recurring tasks",
+		}
+	}
+
+	for _, task := range due {
+		task := task
+		_, spawnErr := spawnNextOccurrence(conn, client, &task)
+
+		if spawnErr != nil {
+			return spawnErr
+		}
+	}
+
+	return nil
+}
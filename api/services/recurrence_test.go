@@ -0,0 +1,111 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+)
+
+func TestWeeklyRecurrence(t *testing.T) {
+
+	// Monday 2024-01-01 09:00, every two weeks on monday and thursday, four times
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC).UnixMilli()
+	rule := &models.Recurrence{
+		Frequency: models.RECURRENCE_WEEKLY,
+		Interval:  2,
+		Weekdays:  []int{1, 4},
+		Count:     4,
+		Start:     start,
+	}
+
+	expected := []time.Time{
+		time.Date(2024, 1, 4, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 18, 9, 0, 0, 0, time.UTC),
+	}
+
+	previous := start
+	for i, date := range expected {
+		next, recurring := nextOccurrence(rule, previous, i+1)
+
+		if !recurring || next != date.UnixMilli() {
+			t.Error("Unexpected occurrence", i+2, time.UnixMilli(next).UTC())
+			return
+		}
+
+		previous = next
+	}
+
+	_, recurring := nextOccurrence(rule, previous, 4)
+	if recurring {
+		t.Error("The recurrence must end after four occurrences")
+		return
+	}
+
+	log.Info("Weekly recurrence calculated")
+}
+
+func TestMonthlyRecurrence(t *testing.T) {
+
+	// The 31st of every month until june, months without a 31st are skipped
+	start := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC).UnixMilli()
+	rule := &models.Recurrence{
+		Frequency: models.RECURRENCE_MONTHLY,
+		Interval:  1,
+		Until:     time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC).UnixMilli(),
+		Start:     start,
+	}
+
+	next, recurring := nextOccurrence(rule, start, 1)
+	if !recurring || next != time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC).UnixMilli() {
+		t.Error("February must be skipped", time.UnixMilli(next).UTC())
+		return
+	}
+
+	next, recurring = nextOccurrence(rule, next, 2)
+	if !recurring || next != time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC).UnixMilli() {
+		t.Error("April must be skipped", time.UnixMilli(next).UTC())
+		return
+	}
+
+	_, recurring = nextOccurrence(rule, next, 3)
+	if recurring {
+		t.Error("The recurrence must end in june")
+		return
+	}
+
+	log.Info("Monthly recurrence calculated")
+}
+
+func TestDailyRecurrenceOnWeekdays(t *testing.T) {
+
+	// Friday 2024-01-05, every day on working days
+	start := time.Date(2024, 1, 5, 8, 0, 0, 0, time.UTC).UnixMilli()
+	rule := &models.Recurrence{
+		Frequency: models.RECURRENCE_DAILY,
+		Weekdays:  []int{1, 2, 3, 4, 5},
+	}
+
+	checkErr := checkRecurrence(rule, start)
+	if checkErr != nil {
+		t.Error("The recurrence must be valid", checkErr)
+		return
+	}
+
+	rule.Start = start
+	next, recurring := nextOccurrence(rule, start, 1)
+	if !recurring || next != time.Date(2024, 1, 8, 8, 0, 0, 0, time.UTC).UnixMilli() {
+		t.Error("The weekend must be skipped", time.UnixMilli(next).UTC())
+		return
+	}
+
+	invalid := &models.Recurrence{Frequency: models.RECURRENCE_MONTHLY, Weekdays: []int{1}}
+	if checkRecurrence(invalid, start) == nil {
+		t.Error("Monthly recurrences cannot have weekdays")
+		return
+	}
+
+	log.Info("Daily recurrence calculated")
+}
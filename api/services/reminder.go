@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Add a reminder fired the given time before the task due date
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user to remind
+// [param] task | *models.Task: task to remind
+// [param] reminder | *models.Reminder: reminder to add
+//
+// [return] *models.Error: error if any
+func AddReminder(conn context.Context, client *mongo.Client, user *models.User, task *models.Task, reminder *models.Reminder) *models.Error {
+
+	if task.DueDate <= 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.MISSING_DUE_DATE),
+			Message: "Reminders require a task with due date",
+		}
+	}
+
+	if reminder.Before < 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_REMINDER),
+			Message: "Reminders must fire before the due date",
+		}
+	}
+
+	reminder.User = user.ID
+	reminder.Task = task.ID
+	reminder.FireDate = task.DueDate - reminder.Before
+	reminder.Fired = false
+	reminder.CreationDate = utils.GetCurrentMillis()
+
+	if reminder.FireDate <= reminder.CreationDate {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_REMINDER),
+			Message: "Reminders cannot fire in the past",
+		}
+	}
+
+	reminders := client.Database(db.CurrentDatabase).Collection(db.REMINDER)
+	result, err := reminders.InsertOne(conn, reminder)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.REMINDER_NOT_CREATED),
+			Message: "Reminder not created",
+		}
+	}
+
+	reminder.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+// Delete a reminder of the user
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: owner of the reminder
+// [param] reminder | *models.Reminder: reminder to delete
+//
+// [return] *models.Error: error if any
+func DeleteReminder(conn context.Context, client *mongo.Client, user *models.User, reminder *models.Reminder) *models.Error {
+
+	objID, err := utils.StringToObjectId(reminder.ID)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.BAD_OBJECT_ID),
+			Message: "Bad object id",
+		}
+	}
+
+	reminders := client.Database(db.CurrentDatabase).Collection(db.REMINDER)
+	result, err := reminders.DeleteOne(conn, bson.M{"_id": objID, "user": user.ID})
+
+	if err != nil || result.DeletedCount == 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.REMINDER_NOT_FOUND),
+			Message: "Reminder not found",
+		}
+	}
+
+	return nil
+}
+
+// Get the reminders of the user on a task
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: owner of the reminders
+// [param] task | *models.Task: task of the reminders
+//
+// [return] []models.Reminder: reminders found --> *models.Error: error if any
+func GetTaskReminders(conn context.Context, client *mongo.Client, user *models.User, task *models.Task) ([]models.Reminder, *models.Error) {
+
+	reminders := client.Database(db.CurrentDatabase).Collection(db.REMINDER)
+	cursor, err := reminders.Find(conn, bson.M{"user": user.ID, "task": task.ID})
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get reminders",
+		}
+	}
+
+	found := []models.Reminder{}
+	err = cursor.All(conn, &found)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get reminders",
+		}
+	}
+
+	return found, nil
+}
+
+// Fire the reminders whose date has been reached. Every reminder
+// is claimed before notifying so it is only fired once.
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+//
+// [return] *models.Error: error if any
+func FireDueReminders(conn context.Context, client *mongo.Client) *models.Error {
+
+	reminders := client.Database(db.CurrentDatabase).Collection(db.REMINDER)
+	now := utils.GetCurrentMillis()
+
+	for {
+		var reminder models.Reminder
+		err := reminders.FindOneAndUpdate(conn,
+			bson.M{"fired": false, "fire_date": bson.M{"$lte": now}},
+			bson.M{"$set": bson.M{"fired": true}},
+		).Decode(&reminder)
+
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+
+		if err != nil {
+			return &models.Error{
+				Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+				Error:   int(error.UNEXPECTED_ERROR),
+				Message: "Cannot get due reminders",
+			}
+		}
+
+		// Completed tasks do not need reminders
+		task, getErr := GetTask(conn, client, &models.Task{ID: reminder.Task})
+
+		if getErr != nil || task.Done {
+			continue
+		}
+
+		NotifyUser(conn, client, &models.Notification{
+			User:    reminder.User,
+			Type:    models.NOTIFICATION_REMINDER,
+			Title:   task.Name,
+			Message: "Task due on " + utils.FormatMillis(task.DueDate),
+			Source:  task.ID,
+		})
+	}
+}
+
+// Copy the reminders of a recurring task to its next occurrence
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] from | *models.Task: previous occurrence
+// [param] to | *models.Task: next occurrence
+//
+// [return] *models.Error: error if any
+func copyTaskReminders(conn context.Context, client *mongo.Client, from *models.Task, to *models.Task) *models.Error {
+
+	reminders := client.Database(db.CurrentDatabase).Collection(db.REMINDER)
+	cursor, err := reminders.Find(conn, bson.M{"task": from.ID})
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get reminders",
+		}
+	}
+
+	found := []models.Reminder{}
+	err = cursor.All(conn, &found)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get reminders",
+		}
+	}
+
+	now := utils.GetCurrentMillis()
+	copies := []interface{}{}
+
+	for _, reminder := range found {
+		copies = append(copies, models.Reminder{
+			User:         reminder.User,
+			Task:         to.ID,
+			Before:       reminder.Before,
+			FireDate:     to.DueDate - reminder.Before,
+			Fired:        false,
+			CreationDate: now,
+		})
+	}
+
+	if len(copies) == 0 {
+		return nil
+	}
+
+	_, err = reminders.InsertMany(conn, copies)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.REMINDER_NOT_CREATED),
+			Message: "Reminders not copied to the next occurrence",
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Add reminder HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func AddReminderHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var reminder *models.Reminder = &models.Reminder{}
+	err := c.ShouldBindJSON(reminder)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	task, getErr := GetTask(conn, client, &models.Task{ID: reminder.Task})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if !CanSeeTask(conn, client, request.User, task) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the task",
+		}
+	}
+
+	addErr := AddReminder(conn, client, request.User, task, reminder)
+	if addErr != nil {
+		return nil, addErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Reminder added", "id": reminder.ID, "fire_date": reminder.FireDate},
+	}, nil
+}
+
+// Delete reminder HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func DeleteReminderHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var reminder *models.Reminder = &models.Reminder{}
+	err := c.ShouldBindJSON(reminder)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	deleteErr := DeleteReminder(conn, client, request.User, reminder)
+	if deleteErr != nil {
+		return nil, deleteErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Reminder deleted"},
+	}, nil
+}
+
+// Get task reminders HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetTaskRemindersHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	task, getErr := GetTask(conn, client, &models.Task{ID: c.Query("task")})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	reminders, remindersErr := GetTaskReminders(conn, client, request.User, task)
+	if remindersErr != nil {
+		return nil, remindersErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Reminders found", "reminders": reminders},
+	}, nil
+}
@@ -1,10 +1,16 @@
 package services
 
 import (
+	"time"
+
 	"github.com/akrck02/valhalla-core/configuration"
+	"github.com/akrck02/valhalla-core/db"
 	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/mail"
 	"github.com/akrck02/valhalla-core/middleware"
 	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/scheduler"
+	"github.com/akrck02/valhalla-core/storage"
 	"github.com/akrck02/valhalla-core/utils"
 	"github.com/gin-gonic/gin"
 )
@@ -20,18 +26,113 @@ var ENDPOINTS = []models.Endpoint{
 	models.EndpointFrom("user/login", utils.HTTP_METHOD_POST, LoginHttp, false),
 	models.EndpointFrom("user/edit", utils.HTTP_METHOD_POST, EditUserHttp, true),
 	models.EndpointFrom("user/edit/email", utils.HTTP_METHOD_POST, EditUserEmailHttp, true),
+	models.EndpointFrom("user/edit/email/confirm", utils.HTTP_METHOD_POST, ConfirmEmailChangeHttp, true),
 	models.EndpointFrom("user/edit/profilepicture", utils.HTTP_METHOD_POST, EditUserProfilePictureHttp, true),
 	models.EndpointFrom("user/delete", utils.HTTP_METHOD_DELETE, DeleteUserHttp, true),
+	models.EndpointFrom("user/delete/cancel", utils.HTTP_METHOD_POST, CancelUserDeletionHttp, true),
+	models.EndpointFrom("user/export", utils.HTTP_METHOD_PUT, RequestDataExportHttp, true),
+	models.EndpointFrom("user/export/get", utils.HTTP_METHOD_GET, GetDataExportHttp, true),
+	models.EndpointFrom("user/export/download", utils.HTTP_METHOD_GET, DownloadDataExportHttp, true),
 	models.EndpointFrom("user/get", utils.HTTP_METHOD_GET, GetUserHttp, true),
+	models.EndpointFrom("user/search", utils.HTTP_METHOD_GET, SearchUsersHttp, true),
+	models.EndpointFrom("user/profile", utils.HTTP_METHOD_GET, GetUserProfileHttp, true),
+	models.EndpointFrom("user/preferences", utils.HTTP_METHOD_GET, GetPreferencesHttp, true),
+	models.EndpointFrom("user/preferences", utils.HTTP_METHOD_PATCH, UpdatePreferencesHttp, true),
 	models.EndpointFrom("user/validate", utils.HTTP_METHOD_GET, ValidateUserHttp, false),
+	models.EndpointFrom("user/validate/resend", utils.HTTP_METHOD_POST, ResendValidationCodeHttp, true),
 
 	// Team endpoints
 	models.EndpointFrom("team/create", utils.HTTP_METHOD_PUT, CreateTeamHttp, true),
 	models.EndpointFrom("team/edit", utils.HTTP_METHOD_POST, EditTeamHttp, true),
-	models.EndpointFrom("team/edit/owner", utils.HTTP_METHOD_POST, EditTeamOwnerHttp, true),
+	models.EndpointFrom("team/edit/profilepicture", utils.HTTP_METHOD_POST, EditTeamProfilePictureHttp, true),
 	models.EndpointFrom("team/delete", utils.HTTP_METHOD_DELETE, DeleteTeamHttp, true),
 	models.EndpointFrom("team/get", utils.HTTP_METHOD_GET, GetTeamHttp, true),
-	models.EndpointFrom("team/add/member", utils.HTTP_METHOD_PUT, AddMemberHttp, true),
+	models.EndpointFrom("team/list", utils.HTTP_METHOD_GET, GetTeamsHttp, true),
+	models.EndpointFrom("team/search", utils.HTTP_METHOD_GET, SearchTeamsHttp, true),
+	models.EndpointFrom("team/members", utils.HTTP_METHOD_GET, GetTeamMembersHttp, true),
+	models.EndpointFrom("team/remove/member", utils.HTTP_METHOD_DELETE, RemoveMemberHttp, true),
+	models.EndpointFrom("team/leave", utils.HTTP_METHOD_POST, LeaveTeamHttp, true),
+	models.EndpointFrom("team/member/role", utils.HTTP_METHOD_POST, SetMemberRoleHttp, true),
+	models.EndpointFrom("team/parent", utils.HTTP_METHOD_POST, SetTeamParentHttp, true),
+	models.EndpointFrom("team/children", utils.HTTP_METHOD_GET, GetTeamChildrenHttp, true),
+	models.EndpointFrom("team/projects", utils.HTTP_METHOD_GET, GetTeamTreeProjectsHttp, true),
+	models.EndpointFrom("team/transfer", utils.HTTP_METHOD_PUT, RequestTeamTransferHttp, true),
+	models.EndpointFrom("team/transfer/get", utils.HTTP_METHOD_GET, GetTeamTransferHttp, true),
+	models.EndpointFrom("team/transfer/accept", utils.HTTP_METHOD_POST, AcceptTeamTransferHttp, true),
+	models.EndpointFrom("team/transfer/decline", utils.HTTP_METHOD_POST, DeclineTeamTransferHttp, true),
+	models.EndpointFrom("team/transfer/cancel", utils.HTTP_METHOD_POST, CancelTeamTransferHttp, true),
+	models.EndpointFrom("team/audit", utils.HTTP_METHOD_GET, GetTeamAuditHttp, true),
+	models.EndpointFrom("team/invite", utils.HTTP_METHOD_PUT, InviteToTeamHttp, true),
+	models.EndpointFrom("team/invitation/get", utils.HTTP_METHOD_GET, GetInvitationHttp, true),
+	models.EndpointFrom("team/invitation/preview", utils.HTTP_METHOD_GET, PreviewInvitationHttp, false),
+	models.EndpointFrom("team/invitation/accept", utils.HTTP_METHOD_POST, AcceptInvitationHttp, true),
+	models.EndpointFrom("team/invitation/decline", utils.HTTP_METHOD_POST, DeclineInvitationHttp, true),
+	models.EndpointFrom("team/invitation/revoke", utils.HTTP_METHOD_POST, RevokeInvitationHttp, true),
+	models.EndpointFrom("team/invitation/list", utils.HTTP_METHOD_GET, GetInvitationsHttp, true),
+	models.EndpointFrom("team/join", utils.HTTP_METHOD_PUT, RequestToJoinTeamHttp, true),
+	models.EndpointFrom("team/join/get", utils.HTTP_METHOD_GET, GetJoinRequestHttp, true),
+	models.EndpointFrom("team/join/approve", utils.HTTP_METHOD_POST, ApproveJoinRequestHttp, true),
+	models.EndpointFrom("team/join/reject", utils.HTTP_METHOD_POST, RejectJoinRequestHttp, true),
+	models.EndpointFrom("team/join/cancel", utils.HTTP_METHOD_POST, CancelJoinRequestHttp, true),
+	models.EndpointFrom("team/join/list", utils.HTTP_METHOD_GET, GetJoinRequestsHttp, true),
+
+	// Task endpoints
+	models.EndpointFrom("task/create", utils.HTTP_METHOD_PUT, CreateTaskHttp, true),
+	models.EndpointFrom("task/get", utils.HTTP_METHOD_GET, GetTaskHttp, true),
+	models.EndpointFrom("task/complete", utils.HTTP_METHOD_POST, CompleteTaskHttp, true),
+	models.EndpointFrom("task/assign", utils.HTTP_METHOD_POST, AssignTaskHttp, true),
+	models.EndpointFrom("task/dependency/add", utils.HTTP_METHOD_PUT, AddTaskDependencyHttp, true),
+	models.EndpointFrom("task/dependency/remove", utils.HTTP_METHOD_DELETE, RemoveTaskDependencyHttp, true),
+	models.EndpointFrom("task/dependency/get", utils.HTTP_METHOD_GET, GetTaskDependenciesHttp, true),
+	models.EndpointFrom("project/gantt", utils.HTTP_METHOD_GET, GetProjectGanttHttp, true),
+	models.EndpointFrom("task/reminder/add", utils.HTTP_METHOD_PUT, AddReminderHttp, true),
+	models.EndpointFrom("task/reminder/delete", utils.HTTP_METHOD_DELETE, DeleteReminderHttp, true),
+	models.EndpointFrom("task/reminder/list", utils.HTTP_METHOD_GET, GetTaskRemindersHttp, true),
+
+	// Time tracking endpoints
+	models.EndpointFrom("task/time/start", utils.HTTP_METHOD_PUT, StartTimerHttp, true),
+	models.EndpointFrom("task/time/stop", utils.HTTP_METHOD_POST, StopTimerHttp, true),
+	models.EndpointFrom("task/time/running", utils.HTTP_METHOD_GET, GetRunningTimerHttp, true),
+	models.EndpointFrom("task/time/add", utils.HTTP_METHOD_PUT, AddTimeEntryHttp, true),
+	models.EndpointFrom("task/time/delete", utils.HTTP_METHOD_DELETE, DeleteTimeEntryHttp, true),
+	models.EndpointFrom("timesheet", utils.HTTP_METHOD_GET, GetTimesheetHttp, true),
+
+	// Sprint endpoints
+	models.EndpointFrom("project/sprint/create", utils.HTTP_METHOD_PUT, CreateSprintHttp, true),
+	models.EndpointFrom("project/sprint/edit", utils.HTTP_METHOD_POST, EditSprintHttp, true),
+	models.EndpointFrom("project/sprint/delete", utils.HTTP_METHOD_DELETE, DeleteSprintHttp, true),
+	models.EndpointFrom("project/sprint/get", utils.HTTP_METHOD_GET, GetSprintHttp, true),
+	models.EndpointFrom("project/sprint/list", utils.HTTP_METHOD_GET, GetProjectSprintsHttp, true),
+	models.EndpointFrom("project/sprint/add/task", utils.HTTP_METHOD_PUT, AddSprintTaskHttp, true),
+	models.EndpointFrom("project/sprint/remove/task", utils.HTTP_METHOD_DELETE, RemoveSprintTaskHttp, true),
+	models.EndpointFrom("project/sprint/burndown", utils.HTTP_METHOD_GET, GetSprintBurndownHttp, true),
+
+	// Comment endpoints
+	models.EndpointFrom("comment/create", utils.HTTP_METHOD_PUT, CreateCommentHttp, true),
+	models.EndpointFrom("comment/edit", utils.HTTP_METHOD_POST, EditCommentHttp, true),
+	models.EndpointFrom("comment/delete", utils.HTTP_METHOD_DELETE, DeleteCommentHttp, true),
+	models.EndpointFrom("comment/list", utils.HTTP_METHOD_GET, GetCommentsHttp, true),
+	models.EndpointFrom("comment/history", utils.HTTP_METHOD_GET, GetCommentHistoryHttp, true),
+
+	// Notification endpoints
+	models.EndpointFrom("notification/list", utils.HTTP_METHOD_GET, GetNotificationsHttp, true),
+	models.EndpointFrom("notification/unread", utils.HTTP_METHOD_GET, CountUnreadNotificationsHttp, true),
+	models.EndpointFrom("notification/read", utils.HTTP_METHOD_POST, MarkNotificationReadHttp, true),
+	models.EndpointFrom("notification/read/all", utils.HTTP_METHOD_POST, MarkAllNotificationsReadHttp, true),
+	models.EndpointFrom("notification/delete", utils.HTTP_METHOD_DELETE, DeleteNotificationHttp, true),
+
+	// Event endpoints
+	models.EndpointFrom("events/token", utils.HTTP_METHOD_GET, GetEventsTokenHttp, true),
+	models.EndpointFrom("events/stream", utils.HTTP_METHOD_GET, StreamEventsHttp, false),
+	models.EndpointFrom("events/socket", utils.HTTP_METHOD_GET, StreamEventsSocketHttp, false),
+
+	// Webhook endpoints
+	models.EndpointFrom("webhook/create", utils.HTTP_METHOD_PUT, CreateWebhookHttp, true),
+	models.EndpointFrom("webhook/list", utils.HTTP_METHOD_GET, GetWebhooksHttp, true),
+	models.EndpointFrom("webhook/delete", utils.HTTP_METHOD_DELETE, DeleteWebhookHttp, true),
+	models.EndpointFrom("webhook/enable", utils.HTTP_METHOD_POST, EnableWebhookHttp, true),
+	models.EndpointFrom("webhook/deliveries", utils.HTTP_METHOD_GET, GetWebhookDeliveriesHttp, true),
+	models.EndpointFrom("webhook/redeliver", utils.HTTP_METHOD_POST, RedeliverWebhookHttp, true),
 
 	// Role endpoints
 	models.EndpointFrom("rol/create", utils.HTTP_METHOD_PUT, CreateRoleHttp, true),
@@ -39,10 +140,23 @@ var ENDPOINTS = []models.Endpoint{
 	models.EndpointFrom("rol/delete", utils.HTTP_METHOD_DELETE, DeleteRoleHttp, true),
 	models.EndpointFrom("rol/get", utils.HTTP_METHOD_GET, GetRoleHttp, true),
 
+	// Media endpoints
+	models.EndpointFrom("media/*path", utils.HTTP_METHOD_GET, GetMediaHttp, true),
+	models.EndpointFrom("avatar/*path", utils.HTTP_METHOD_GET, GetAvatarHttp, true),
+
 	// System endpoints
 	models.EndpointFrom("", utils.HTTP_METHOD_GET, ValhallaCoreInfoHttp, false),
 }
 
+var JOBS = []*scheduler.Job{
+	scheduler.JobFrom("task/recurrence", time.Minute, SpawnScheduledTasks),
+	scheduler.JobFrom("task/reminder", time.Minute, FireDueReminders),
+	scheduler.JobFrom("user/export", time.Minute, BuildDataExports),
+	scheduler.JobFrom("user/deletion", time.Hour, DeleteScheduledUsers),
+	scheduler.JobFrom("mail/outbox", 30*time.Second, DeliverQueuedEmails),
+	scheduler.JobFrom("webhook/delivery", 30*time.Second, DeliverWebhooks),
+}
+
 // Start API
 func Start() {
 
@@ -63,6 +177,10 @@ func Start() {
 	router.Use(middleware.Panic())
 
 	registerEndpoints(router)
+	db.Setup()
+	storage.Setup()
+	mail.Setup()
+	scheduler.Start(JOBS)
 
 	log.FormattedInfo("API started on https://${0}:${1}${2}", configuration.Params.Ip, configuration.Params.Port, API_COMPLETE)
 	state := router.Run(configuration.Params.Ip + ":" + configuration.Params.Port)
@@ -85,6 +203,8 @@ func registerEndpoints(router *gin.Engine) {
 			router.PUT(API_COMPLETE+endpoint.Path, middleware.APIResponseManagement(endpoint))
 		case utils.HTTP_METHOD_DELETE:
 			router.DELETE(API_COMPLETE+endpoint.Path, middleware.APIResponseManagement(endpoint))
+		case utils.HTTP_METHOD_PATCH:
+			router.PATCH(API_COMPLETE+endpoint.Path, middleware.APIResponseManagement(endpoint))
 		}
 	}
 }
@@ -0,0 +1,541 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SprintTaskRequest struct {
+	Sprint string `json:"sprintid"`
+	Task   string `json:"taskid"`
+}
+
+// Create sprint logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] sprint | *models.Sprint: sprint to create
+//
+// [return] *models.Error: error if any
+func CreateSprint(conn context.Context, client *mongo.Client, sprint *models.Sprint) *models.Error {
+
+	// Check if sprint name is valid
+	checkedName := utils.ValidateName(sprint.Name)
+
+	if checkedName.Response != 200 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(checkedName.Response),
+			Message: checkedName.Message,
+		}
+	}
+
+	// Check if sprint dates are valid
+	datesErr := checkSprintDates(sprint.StartDate, sprint.EndDate)
+
+	if datesErr != nil {
+		return datesErr
+	}
+
+	// Check if project exists
+	_, projectErr := getProjectById(conn, client, sprint.Project)
+
+	if projectErr != nil {
+		return projectErr
+	}
+
+	// Check if sprint already exists
+	coll := client.Database(db.CurrentDatabase).Collection(db.SPRINT)
+	count, err := coll.CountDocuments(conn, bson.M{"project": sprint.Project, "name": sprint.Name})
+
+	if err != nil || count > 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.SPRINT_ALREADY_EXISTS),
+			Message: "Sprint already exists with name " + sprint.Name,
+		}
+	}
+
+	sprint.Tasks = []string{}
+	sprint.ScopeChanges = []models.ScopeChange{}
+
+	result, err := coll.InsertOne(conn, sprint)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.SPRINT_ALREADY_EXISTS),
+			Message: "Sprint not created",
+		}
+	}
+
+	sprint.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+// Edit sprint logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] sprint | *models.Sprint: sprint to edit
+//
+// [return] *models.Error: error if any
+func EditSprint(conn context.Context, client *mongo.Client, sprint *models.Sprint) *models.Error {
+
+	found, getErr := GetSprint(conn, client, sprint)
+
+	if getErr != nil {
+		return getErr
+	}
+
+	if sprint.Name != "" {
+		checkedName := utils.ValidateName(sprint.Name)
+
+		if checkedName.Response != 200 {
+			return &models.Error{
+				Status:  utils.HTTP_STATUS_BAD_REQUEST,
+				Error:   int(checkedName.Response),
+				Message: checkedName.Message,
+			}
+		}
+	}
+
+	// Check the resulting dates
+	startDate := found.StartDate
+	endDate := found.EndDate
+
+	if sprint.StartDate != 0 {
+		startDate = sprint.StartDate
+	}
+
+	if sprint.EndDate != 0 {
+		endDate = sprint.EndDate
+	}
+
+	datesErr := checkSprintDates(startDate, endDate)
+
+	if datesErr != nil {
+		return datesErr
+	}
+
+	objID, _ := utils.StringToObjectId(found.ID)
+	coll := client.Database(db.CurrentDatabase).Collection(db.SPRINT)
+	_, err := coll.UpdateOne(conn, bson.M{"_id": objID}, bson.M{"$set": sprint.PurgedBson()})
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.SPRINT_NOT_UPDATED),
+			Message: "Could not update sprint: " + err.Error(),
+		}
+	}
+
+	return nil
+}
+
+// Delete sprint logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] sprint | *models.Sprint: sprint to delete
+//
+// [return] *models.Error: error if any
+func DeleteSprint(conn context.Context, client *mongo.Client, sprint *models.Sprint) *models.Error {
+
+	objID, err := utils.StringToObjectId(sprint.ID)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.BAD_OBJECT_ID),
+			Message: "Bad object id",
+		}
+	}
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.SPRINT)
+	deleteResult, err := coll.DeleteOne(conn, bson.M{"_id": objID})
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.SPRINT_NOT_DELETED),
+			Message: "Sprint not deleted",
+		}
+	}
+
+	if deleteResult.DeletedCount == 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.SPRINT_NOT_FOUND),
+			Message: "Sprint not found",
+		}
+	}
+
+	return nil
+}
+
+// Get sprint logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] sprint | *models.Sprint: sprint to get
+//
+// [return] *models.Sprint: sprint found --> *models.Error: error if any
+func GetSprint(conn context.Context, client *mongo.Client, sprint *models.Sprint) (*models.Sprint, *models.Error) {
+
+	objID, err := utils.StringToObjectId(sprint.ID)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.BAD_OBJECT_ID),
+			Message: "Bad object id",
+		}
+	}
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.SPRINT)
+
+	var found models.Sprint
+	err = coll.FindOne(conn, bson.M{"_id": objID}).Decode(&found)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.SPRINT_NOT_FOUND),
+			Message: "Sprint not found",
+		}
+	}
+
+	return &found, nil
+}
+
+// Get project sprints logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] project | string: id of the project
+//
+// [return] []models.Sprint: sprints of the project sorted by start date --> *models.Error: error if any
+func GetProjectSprints(conn context.Context, client *mongo.Client, project string) ([]models.Sprint, *models.Error) {
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.SPRINT)
+	findOptions := options.Find().SetSort(bson.D{{Key: "start_date", Value: 1}})
+	cursor, err := coll.Find(conn, bson.M{"project": project}, findOptions)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get sprints",
+		}
+	}
+
+	sprints := []models.Sprint{}
+	err = cursor.All(conn, &sprints)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get sprints",
+		}
+	}
+
+	return sprints, nil
+}
+
+// Add task to sprint logic, the change is recorded
+// as a scope change of the sprint
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] request | *SprintTaskRequest: sprint and task to link
+//
+// [return] *models.Error: error if any
+func AddSprintTask(conn context.Context, client *mongo.Client, request *SprintTaskRequest) *models.Error {
+
+	sprint, task, err := getSprintAndTask(conn, client, request)
+
+	if err != nil {
+		return err
+	}
+
+	if sprintHasTask(sprint, task.ID) {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.TASK_ALREADY_IN_SPRINT),
+			Message: "Task is already in the sprint",
+		}
+	}
+
+	return updateSprintScope(conn, client, sprint, task, models.SCOPE_CHANGE_ADDED)
+}
+
+// Remove task from sprint logic, the change is recorded
+// as a scope change of the sprint
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] request | *SprintTaskRequest: sprint and task to unlink
+//
+// [return] *models.Error: error if any
+func RemoveSprintTask(conn context.Context, client *mongo.Client, request *SprintTaskRequest) *models.Error {
+
+	sprint, task, err := getSprintAndTask(conn, client, request)
+
+	if err != nil {
+		return err
+	}
+
+	if !sprintHasTask(sprint, task.ID) {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.TASK_NOT_IN_SPRINT),
+			Message: "Task is not in the sprint",
+		}
+	}
+
+	return updateSprintScope(conn, client, sprint, task, models.SCOPE_CHANGE_REMOVED)
+}
+
+// Get sprint burndown logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] sprint | *models.Sprint: sprint to calculate
+// [param] unit | string: count or points
+//
+// [return] []models.BurndownPoint: one point per sprint day --> *models.Error: error if any
+func GetSprintBurndown(conn context.Context, client *mongo.Client, sprint *models.Sprint, unit string) ([]models.BurndownPoint, *models.Error) {
+
+	if unit == "" {
+		unit = models.BURNDOWN_UNIT_COUNT
+	}
+
+	if unit != models.BURNDOWN_UNIT_COUNT && unit != models.BURNDOWN_UNIT_POINTS {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_BURNDOWN_UNIT),
+			Message: "Burndown unit must be count or points",
+		}
+	}
+
+	found, getErr := GetSprint(conn, client, sprint)
+
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	// Every task that has been in the sprint counts, removed ones included
+	ids := []string{}
+	for _, change := range found.ScopeChanges {
+		ids = append(ids, change.Task)
+	}
+
+	tasks, tasksErr := getTasksById(conn, client, ids)
+
+	if tasksErr != nil {
+		return nil, tasksErr
+	}
+
+	return computeBurndown(found, tasks, unit, utils.GetCurrentMillis()), nil
+}
+
+// Calculate the burndown series of a sprint, one point per day.
+// Days after now only carry the ideal line.
+//
+// [param] sprint | *models.Sprint: sprint to calculate
+// [param] tasks | map[string]models.Task: tasks that have been in the sprint
+// [param] unit | string: count or points
+// [param] now | int64: current time in milliseconds
+//
+// [return] []models.BurndownPoint: burndown series
+func computeBurndown(sprint *models.Sprint, tasks map[string]models.Task, unit string, now int64) []models.BurndownPoint {
+
+	weight := func(id string) int {
+		if unit == models.BURNDOWN_UNIT_POINTS {
+			return tasks[id].Points
+		}
+		return 1
+	}
+
+	firstDay := utils.StartOfDay(sprint.StartDate)
+	lastDay := utils.StartOfDay(sprint.EndDate)
+	days := int((lastDay-firstDay)/utils.MILLIS_PER_DAY) + 1
+
+	initialScope := 0
+	for id := range sprintScopeAt(sprint, sprint.StartDate) {
+		initialScope += weight(id)
+	}
+
+	series := []models.BurndownPoint{}
+	for day := 0; day < days; day++ {
+
+		dayStart := firstDay + int64(day)*utils.MILLIS_PER_DAY
+		point := models.BurndownPoint{
+			Date:  dayStart,
+			Ideal: float64(initialScope),
+		}
+
+		if days > 1 {
+			point.Ideal = float64(initialScope) * (1 - float64(day)/float64(days-1))
+		}
+
+		if dayStart <= now {
+
+			at := dayStart + utils.MILLIS_PER_DAY - 1
+			if at > now {
+				at = now
+			}
+
+			scope := 0
+			completed := 0
+
+			for id := range sprintScopeAt(sprint, at) {
+				scope += weight(id)
+
+				task, exists := tasks[id]
+				if exists && task.Done && task.CompletionDate <= at {
+					completed += weight(id)
+				}
+			}
+
+			remaining := scope - completed
+			point.Scope = &scope
+			point.Completed = &completed
+			point.Remaining = &remaining
+		}
+
+		series = append(series, point)
+	}
+
+	return series
+}
+
+// Get the tasks that were in the sprint at the given time
+// by replaying the scope changes
+//
+// [param] sprint | *models.Sprint: sprint to replay
+// [param] at | int64: time in milliseconds
+//
+// [return] map[string]bool: set of task ids
+func sprintScopeAt(sprint *models.Sprint, at int64) map[string]bool {
+
+	changes := make([]models.ScopeChange, len(sprint.ScopeChanges))
+	copy(changes, sprint.ScopeChanges)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Date < changes[j].Date
+	})
+
+	scope := map[string]bool{}
+	for _, change := range changes {
+
+		if change.Date > at {
+			break
+		}
+
+		if change.Action == models.SCOPE_CHANGE_ADDED {
+			scope[change.Task] = true
+		} else {
+			delete(scope, change.Task)
+		}
+	}
+
+	return scope
+}
+
+// Check if the given sprint dates are valid
+//
+// [param] start | int64: start date in milliseconds
+// [param] end | int64: end date in milliseconds
+//
+// [return] *models.Error: error if any
+func checkSprintDates(start int64, end int64) *models.Error {
+
+	if start <= 0 || end <= 0 || end <= start {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_SPRINT_DATES),
+			Message: "Sprint must end after it starts",
+		}
+	}
+
+	return nil
+}
+
+func getSprintAndTask(conn context.Context, client *mongo.Client, request *SprintTaskRequest) (*models.Sprint, *models.Task, *models.Error) {
+
+	sprint, err := GetSprint(conn, client, &models.Sprint{ID: request.Sprint})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	task, err := GetTask(conn, client, &models.Task{ID: request.Task})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if task.Project != sprint.Project {
+		return nil, nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.TASK_FROM_OTHER_PROJECT),
+			Message: "Task does not belong to the sprint project",
+		}
+	}
+
+	return sprint, task, nil
+}
+
+func sprintHasTask(sprint *models.Sprint, task string) bool {
+
+	for _, id := range sprint.Tasks {
+		if id == task {
+			return true
+		}
+	}
+
+	return false
+}
+
+func updateSprintScope(conn context.Context, client *mongo.Client, sprint *models.Sprint, task *models.Task, action string) *models.Error {
+
+	change := models.ScopeChange{
+		Task:   task.ID,
+		Action: action,
+		Points: task.Points,
+		Date:   utils.GetCurrentMillis(),
+	}
+
+	update := bson.M{"$push": bson.M{"scope_changes": change}}
+
+	if action == models.SCOPE_CHANGE_ADDED {
+		update["$addToSet"] = bson.M{"tasks": task.ID}
+	} else {
+		update["$pull"] = bson.M{"tasks": task.ID}
+	}
+
+	objID, _ := utils.StringToObjectId(sprint.ID)
+	coll := client.Database(db.CurrentDatabase).Collection(db.SPRINT)
+	_, err := coll.UpdateOne(conn, bson.M{"_id": objID}, update)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.SPRINT_NOT_UPDATED),
+			Message: "Sprint scope not updated",
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"context"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Create sprint HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func CreateSprintHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var sprint *models.Sprint = &models.Sprint{}
+	err := c.ShouldBindJSON(sprint)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	project, projectErr := getProjectById(conn, client, sprint.Project)
+	if projectErr != nil {
+		return nil, projectErr
+	}
+
+	if !CanSeeProject(conn, client, request.User, project) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot create sprints on this project",
+		}
+	}
+
+	var createErr = CreateSprint(conn, client, sprint)
+	if createErr != nil {
+		return nil, createErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Sprint created", "id": sprint.ID},
+	}, nil
+}
+
+// Edit sprint HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func EditSprintHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *models.Sprint = &models.Sprint{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	_, accessErr := getVisibleSprint(conn, client, request.User, params.ID)
+	if accessErr != nil {
+		return nil, accessErr
+	}
+
+	var editErr = EditSprint(conn, client, params)
+	if editErr != nil {
+		return nil, editErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Sprint changed"},
+	}, nil
+}
+
+// Delete sprint HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func DeleteSprintHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *models.Sprint = &models.Sprint{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	_, accessErr := getVisibleSprint(conn, client, request.User, params.ID)
+	if accessErr != nil {
+		return nil, accessErr
+	}
+
+	var deleteErr = DeleteSprint(conn, client, params)
+	if deleteErr != nil {
+		return nil, deleteErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Sprint deleted"},
+	}, nil
+}
+
+// Get sprint HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetSprintHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	id := c.Query("id")
+	if id == "" {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Sprint ID is required",
+		}
+	}
+
+	sprint, getErr := getVisibleSprint(conn, client, request.User, id)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Sprint found", "sprint": sprint},
+	}, nil
+}
+
+// Get project sprints HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetProjectSprintsHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	project, projectErr := getProjectById(conn, client, c.Query("project"))
+	if projectErr != nil {
+		return nil, projectErr
+	}
+
+	if !CanSeeProject(conn, client, request.User, project) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the project",
+		}
+	}
+
+	sprints, getErr := GetProjectSprints(conn, client, project.ID)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Sprints found", "sprints": sprints},
+	}, nil
+}
+
+// Add task to sprint HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func AddSprintTaskHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *SprintTaskRequest = &SprintTaskRequest{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	_, accessErr := getVisibleSprint(conn, client, request.User, params.Sprint)
+	if accessErr != nil {
+		return nil, accessErr
+	}
+
+	var addErr = AddSprintTask(conn, client, params)
+	if addErr != nil {
+		return nil, addErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Task added to sprint"},
+	}, nil
+}
+
+// Remove task from sprint HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func RemoveSprintTaskHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *SprintTaskRequest = &SprintTaskRequest{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	_, accessErr := getVisibleSprint(conn, client, request.User, params.Sprint)
+	if accessErr != nil {
+		return nil, accessErr
+	}
+
+	var removeErr = RemoveSprintTask(conn, client, params)
+	if removeErr != nil {
+		return nil, removeErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Task removed from sprint"},
+	}, nil
+}
+
+// Get sprint burndown HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetSprintBurndownHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	sprint, accessErr := getVisibleSprint(conn, client, request.User, c.Query("id"))
+	if accessErr != nil {
+		return nil, accessErr
+	}
+
+	unit := c.Query("unit")
+	burndown, burndownErr := GetSprintBurndown(conn, client, sprint, unit)
+	if burndownErr != nil {
+		return nil, burndownErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Sprint burndown", "burndown": burndown},
+	}, nil
+}
+
+// Get a sprint only if the user can see its project
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user requesting the sprint
+// [param] id | string: id of the sprint
+//
+// [return] *models.Sprint: sprint found --> *models.Error: error if any
+func getVisibleSprint(conn context.Context, client *mongo.Client, user *models.User, id string) (*models.Sprint, *models.Error) {
+
+	sprint, getErr := GetSprint(conn, client, &models.Sprint{ID: id})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	project, projectErr := getProjectById(conn, client, sprint.Project)
+	if projectErr != nil {
+		return nil, projectErr
+	}
+
+	if !CanSeeProject(conn, client, user, project) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the sprint",
+		}
+	}
+
+	return sprint, nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+)
+
+func TestSprintBurndown(t *testing.T) {
+
+	var start = int64(1700000000000) - int64(1700000000000)%utils.MILLIS_PER_DAY
+	var day = utils.MILLIS_PER_DAY
+
+	var sprint = &models.Sprint{
+		StartDate: start + 1,
+		EndDate:   start + 4*day,
+		ScopeChanges: []models.ScopeChange{
+			{Task: "a", Action: models.SCOPE_CHANGE_ADDED, Date: start},
+			{Task: "b", Action: models.SCOPE_CHANGE_ADDED, Date: start},
+			{Task: "c", Action: models.SCOPE_CHANGE_ADDED, Date: start + day + 10},
+			{Task: "b", Action: models.SCOPE_CHANGE_REMOVED, Date: start + 2*day + 10},
+		},
+	}
+
+	var tasks = map[string]models.Task{
+		"a": {ID: "a", Points: 3, Done: true, CompletionDate: start + day + 20},
+		"b": {ID: "b", Points: 5},
+		"c": {ID: "c", Points: 2},
+	}
+
+	// now is in the middle of the third day
+	var burndown = computeBurndown(sprint, tasks, models.BURNDOWN_UNIT_POINTS, start+2*day+100)
+
+	if len(burndown) != 5 {
+		t.Error("The burndown must have one point per sprint day", len(burndown))
+		return
+	}
+
+	if burndown[0].Ideal != 8 || burndown[4].Ideal != 0 {
+		t.Error("The ideal line must go from the initial scope to zero", burndown[0].Ideal, burndown[4].Ideal)
+		return
+	}
+
+	var expected = []struct{ scope, completed, remaining int }{
+		{8, 0, 8},
+		{10, 3, 7},
+		{5, 3, 2},
+	}
+
+	for i, values := range expected {
+		point := burndown[i]
+
+		if point.Scope == nil || *point.Scope != values.scope || *point.Completed != values.completed || *point.Remaining != values.remaining {
+			t.Error("Unexpected burndown values for day", i)
+			return
+		}
+	}
+
+	if burndown[3].Remaining != nil || burndown[4].Remaining != nil {
+		t.Error("Future days must only have the ideal line")
+		return
+	}
+
+	log.Info("Burndown calculated")
+}
+
+func TestSprintBurndownByCount(t *testing.T) {
+
+	var start = int64(1700000000000) - int64(1700000000000)%utils.MILLIS_PER_DAY
+	var day = utils.MILLIS_PER_DAY
+
+	var sprint = &models.Sprint{
+		StartDate: start,
+		EndDate:   start + day,
+		ScopeChanges: []models.ScopeChange{
+			{Task: "a", Action: models.SCOPE_CHANGE_ADDED, Date: start},
+			{Task: "b", Action: models.SCOPE_CHANGE_ADDED, Date: start},
+		},
+	}
+
+	var tasks = map[string]models.Task{
+		"a": {ID: "a", Points: 3, Done: true, CompletionDate: start + 10},
+		"b": {ID: "b", Points: 5},
+	}
+
+	var burndown = computeBurndown(sprint, tasks, models.BURNDOWN_UNIT_COUNT, start+3*day)
+
+	if *burndown[0].Scope != 2 || *burndown[0].Remaining != 1 || *burndown[1].Completed != 1 {
+		t.Error("The burndown must count tasks when the unit is count")
+		return
+	}
+
+	log.Info("Burndown by count calculated")
+}
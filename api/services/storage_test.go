@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/storage"
+)
+
+// In-memory stand-in of an S3-compatible service,
+// objects are kept by path while requests are signed
+func s3StandIn(t *testing.T, accessKey string) *httptest.Server {
+
+	var objects = map[string][]byte{}
+	var mutex sync.Mutex
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		body, _ := io.ReadAll(r.Body)
+		hash := sha256.Sum256(body)
+
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential="+accessKey+"/") ||
+			r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(hash[:]) ||
+			r.Header.Get("X-Amz-Date") == "" {
+			t.Error("Unsigned request", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		switch r.Method {
+		case http.MethodPut:
+			objects[r.URL.Path] = body
+		case http.MethodGet:
+			content, found := objects[r.URL.Path]
+			if !found {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			w.Write(content)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+}
+
+func checkBlob(t *testing.T, blob storage.Blob) bool {
+
+	var ctx = context.Background()
+	var content = []byte("valhalla")
+	var key = storage.ContentAddress("attachments", content, ".txt")
+
+	if err := blob.Put(ctx, key, content, "text/plain"); err != nil {
+		t.Error("The object was not stored", err)
+		return false
+	}
+
+	if stored, err := blob.Get(ctx, key); err != nil || string(stored) != string(content) {
+		t.Error("The object must be read as stored", err)
+		return false
+	}
+
+	if err := blob.Delete(ctx, key); err != nil {
+		t.Error("The object was not deleted", err)
+		return false
+	}
+
+	if _, err := blob.Get(ctx, key); err != storage.ErrNotFound {
+		t.Error("Deleted objects must not be found", err)
+		return false
+	}
+
+	return true
+}
+
+func TestContentAddress(t *testing.T) {
+
+	var key = storage.ContentAddress("images", []byte("valhalla"), ".png")
+
+	if key != storage.ContentAddress("images", []byte("valhalla"), ".png") || key == storage.ContentAddress("images", []byte("asgard"), ".png") {
+		t.Error("Keys must depend only on the content", key)
+		return
+	}
+
+	if !strings.HasPrefix(key, "images/") || strings.Count(key, "/") != 2 || !strings.HasSuffix(key, ".png") {
+		t.Error("Unexpected key", key)
+		return
+	}
+
+	log.Info("Content addresses checked")
+}
+
+func TestLocalBlob(t *testing.T) {
+
+	var blob = storage.NewLocal(t.TempDir())
+
+	if !checkBlob(t, blob) {
+		return
+	}
+
+	if err := blob.Put(context.Background(), "../outside", []byte("x"), "text/plain"); err != storage.ErrInvalidKey {
+		t.Error("Keys must not leave the root", err)
+		return
+	}
+
+	log.Info("Local blob storage checked")
+}
+
+func TestS3Blob(t *testing.T) {
+
+	var server = s3StandIn(t, "valhalla")
+	defer server.Close()
+
+	if !checkBlob(t, storage.NewS3(server.URL, "", "media", "valhalla", "secret")) {
+		return
+	}
+
+	log.Info("S3 blob storage checked")
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/events"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Create task logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] task | *models.Task: task to create
+//
+// [return] *models.Error: error if any
+func CreateTask(conn context.Context, client *mongo.Client, task *models.Task) *models.Error {
+
+	if utils.IsEmpty(task.Name) {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.EMPTY_TASK_NAME),
+			Message: "Task name cannot be empty",
+		}
+	}
+
+	if task.Points < 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_TASK_POINTS),
+			Message: "Task points cannot be negative",
+		}
+	}
+
+	if task.Duration < 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_TASK_DURATION),
+			Message: "Task duration cannot be negative",
+		}
+	}
+
+	if utils.IsEmpty(task.Project) {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.NO_PROJECT),
+			Message: "Task requires a project",
+		}
+	}
+
+	if task.DueDate < 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.MISSING_DUE_DATE),
+			Message: "Task due date cannot be negative",
+		}
+	}
+
+	if task.Recurrence != nil {
+		recurrenceErr := checkRecurrence(task.Recurrence, task.DueDate)
+
+		if recurrenceErr != nil {
+			return recurrenceErr
+		}
+
+		// Tasks without series are the first occurrence
+		if task.Series == "" {
+			task.Recurrence.Start = task.DueDate
+			task.Occurrence = 1
+		}
+	}
+
+	project, projectErr := getProjectById(conn, client, task.Project)
+
+	if projectErr != nil {
+		return projectErr
+	}
+
+	task.Spawned = false
+	task.Done = false
+	task.CompletionDate = 0
+	task.CreationDate = utils.GetCurrentMillis()
+
+	tasks := client.Database(db.CurrentDatabase).Collection(db.TASK)
+	result, err := tasks.InsertOne(conn, task)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.TASK_NOT_CREATED),
+			Message: "Task not created",
+		}
+	}
+
+	task.ID = result.InsertedID.(primitive.ObjectID).Hex()
+
+	// Link the task to its project
+	projectID, _ := utils.StringToObjectId(project.ID)
+	projects := client.Database(db.CurrentDatabase).Collection(db.PROJECT)
+	_, err = projects.UpdateOne(conn, bson.M{"_id": projectID}, bson.M{"$push": bson.M{"tasks": task.ID}})
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UPDATE_ERROR),
+			Message: "Task not linked to project",
+		}
+	}
+
+	publishTaskEvent(conn, client, task, events.EVENT_TASK_CREATED)
+	return nil
+}
+
+// Get task logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] task | *models.Task: task to get
+//
+// [return] *models.Task: task found --> *models.Error: error if any
+func GetTask(conn context.Context, client *mongo.Client, task *models.Task) (*models.Task, *models.Error) {
+
+	objID, err := utils.StringToObjectId(task.ID)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.BAD_OBJECT_ID),
+			Message: "Bad object id",
+		}
+	}
+
+	tasks := client.Database(db.CurrentDatabase).Collection(db.TASK)
+
+	var found models.Task
+	err = tasks.FindOne(conn, bson.M{"_id": objID}).Decode(&found)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.TASK_NOT_FOUND),
+			Message: "Task not found",
+		}
+	}
+
+	return &found, nil
+}
+
+// Complete task logic, tasks with open blockers
+// can only be completed when forced
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] task | *models.Task: task to complete
+// [param] force | bool: complete even if the task is blocked
+//
+// [return] *models.Error: error if any
+func CompleteTask(conn context.Context, client *mongo.Client, task *models.Task, force bool) *models.Error {
+
+	found, getErr := GetTask(conn, client, task)
+
+	if getErr != nil {
+		return getErr
+	}
+
+	if found.Done {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.TASK_ALREADY_COMPLETED),
+			Message: "Task already completed",
+		}
+	}
+
+	if !force {
+		blockers, blockersErr := getOpenBlockers(conn, client, found.ID)
+
+		if blockersErr != nil {
+			return blockersErr
+		}
+
+		if len(blockers) > 0 {
+			return &models.Error{
+				Status:  utils.HTTP_STATUS_CONFLICT,
+				Error:   int(error.TASK_BLOCKED),
+				Message: "Task is blocked by " + utils.Int2String(len(blockers)) + " open tasks",
+			}
+		}
+	}
+
+	objID, _ := utils.StringToObjectId(found.ID)
+	tasks := client.Database(db.CurrentDatabase).Collection(db.TASK)
+	completionDate := utils.GetCurrentMillis()
+	_, err := tasks.UpdateOne(conn, bson.M{"_id": objID}, bson.M{"$set": bson.M{
+		"done":            true,
+		"completion_date": completionDate,
+	}})
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.TASK_NOT_UPDATED),
+			Message: "Task not completed",
+		}
+	}
+
+	found.Done = true
+	found.CompletionDate = completionDate
+	publishTaskEvent(conn, client, found, events.EVENT_TASK_UPDATED)
+
+	if found.Recurrence != nil && found.Recurrence.Mode == models.RECURRENCE_ON_COMPLETION {
+		_, spawnErr := spawnNextOccurrence(conn, client, found)
+
+		if spawnErr != nil {
+			log.FormattedError("Next occurrence of task ${0} not created: ${1}", found.ID, spawnErr.Message)
+		}
+	}
+
+	return nil
+}
+
+// Assign a task to a user who can see it, an empty
+// assignee leaves the task unassigned
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] author | *models.User: user assigning the task
+// [param] task | *models.Task: task to assign
+// [param] assignee | string: id of the assignee
+//
+// [return] *models.Error: error if any
+func AssignTask(conn context.Context, client *mongo.Client, author *models.User, task *models.Task, assignee string) *models.Error {
+
+	update := bson.M{"$unset": bson.M{"assignee": ""}}
+
+	if assignee != "" {
+		user, userErr := GetUserById(conn, client, assignee)
+
+		if userErr != nil || !CanSeeTask(conn, client, user, task) {
+			return &models.Error{
+				Status:  utils.HTTP_STATUS_BAD_REQUEST,
+				Error:   int(error.INVALID_ASSIGNEE),
+				Message: "Tasks can only be assigned to users who can see them",
+			}
+		}
+
+		update = bson.M{"$set": bson.M{"assignee": assignee}}
+	}
+
+	objID, _ := utils.StringToObjectId(task.ID)
+	tasks := client.Database(db.CurrentDatabase).Collection(db.TASK)
+	_, err := tasks.UpdateOne(conn, bson.M{"_id": objID}, update)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.TASK_NOT_UPDATED),
+			Message: "Task not assigned",
+		}
+	}
+
+	previous := task.Assignee
+	task.Assignee = assignee
+	publishTaskEvent(conn, client, task, events.EVENT_TASK_UPDATED)
+
+	if assignee != "" && assignee != previous && assignee != author.ID {
+		NotifyUser(conn, client, &models.Notification{
+			User:    assignee,
+			Type:    models.NOTIFICATION_ASSIGNMENT,
+			Title:   author.Username + " assigned you " + task.Name,
+			Message: task.Description,
+			Source:  task.ID,
+		})
+	}
+
+	return nil
+}
+
+// Get tasks by id logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] ids | []string: ids of the tasks
+//
+// [return] map[string]models.Task: tasks found by id --> *models.Error: error if any
+func getTasksById(conn context.Context, client *mongo.Client, ids []string) (map[string]models.Task, *models.Error) {
+
+	objIDs := []primitive.ObjectID{}
+	for _, id := range ids {
+		objID, err := utils.StringToObjectId(id)
+
+		if err == nil {
+			objIDs = append(objIDs, objID)
+		}
+	}
+
+	found := map[string]models.Task{}
+
+	if len(objIDs) == 0 {
+		return found, nil
+	}
+
+	tasks := client.Database(db.CurrentDatabase).Collection(db.TASK)
+	cursor, err := tasks.Find(conn, bson.M{"_id": bson.M{"$in": objIDs}})
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get tasks",
+		}
+	}
+
+	var results []models.Task
+	err = cursor.All(conn, &results)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get tasks",
+		}
+	}
+
+	for _, task := range results {
+		found[task.ID] = task
+	}
+
+	return found, nil
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Create task HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func CreateTaskHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var task *models.Task = &models.Task{}
+	err := c.ShouldBindJSON(task)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	project, projectErr := getProjectById(conn, client, task.Project)
+	if projectErr != nil {
+		return nil, projectErr
+	}
+
+	if !CanSeeProject(conn, client, request.User, project) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot create tasks on this project",
+		}
+	}
+
+	task.Owner = request.User.ID
+	task.Assignee = ""
+	task.Series = ""
+	var createErr = CreateTask(conn, client, task)
+	if createErr != nil {
+		return nil, createErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Task created", "id": task.ID},
+	}, nil
+}
+
+// Get task HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetTaskHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params = &models.Task{ID: c.Query("id")}
+
+	if params.ID == "" {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Task ID is required",
+		}
+	}
+
+	task, getErr := GetTask(conn, client, params)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if !CanSeeTask(conn, client, request.User, task) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the task",
+		}
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Task found", "task": task},
+	}, nil
+}
+
+// Complete task HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func CompleteTaskHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *models.Task = &models.Task{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	task, getErr := GetTask(conn, client, params)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if !CanSeeTask(conn, client, request.User, task) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot edit the task",
+		}
+	}
+
+	force := c.Query("force") == "true"
+	var completeErr = CompleteTask(conn, client, task, force)
+	if completeErr != nil {
+		return nil, completeErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Task completed"},
+	}, nil
+}
+
+// Assign task HTTP API endpoint, an empty Assignee unassigns the task
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func AssignTaskHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *models.Task = &models.Task{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	task, getErr := GetTask(conn, client, params)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if !CanSeeTask(conn, client, request.User, task) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot edit the task",
+		}
+	}
+
+	var assignErr = AssignTask(conn, client, request.User, task, params.Assignee)
+	if assignErr != nil {
+		return nil, assignErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Task assigned"},
+	}, nil
+}
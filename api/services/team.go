@@ -2,18 +2,24 @@ package services
 
 import (
 	"context"
+	"regexp"
+	"strings"
 
 	"github.com/akrck02/valhalla-core/db"
 	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/events"
 	"github.com/akrck02/valhalla-core/models"
 	"github.com/akrck02/valhalla-core/utils"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type MemberChangeRequest struct {
 	Team string `json:"teamid"`
 	User string `json:"userid"`
+	Role string `json:"role"`
 }
 
 // Create team logic
@@ -81,6 +87,33 @@ func CreateTeam(conn context.Context, client *mongo.Client, team *models.Team) *
 		}
 	}
 
+	// New teams start with their owner alone, the
+	// rest join by invitation or join request
+	team.Members = nil
+	team.Roles = nil
+	team.ProfilePic = ""
+
+	// Teams are private unless told otherwise
+	if team.Visibility == "" {
+		team.Visibility = models.TEAM_VISIBILITY_PRIVATE
+	}
+
+	visibilityErr := validateTeamVisibility(team.Visibility)
+	if visibilityErr != nil {
+		return visibilityErr
+	}
+
+	// Child teams inherit the ancestors of their parent
+	team.Ancestors = nil
+	if team.Parent != "" {
+		parent, parentErr := GetTeam(conn, client, &models.Team{ID: team.Parent})
+		if parentErr != nil {
+			return parentErr
+		}
+
+		team.Ancestors = teamLineage(parent)
+	}
+
 	// Check if team already exists
 	coll := client.Database(db.CurrentDatabase).Collection(db.TEAM)
 
@@ -129,8 +162,19 @@ func DeleteTeam(conn context.Context, client *mongo.Client, team *models.Team) *
 		}
 	}
 
-	// Delete team
+	// Child teams must be moved or deleted first
 	coll := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+	children, err := coll.CountDocuments(conn, bson.M{"parent": team.ID})
+
+	if err == nil && children > 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.TEAM_HAS_CHILDREN),
+			Message: "Team has child teams",
+		}
+	}
+
+	// Delete team
 	_, err = coll.DeleteOne(conn, bson.M{"_id": objID})
 
 	// Check if team was deleted
@@ -166,9 +210,18 @@ func EditTeam(conn context.Context, client *mongo.Client, team *models.Team) *mo
 		}
 	}
 
+	if team.Visibility != "" {
+		visibilityErr := validateTeamVisibility(team.Visibility)
+		if visibilityErr != nil {
+			return visibilityErr
+		}
+	}
+
 	coll := client.Database(db.CurrentDatabase).Collection(db.TEAM)
 
+	// The owner only changes with an accepted transfer
 	update := team.PurgedBson(true)
+	delete(update, "owner")
 	_, err = coll.UpdateOne(conn, bson.M{"_id": objID}, bson.M{"$set": update})
 
 	// Check if team was updated
@@ -180,161 +233,272 @@ func EditTeam(conn context.Context, client *mongo.Client, team *models.Team) *mo
 		}
 	}
 
+	updated, getErr := GetTeam(conn, client, team)
+	if getErr == nil {
+		publishTeamEvent(conn, client, updated, events.EVENT_TEAM_UPDATED, map[string]string{"team": updated.ID})
+	}
+
 	return nil
 }
 
-// Edit team owner logic
+// Change team profile picture logic
 //
 // [param] conn | context.Context: connection to the database
 // [param] client | *mongo.Client: client to the database
-// [param] team | *models.Team: team to edit
+// [param] team | *models.Team: team to change the picture
+// [param] picture | []byte: uploaded picture
 //
-// [return] error: *models.Error: error if any
-func EditTeamOwner(conn context.Context, client *mongo.Client, team *models.Team) *models.Error {
+// [return] *models.Error: error if any
+func EditTeamProfilePicture(conn context.Context, client *mongo.Client, team *models.Team, picture []byte) *models.Error {
 
-	// Check if team owner is empty
-	if utils.IsEmpty(team.Owner) {
+	media, saveErr := savePictureMedia(conn, client, models.MEDIA_OF_TEAM, team.ID, picture)
+	if saveErr != nil {
+		return saveErr
+	}
+
+	objID, _ := utils.StringToObjectId(team.ID)
+	coll := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+	_, err := coll.UpdateOne(conn, bson.M{"_id": objID}, bson.M{"$set": bson.M{
+		"profilepic": media.ID,
+	}})
+
+	if err != nil {
+		deleteMedia(conn, client, media.ID, models.MEDIA_OF_TEAM, team.ID)
 		return &models.Error{
 			Status:  utils.HTTP_STATUS_BAD_REQUEST,
-			Error:   int(error.NO_OWNER),
-			Message: "Team requires an owner",
+			Error:   int(error.UPDATE_ERROR),
+			Message: "Could not change the team picture",
 		}
 	}
 
-	// Transform team id to object id
-	// also check if team id is valid
-	objID, err1 := utils.StringToObjectId(team.ID)
+	deleteMedia(conn, client, team.ProfilePic, models.MEDIA_OF_TEAM, team.ID)
+	team.ProfilePic = media.ID
+	return nil
+}
 
-	if err1 != nil {
+// Remove member from team logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] author | *models.User: user removing the member
+// [param] member | *MemberChangeRequest: team and member to remove
+//
+// [return] error: *models.Error: error if any
+func RemoveMember(conn context.Context, client *mongo.Client, author *models.User, member *MemberChangeRequest) *models.Error {
+
+	// Check if member is empty
+	if utils.IsEmpty(member.User) {
 		return &models.Error{
 			Status:  utils.HTTP_STATUS_BAD_REQUEST,
-			Error:   int(error.BAD_OBJECT_ID),
-			Message: "Bad object id",
+			Error:   int(error.NO_MEMBER),
+			Message: "Removing a member requires a member",
 		}
 	}
 
-	// Check if owner exists
-	err2 := userExists(conn, client, team.Owner)
+	team, err1 := GetTeam(conn, client, &models.Team{ID: member.Team})
 
-	if err2 != nil {
-		return err2
+	if err1 != nil {
+		return err1
 	}
 
-	// Update owner
-	coll := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+	// The owner must transfer the team first
+	if team.Owner == member.User {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.OWNER_CANNOT_LEAVE),
+			Message: "The owner cannot leave the team until ownership is transferred",
+		}
+	}
 
-	result := coll.FindOneAndUpdate(conn, bson.M{"_id": objID}, bson.M{"$set": bson.M{
-		"owner": team.Owner,
-	},
-	})
+	if !isTeamMember(team, member.User) {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.NOT_A_MEMBER),
+			Message: "User is not a member of the team",
+		}
+	}
 
-	// Check if team was updated
-	err3 := result.Err()
+	objID, _ := utils.StringToObjectId(team.ID)
+	coll := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+	_, err2 := coll.UpdateOne(conn, bson.M{"_id": objID}, bson.M{
+		"$pull":  bson.M{"members": member.User},
+		"$unset": bson.M{"roles." + member.User: ""},
+	})
 
-	if err3 != nil {
+	if err2 != nil {
 		return &models.Error{
 			Status:  utils.HTTP_STATUS_BAD_REQUEST,
 			Error:   int(error.UPDATE_ERROR),
-			Message: "Could not change owner",
+			Message: "Could not remove member",
 		}
 	}
 
+	// Former members cannot become owners
+	cancelMemberTransfers(conn, client, author, team, member.User)
+
+	// The removed member still gets the event
+	publishTeamEvent(conn, client, team, events.EVENT_TEAM_MEMBER_REMOVED, map[string]string{"team": team.ID, "user": member.User})
 	return nil
 }
 
-// Add member to team logic
+// Change the role of a member of the team
 //
 // [param] conn | context.Context: connection to the database
 // [param] client | *mongo.Client: client to the database
-// [param] team | *models.Team: team to edit
+// [param] member | *MemberChangeRequest: team, member and new role
 //
 // [return] error: *models.Error: error if any
-func AddMember(conn context.Context, client *mongo.Client, memberChange *MemberChangeRequest) *models.Error {
-
-	// Check if member is empty
-	if utils.IsEmpty(memberChange.User) {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_BAD_REQUEST,
-			Error:   int(error.NO_MEMBER),
-			Message: "Adding a member requires a member",
-		}
-	}
+func SetMemberRole(conn context.Context, client *mongo.Client, member *MemberChangeRequest) *models.Error {
 
-	// Check if team is empty
-	if utils.IsEmpty(memberChange.Team) {
+	if member.Role != models.TEAM_ROLE_ADMIN && member.Role != models.TEAM_ROLE_MEMBER {
 		return &models.Error{
 			Status:  utils.HTTP_STATUS_BAD_REQUEST,
-			Error:   int(error.NO_TEAM),
-			Message: "Adding a member requires a team",
+			Error:   int(error.INVALID_TEAM_ROLE),
+			Message: "Members can only be admin or member",
 		}
 	}
 
-	// Check if member exists
-	err1 := userExists(conn, client, memberChange.User)
+	team, err1 := GetTeam(conn, client, &models.Team{ID: member.Team})
 
 	if err1 != nil {
 		return err1
 	}
 
-	// Transform team id to object id
-	// also check if team id is valid
-	objID, err2 := utils.StringToObjectId(memberChange.Team)
-
-	if err2 != nil {
+	if !isTeamMember(team, member.User) {
 		return &models.Error{
-			Status:  utils.HTTP_STATUS_BAD_REQUEST,
-			Error:   int(error.BAD_OBJECT_ID),
-			Message: "Bad object id",
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.NOT_A_MEMBER),
+			Message: "User is not a member of the team",
 		}
 	}
 
-	// Check if member is already in team or is owner
+	objID, _ := utils.StringToObjectId(team.ID)
 	coll := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+	_, err2 := coll.UpdateOne(conn, bson.M{"_id": objID}, bson.M{"$set": bson.M{
+		"roles." + member.User: member.Role,
+	}})
 
-	err3 := isUserMemberOrOwner(conn, client, memberChange)
-
-	if err3 != nil {
-		return err3
-	}
-
-	// Add member to team
-	_, err4 := coll.UpdateOne(conn, bson.M{"_id": objID}, bson.M{"$push": bson.M{
-		"members": memberChange.User,
-	},
-	})
-
-	if err4 != nil {
+	if err2 != nil {
 		return &models.Error{
 			Status:  utils.HTTP_STATUS_BAD_REQUEST,
 			Error:   int(error.UPDATE_ERROR),
-			Message: "Could not add member",
+			Message: "Could not change the member role",
 		}
 	}
 
 	return nil
 }
 
-// Remove member from team logic
+// Get a page of the members of a team with their roles, the owner first
 //
 // [param] conn | context.Context: connection to the database
 // [param] client | *mongo.Client: client to the database
-// [param] team | *models.Team: team to edit
+// [param] team | *models.Team: team of the members
+// [param] request | *models.PageRequest: cursor and limit of the page
 //
-// [return] error: *models.Error: error if any
-func RemoveMember(conn context.Context, client *mongo.Client, member *MemberChangeRequest) *models.Error {
-	return nil
+// [return] *models.Page: page of members --> *models.Error: error if any
+func GetTeamMembers(conn context.Context, client *mongo.Client, team *models.Team, request *models.PageRequest) (*models.Page, *models.Error) {
+
+	cursor, cursorErr := decodeCursor(request.Cursor)
+	if cursorErr != nil {
+		return nil, cursorErr
+	}
+
+	offset := 0
+	if cursor != nil {
+		offset = cursor.Offset
+	}
+
+	ids := append([]string{team.Owner}, team.Members...)
+	page := paginate(ids, offset, request.Limit)
+	members := []models.TeamMember{}
+
+	result := &models.Page{
+		Items: members,
+		Total: int64(len(ids)),
+		Limit: request.Limit,
+	}
+
+	if offset+request.Limit < len(ids) {
+		result.NextCursor = encodeCursor(pageCursor{Offset: offset + request.Limit})
+	}
+
+	objIDs := []primitive.ObjectID{}
+	for _, id := range page {
+		objID, err := utils.StringToObjectId(id)
+
+		if err == nil {
+			objIDs = append(objIDs, objID)
+		}
+	}
+
+	if len(objIDs) == 0 {
+		return result, nil
+	}
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.USER)
+	found, err := coll.Find(conn, bson.M{"_id": bson.M{"$in": objIDs}})
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get team members",
+		}
+	}
+
+	users := []models.User{}
+	err = found.All(conn, &users)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get team members",
+		}
+	}
+
+	byID := map[string]models.User{}
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	// Keep the team order, deleted users are skipped
+	for _, id := range page {
+		user, exists := byID[id]
+
+		if !exists {
+			continue
+		}
+
+		members = append(members, models.TeamMember{
+			ID:         user.ID,
+			Username:   user.Username,
+			ProfilePic: user.ProfilePic,
+			Role:       team.RoleOf(user.ID),
+		})
+	}
+
+	result.Items = members
+	return result, nil
 }
 
-// Get teams logic
+// Get the teams the user owns or belongs to
 //
 // [param] conn | context.Context: connection to the database
 // [param] client | *mongo.Client: client to the database
-// [param] team | *models.Team: team to edit
+// [param] user | *models.User: owner or member of the teams
+// [param] request | *models.PageRequest: cursor, limit and sort of the page
 //
-// [return] error: *models.Error: error if any
-func GetTeams(conn context.Context, client *mongo.Client, team *models.Team) *models.Error {
+// [return] *models.Page: page of teams --> *models.Error: error if any
+func GetTeams(conn context.Context, client *mongo.Client, user *models.User, request *models.PageRequest) (*models.Page, *models.Error) {
 
-	return nil
+	filter := bson.M{"$or": bson.A{
+		bson.M{"owner": user.ID},
+		bson.M{"members": user.ID},
+	}}
+
+	return findTeams(conn, client, filter, nil, request, []string{models.SORT_BY_NAME, models.SORT_BY_CREATION})
 }
 
 // Get team logic
@@ -372,19 +536,215 @@ func GetTeam(conn context.Context, client *mongo.Client, team *models.Team) (*mo
 	return &foundTeam, nil
 }
 
-// Search teams logic
+// Search teams by name or description, case insensitive.
+// Teams whose name starts with the text are the most relevant,
+// the rest must contain every word of the text. Only public and
+// discoverable teams or teams the user belongs to are found, and
+// teams the user cannot see only show their summary
 //
 // [param] conn | context.Context: connection to the database
 // [param] client | *mongo.Client: client to the database
-// [param] searchText | *string: text to search
+// [param] user | *models.User: user searching
+// [param] searchText | string: text to search
+// [param] request | *models.PageRequest: cursor, limit and sort of the page
 //
-// [return] error: *models.Error: error if any
-func SearchTeams(conn context.Context, client *mongo.Client, searchText *string) (*[]models.Team, *models.Error) {
+// [return] *models.Page: page of teams --> *models.Error: error if any
+func SearchTeams(conn context.Context, client *mongo.Client, user *models.User, searchText string, request *models.PageRequest) (*models.Page, *models.Error) {
+
+	words := strings.Fields(searchText)
+
+	if len(words) == 0 {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.TEAM_SEARCH_ERROR),
+			Message: "Search text cannot be empty",
+		}
+	}
+
+	prefix := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(strings.Join(words, " ")), Options: "i"}
+
+	// Every word must start a word of the name or the description
+	fullText := bson.A{}
+	for _, word := range words {
+		pattern := primitive.Regex{Pattern: `(^|\W)` + regexp.QuoteMeta(word), Options: "i"}
+		fullText = append(fullText, bson.M{"$or": bson.A{
+			bson.M{"name": pattern},
+			bson.M{"description": pattern},
+		}})
+	}
+
+	filter := bson.M{"$and": bson.A{
+		bson.M{"$or": bson.A{
+			bson.M{"name": prefix},
+			bson.M{"$and": fullText},
+		}},
+		bson.M{"$or": bson.A{
+			bson.M{"visibility": bson.M{"$in": bson.A{models.TEAM_VISIBILITY_PUBLIC, models.TEAM_VISIBILITY_DISCOVERABLE}}},
+			bson.M{"owner": user.ID},
+			bson.M{"members": user.ID},
+		}},
+	}}
+
+	page, findErr := findTeams(conn, client, filter, &prefix, request, []string{models.SORT_BY_RELEVANCE, models.SORT_BY_NAME, models.SORT_BY_CREATION})
+	if findErr != nil {
+		return nil, findErr
+	}
+
+	page.Items = teamsSeenBy(conn, client, user, page.Items.([]models.Team))
+	return page, nil
+}
+
+// Get some teams as a user sees them, discoverable teams
+// the user cannot see only show their summary
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user getting the teams
+// [param] teams | []models.Team: teams to show
+//
+// [return] []interface{}: teams or summaries
+func teamsSeenBy(conn context.Context, client *mongo.Client, user *models.User, teams []models.Team) []interface{} {
+
+	items := []interface{}{}
+	for i := range teams {
+		if CanSeeTeam(conn, client, user, &teams[i]) {
+			items = append(items, teams[i])
+		} else {
+			items = append(items, teams[i].Summary())
+		}
+	}
+
+	return items
+}
+
+// Find a page of teams, sorted by name or creation with a keyset
+// cursor or by relevance with an offset cursor
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] filter | bson.M: filter of the teams
+// [param] prefix | *primitive.Regex: name prefix of the most relevant teams, nil if not searching
+// [param] request | *models.PageRequest: cursor, limit and sort of the page
+// [param] sorts | []string: allowed sort fields, the first one is the default
+//
+// [return] *models.Page: page of teams --> *models.Error: error if any
+func findTeams(conn context.Context, client *mongo.Client, filter bson.M, prefix *primitive.Regex, request *models.PageRequest, sorts []string) (*models.Page, *models.Error) {
+
+	sort, descending, sortErr := parseSort(request.Sort, sorts)
+	if sortErr != nil {
+		return nil, sortErr
+	}
+
+	cursor, cursorErr := decodeCursor(request.Cursor)
+	if cursorErr != nil {
+		return nil, cursorErr
+	}
+
+	searchErr := &models.Error{
+		Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+		Error:   int(error.TEAM_SEARCH_ERROR),
+		Message: "Cannot get teams",
+	}
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+	total, err := coll.CountDocuments(conn, filter)
+
+	if err != nil {
+		return nil, searchErr
+	}
+
+	// One more team than the limit tells if there is a next page
+	teams := []models.Team{}
+	var found *mongo.Cursor
+
+	if sort == models.SORT_BY_RELEVANCE {
+		offset := 0
+		if cursor != nil {
+			offset = cursor.Offset
+		}
+
+		direction := -1
+		if descending {
+			direction = 1
+		}
+
+		found, err = coll.Aggregate(conn, mongo.Pipeline{
+			{{Key: "$match", Value: filter}},
+			{{Key: "$addFields", Value: bson.M{"relevance": bson.M{"$cond": bson.A{
+				bson.M{"$regexMatch": bson.M{"input": "$name", "regex": prefix.Pattern, "options": prefix.Options}}, 1, 0,
+			}}}}},
+			{{Key: "$sort", Value: bson.D{{Key: "relevance", Value: direction}, {Key: "name", Value: 1}, {Key: "_id", Value: 1}}}},
+			{{Key: "$skip", Value: offset}},
+			{{Key: "$limit", Value: request.Limit + 1}},
+			{{Key: "$project", Value: bson.M{"relevance": 0}}},
+		})
+
+		if err != nil || found.All(conn, &teams) != nil {
+			return nil, searchErr
+		}
 
-	foundTeams := []models.Team{}
+		page := &models.Page{Total: total, Limit: request.Limit}
 
-	return &foundTeams, nil
+		if len(teams) > request.Limit {
+			teams = teams[:request.Limit]
+			page.NextCursor = encodeCursor(pageCursor{Offset: offset + request.Limit})
+		}
+
+		page.Items = teams
+		return page, nil
+	}
+
+	field := "name"
+	if sort == models.SORT_BY_CREATION {
+		field = "_id"
+	}
+
+	if cursor != nil {
+		after, keysetErr := keysetFilter(field, descending, cursor)
+		if keysetErr != nil {
+			return nil, keysetErr
+		}
+
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	found, err = coll.Find(conn, filter, options.Find().
+		SetSort(keysetSort(field, descending)).
+		SetLimit(int64(request.Limit+1)))
+
+	if err != nil || found.All(conn, &teams) != nil {
+		return nil, searchErr
+	}
+
+	page := &models.Page{Total: total, Limit: request.Limit}
+
+	if len(teams) > request.Limit {
+		teams = teams[:request.Limit]
+		last := teams[len(teams)-1]
+		page.NextCursor = encodeCursor(pageCursor{Value: last.Name, ID: last.ID})
+	}
 
+	page.Items = teams
+	return page, nil
+}
+
+// Check the visibility of a team
+//
+// [param] visibility | string: visibility to check
+//
+// [return] *models.Error: error if the visibility is not valid
+func validateTeamVisibility(visibility string) *models.Error {
+
+	switch visibility {
+	case models.TEAM_VISIBILITY_PUBLIC, models.TEAM_VISIBILITY_DISCOVERABLE, models.TEAM_VISIBILITY_PRIVATE:
+		return nil
+	}
+
+	return &models.Error{
+		Status:  utils.HTTP_STATUS_BAD_REQUEST,
+		Error:   int(error.INVALID_TEAM_VISIBILITY),
+		Message: "Teams can only be public, discoverable or private",
+	}
 }
 
 func userExists(conn context.Context, client *mongo.Client, user string) *models.Error {
@@ -428,25 +788,30 @@ func teamExists(conn context.Context, coll *mongo.Collection, team *models.Team)
 	return result
 }
 
+// Check that the user is not already the owner or a member of the team
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] request | *MemberChangeRequest: team and user to check
+//
+// [return] *models.Error: error if the user already belongs to the team
 func isUserMemberOrOwner(conn context.Context, client *mongo.Client, request *MemberChangeRequest) *models.Error {
 
-	filterMember := bson.D{
-		{Key: "_id", Value: request.Team},
-		{Key: "members", Value: bson.D{{Key: "$all", Value: bson.A{request.User}}}},
-	}
+	team, err := GetTeam(conn, client, &models.Team{ID: request.Team})
 
-	filterOwner := bson.D{
-		{Key: "_id", Value: request.Team},
-		{Key: "owner", Value: request.User},
+	if err != nil {
+		return err
 	}
 
-	coll := client.Database(db.CurrentDatabase).Collection(db.TEAM)
-
-	var result models.Team
-
-	err := coll.FindOne(conn, filterMember).Decode(&result)
+	if team.Owner == request.User {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.USER_IS_OWNER),
+			Message: "User is owner of the team",
+		}
+	}
 
-	if err != nil {
+	if isTeamMember(team, request.User) {
 		return &models.Error{
 			Status:  utils.HTTP_STATUS_BAD_REQUEST,
 			Error:   int(error.USER_ALREADY_MEMBER),
@@ -454,15 +819,43 @@ func isUserMemberOrOwner(conn context.Context, client *mongo.Client, request *Me
 		}
 	}
 
-	err = coll.FindOne(conn, filterOwner).Decode(&result)
+	return nil
+}
 
-	if err != nil {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_BAD_REQUEST,
-			Error:   int(error.USER_IS_OWNER),
-			Message: "User is owner of the team",
+// Get if the user is a member of the team
+//
+// [param] team | *models.Team: team to check
+// [param] user | string: id of the user
+//
+// [return] bool: true if the user is a member
+func isTeamMember(team *models.Team, user string) bool {
+
+	for _, member := range team.Members {
+		if member == user {
+			return true
 		}
 	}
 
-	return nil
+	return false
+}
+
+// Get a page of a list of ids
+//
+// [param] ids | []string: ids to paginate
+// [param] offset | int: ids to skip
+// [param] limit | int: maximum ids to return
+//
+// [return] []string: ids of the page
+func paginate(ids []string, offset int, limit int) []string {
+
+	if offset >= len(ids) {
+		return []string{}
+	}
+
+	end := offset + limit
+	if end > len(ids) {
+		end = len(ids)
+	}
+
+	return ids[offset:end]
 }
@@ -15,10 +15,16 @@ import (
 // [return] *models.Response: response | *models.Error: error
 func CreateTeamHttp(c *gin.Context) (*models.Response, *models.Error) {
 
+	var request = utils.GetRequestMetadata(c)
 	var client = db.CreateClient()
 	var conn = db.Connect(*client)
 	defer db.Disconnect(*client, conn)
 
+	validatedErr := checkUserValidated(request.User)
+	if validatedErr != nil {
+		return nil, validatedErr
+	}
+
 	var team *models.Team = &models.Team{}
 
 	err := c.ShouldBindJSON(team)
@@ -30,6 +36,25 @@ func CreateTeamHttp(c *gin.Context) (*models.Response, *models.Error) {
 		}
 	}
 
+	// Teams are owned by the user creating them
+	team.Owner = request.User.ID
+
+	// Only the managers of a team can create child teams
+	if team.Parent != "" {
+		parent, getErr := GetTeam(conn, client, &models.Team{ID: team.Parent})
+		if getErr != nil {
+			return nil, getErr
+		}
+
+		if !CanManageTeam(conn, client, request.User, parent) {
+			return nil, &models.Error{
+				Status:  utils.HTTP_STATUS_FORBIDDEN,
+				Error:   error.ACCESS_DENIED,
+				Message: "Access denied: Cannot create child teams",
+			}
+		}
+	}
+
 	var error = CreateTeam(conn, client, team)
 	if error != nil {
 		return nil, error
@@ -49,6 +74,7 @@ func CreateTeamHttp(c *gin.Context) (*models.Response, *models.Error) {
 // [return] *models.Response: response | *models.Error: error
 func EditTeamHttp(c *gin.Context) (*models.Response, *models.Error) {
 
+	var request = utils.GetRequestMetadata(c)
 	var client = db.CreateClient()
 	var conn = db.Connect(*client)
 	defer db.Disconnect(*client, conn)
@@ -63,6 +89,19 @@ func EditTeamHttp(c *gin.Context) (*models.Response, *models.Error) {
 		}
 	}
 
+	team, getErr := GetTeam(conn, client, &models.Team{ID: params.ID})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if !CanManageTeam(conn, client, request.User, team) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot edit the team",
+		}
+	}
+
 	var error = EditTeam(conn, client, params)
 
 	if error != nil {
@@ -75,20 +114,17 @@ func EditTeamHttp(c *gin.Context) (*models.Response, *models.Error) {
 	}, nil
 }
 
-// Edit team owner HTTP API endpoint
+// Edit team profile picture HTTP API endpoint, for the team ?id=
 //
 // [param] c | *gin.Context: context
 //
 // [return] *models.Response: response | *models.Error: error
-func EditTeamOwnerHttp(c *gin.Context) (*models.Response, *models.Error) {
-
-	var client = db.CreateClient()
-	var conn = db.Connect(*client)
-	defer db.Disconnect(*client, conn)
+func EditTeamProfilePictureHttp(c *gin.Context) (*models.Response, *models.Error) {
 
-	var params *models.Team = &models.Team{}
+	var request = utils.GetRequestMetadata(c)
 
-	err := c.ShouldBindJSON(params)
+	// Get image as bytes
+	bytes, err := utils.MultipartToBytes(c, "ProfilePicture")
 	if err != nil {
 		return nil, &models.Error{
 			Status:  utils.HTTP_STATUS_BAD_REQUEST,
@@ -97,15 +133,31 @@ func EditTeamOwnerHttp(c *gin.Context) (*models.Response, *models.Error) {
 		}
 	}
 
-	var error = EditTeamOwner(conn, client, params)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
 
-	if error != nil {
-		return nil, error
+	team, getErr := GetTeam(conn, client, &models.Team{ID: c.Query("id")})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if !CanManageTeam(conn, client, request.User, team) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot edit the team",
+		}
+	}
+
+	var pictureErr = EditTeamProfilePicture(conn, client, team, bytes)
+	if pictureErr != nil {
+		return nil, pictureErr
 	}
 
 	return &models.Response{
 		Code:     utils.HTTP_STATUS_OK,
-		Response: gin.H{"message": "Team owner edited"},
+		Response: gin.H{"message": "Team picture updated"},
 	}, nil
 }
 
@@ -116,6 +168,7 @@ func EditTeamOwnerHttp(c *gin.Context) (*models.Response, *models.Error) {
 // [return] *models.Response: response | *models.Error: error
 func DeleteTeamHttp(c *gin.Context) (*models.Response, *models.Error) {
 
+	var request = utils.GetRequestMetadata(c)
 	var client = db.CreateClient()
 	var conn = db.Connect(*client)
 	defer db.Disconnect(*client, conn)
@@ -130,7 +183,21 @@ func DeleteTeamHttp(c *gin.Context) (*models.Response, *models.Error) {
 		}
 	}
 
-	var error = DeleteTeam(conn, client, params)
+	team, getErr := GetTeam(conn, client, &models.Team{ID: params.ID})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	// Only owners, of the team or of a parent team, delete it
+	if TeamRoleOf(conn, client, team, request.User.ID) != models.TEAM_ROLE_OWNER {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot delete the team",
+		}
+	}
+
+	var error = DeleteTeam(conn, client, team)
 	if error != nil {
 		return nil, error
 	}
@@ -148,6 +215,7 @@ func DeleteTeamHttp(c *gin.Context) (*models.Response, *models.Error) {
 // [return] *models.Response: response | *models.Error: error
 func GetTeamHttp(c *gin.Context) (*models.Response, *models.Error) {
 
+	var request = utils.GetRequestMetadata(c)
 	var client = db.CreateClient()
 	var conn = db.Connect(*client)
 	defer db.Disconnect(*client, conn)
@@ -163,9 +231,25 @@ func GetTeamHttp(c *gin.Context) (*models.Response, *models.Error) {
 		}
 	}
 
-	team, error := GetTeam(conn, client, &params)
-	if error != nil {
-		return nil, error
+	team, getErr := GetTeam(conn, client, &params)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	// Users who are not members only see the summary of discoverable teams
+	if !CanSeeTeam(conn, client, request.User, team) {
+		if !team.IsListed() {
+			return nil, &models.Error{
+				Status:  utils.HTTP_STATUS_FORBIDDEN,
+				Error:   error.ACCESS_DENIED,
+				Message: "Access denied: Cannot see the team",
+			}
+		}
+
+		return &models.Response{
+			Code:     utils.HTTP_STATUS_OK,
+			Response: gin.H{"message": "Team found", "team": team.Summary()},
+		}, nil
 	}
 
 	return &models.Response{
@@ -174,18 +258,19 @@ func GetTeamHttp(c *gin.Context) (*models.Response, *models.Error) {
 	}, nil
 }
 
-// Add user to team HTTP API endpoint
+// Remove user from team HTTP API endpoint
 //
 // [param] c | *gin.Context: context
 //
 // [return] *models.Response: response | *models.Error: error
-func AddMemberHttp(c *gin.Context) (*models.Response, *models.Error) {
+func RemoveMemberHttp(c *gin.Context) (*models.Response, *models.Error) {
 
+	var request = utils.GetRequestMetadata(c)
 	var client = db.CreateClient()
 	var conn = db.Connect(*client)
 	defer db.Disconnect(*client, conn)
-	var params *MemberChangeRequest = &MemberChangeRequest{}
 
+	var params *MemberChangeRequest = &MemberChangeRequest{}
 	err := c.ShouldBindJSON(params)
 	if err != nil {
 		return nil, &models.Error{
@@ -195,24 +280,72 @@ func AddMemberHttp(c *gin.Context) (*models.Response, *models.Error) {
 		}
 	}
 
-	var addMemberErr = AddMember(conn, client, params)
+	team, getErr := GetTeam(conn, client, &models.Team{ID: params.Team})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if !CanRemoveMember(conn, client, request.User, team, params.User) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot remove the member",
+		}
+	}
+
+	var removeMemberErr = RemoveMember(conn, client, request.User, params)
+	if removeMemberErr != nil {
+		return nil, removeMemberErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Member removed"},
+	}, nil
+}
+
+// Leave team HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func LeaveTeamHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *MemberChangeRequest = &MemberChangeRequest{}
+	err := c.ShouldBindJSON(params)
 	if err != nil {
-		return nil, addMemberErr
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	params.User = request.User.ID
+	var leaveErr = RemoveMember(conn, client, request.User, params)
+	if leaveErr != nil {
+		return nil, leaveErr
 	}
 
 	return &models.Response{
 		Code:     utils.HTTP_STATUS_OK,
-		Response: gin.H{"message": "Member added"},
+		Response: gin.H{"message": "Team left"},
 	}, nil
 }
 
-// Remove user from team HTTP API endpoint
+// Change member role HTTP API endpoint
 //
 // [param] c | *gin.Context: context
 //
 // [return] *models.Response: response | *models.Error: error
-func RemoveMemberHttp(c *gin.Context) (*models.Response, *models.Error) {
+func SetMemberRoleHttp(c *gin.Context) (*models.Response, *models.Error) {
 
+	var request = utils.GetRequestMetadata(c)
 	var client = db.CreateClient()
 	var conn = db.Connect(*client)
 	defer db.Disconnect(*client, conn)
@@ -227,13 +360,135 @@ func RemoveMemberHttp(c *gin.Context) (*models.Response, *models.Error) {
 		}
 	}
 
-	var removeMemberErr = RemoveMember(conn, client, params)
+	team, getErr := GetTeam(conn, client, &models.Team{ID: params.Team})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if TeamRoleOf(conn, client, team, request.User.ID) != models.TEAM_ROLE_OWNER {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Only the owner can change roles",
+		}
+	}
+
+	var roleErr = SetMemberRole(conn, client, params)
+	if roleErr != nil {
+		return nil, roleErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Member role changed"},
+	}, nil
+}
+
+// Get team members HTTP API endpoint, paginated with ?cursor= and ?limit=
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetTeamMembersHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	pageRequest, err := utils.GetPageRequest(c)
 	if err != nil {
-		return nil, removeMemberErr
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_PAGE,
+			Message: "Invalid page: " + err.Error(),
+		}
+	}
+
+	team, getErr := GetTeam(conn, client, &models.Team{ID: c.Query("id")})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if !CanSeeTeam(conn, client, request.User, team) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the team members",
+		}
+	}
+
+	members, membersErr := GetTeamMembers(conn, client, team, pageRequest)
+	if membersErr != nil {
+		return nil, membersErr
 	}
 
 	return &models.Response{
 		Code:     utils.HTTP_STATUS_OK,
-		Response: gin.H{"message": "Member removed"},
+		Response: gin.H{"message": "Members found", "page": members},
+	}, nil
+}
+
+// Get my teams HTTP API endpoint, paginated with ?cursor=, ?limit= and ?sort=
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetTeamsHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	pageRequest, err := utils.GetPageRequest(c)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_PAGE,
+			Message: "Invalid page: " + err.Error(),
+		}
+	}
+
+	teams, teamsErr := GetTeams(conn, client, request.User, pageRequest)
+	if teamsErr != nil {
+		return nil, teamsErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Teams found", "page": teams},
+	}, nil
+}
+
+// Search teams HTTP API endpoint, searches ?text= paginated with ?cursor=, ?limit= and ?sort=
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func SearchTeamsHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	pageRequest, err := utils.GetPageRequest(c)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_PAGE,
+			Message: "Invalid page: " + err.Error(),
+		}
+	}
+
+	teams, searchErr := SearchTeams(conn, client, request.User, c.Query("text"), pageRequest)
+	if searchErr != nil {
+		return nil, searchErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Teams found", "page": teams},
 	}, nil
 }
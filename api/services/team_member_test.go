@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+)
+
+func TestTeamRoles(t *testing.T) {
+
+	var team = &models.Team{
+		Owner:   "owner",
+		Members: []string{"admin", "member", "other"},
+		Roles:   map[string]string{"admin": models.TEAM_ROLE_ADMIN},
+	}
+
+	if team.RoleOf("owner") != models.TEAM_ROLE_OWNER || team.RoleOf("member") != models.TEAM_ROLE_MEMBER || team.RoleOf("stranger") != "" {
+		t.Error("Unexpected team roles")
+		return
+	}
+
+	if !managesTeam(team.RoleOf("admin")) || managesTeam(team.RoleOf("member")) {
+		t.Error("Only the owner and admins can manage the team")
+		return
+	}
+
+	if !removesMember(team.RoleOf("admin"), team.RoleOf("member")) || removesMember(team.RoleOf("admin"), team.RoleOf("owner")) || removesMember(team.RoleOf("member"), team.RoleOf("other")) {
+		t.Error("Admins can only remove members")
+		return
+	}
+
+	log.Info("Team roles checked")
+}
+
+func TestPaginate(t *testing.T) {
+
+	var ids = []string{"a", "b", "c", "d", "e"}
+
+	if page := paginate(ids, 1, 2); len(page) != 2 || page[0] != "b" || page[1] != "c" {
+		t.Error("Unexpected page", page)
+		return
+	}
+
+	if page := paginate(ids, 4, 10); len(page) != 1 || page[0] != "e" {
+		t.Error("The last page must be cut", page)
+		return
+	}
+
+	if page := paginate(ids, 10, 2); len(page) != 0 {
+		t.Error("Pages out of range must be empty", page)
+		return
+	}
+
+	log.Info("Pagination checked")
+}
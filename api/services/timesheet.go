@@ -0,0 +1,394 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TimesheetRequest struct {
+	From    int64
+	To      int64
+	User    string
+	Project string
+	Group   string
+}
+
+// Start a timer on a task, a user can only have one running timer
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user tracking the time
+// [param] task | *models.Task: task to track
+//
+// [return] *models.TimeEntry: running entry --> *models.Error: error if any
+func StartTimer(conn context.Context, client *mongo.Client, user *models.User, task *models.Task) (*models.TimeEntry, *models.Error) {
+
+	entry := &models.TimeEntry{
+		User:    user.ID,
+		Task:    task.ID,
+		Project: task.Project,
+		Start:   utils.GetCurrentMillis(),
+		End:     0,
+		Manual:  false,
+	}
+
+	// Only insert if the user has no running timer, the unique
+	// index rejects the upserts racing with another one
+	coll := client.Database(db.CurrentDatabase).Collection(db.TIME_ENTRY)
+	result, err := coll.UpdateOne(conn,
+		bson.M{"user": user.ID, "end": 0},
+		bson.M{"$setOnInsert": entry},
+		options.Update().SetUpsert(true),
+	)
+
+	if (err == nil && result.MatchedCount > 0) || mongo.IsDuplicateKeyError(err) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.TIMER_ALREADY_RUNNING),
+			Message: "There is already a running timer",
+		}
+	}
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.TIME_ENTRY_NOT_CREATED),
+			Message: "Timer not started",
+		}
+	}
+
+	entry.ID = result.UpsertedID.(primitive.ObjectID).Hex()
+	return entry, nil
+}
+
+// Stop the running timer of a user
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user tracking the time
+//
+// [return] *models.TimeEntry: stopped entry --> *models.Error: error if any
+func StopTimer(conn context.Context, client *mongo.Client, user *models.User) (*models.TimeEntry, *models.Error) {
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.TIME_ENTRY)
+
+	var stopped models.TimeEntry
+	err := coll.FindOneAndUpdate(conn,
+		bson.M{"user": user.ID, "end": 0},
+		bson.M{"$set": bson.M{"end": utils.GetCurrentMillis()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&stopped)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.NO_RUNNING_TIMER),
+			Message: "There is no running timer",
+		}
+	}
+
+	return &stopped, nil
+}
+
+// Get the running timer of a user
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user tracking the time
+//
+// [return] *models.TimeEntry: running entry --> *models.Error: error if any
+func GetRunningTimer(conn context.Context, client *mongo.Client, user *models.User) (*models.TimeEntry, *models.Error) {
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.TIME_ENTRY)
+
+	var running models.TimeEntry
+	err := coll.FindOne(conn, bson.M{"user": user.ID, "end": 0}).Decode(&running)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.NO_RUNNING_TIMER),
+			Message: "There is no running timer",
+		}
+	}
+
+	return &running, nil
+}
+
+// Add a manual time entry
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user tracking the time
+// [param] task | *models.Task: task tracked
+// [param] entry | *models.TimeEntry: entry to add
+//
+// [return] *models.Error: error if any
+func AddTimeEntry(conn context.Context, client *mongo.Client, user *models.User, task *models.Task, entry *models.TimeEntry) *models.Error {
+
+	if entry.Start <= 0 || entry.End <= entry.Start || entry.End > utils.GetCurrentMillis() {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_TIME_RANGE),
+			Message: "Time entries must end after they start and cannot end in the future",
+		}
+	}
+
+	entry.User = user.ID
+	entry.Task = task.ID
+	entry.Project = task.Project
+	entry.Manual = true
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.TIME_ENTRY)
+	result, err := coll.InsertOne(conn, entry)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.TIME_ENTRY_NOT_CREATED),
+			Message: "Time entry not created",
+		}
+	}
+
+	entry.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+// Delete a time entry of the user
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: owner of the entry
+// [param] entry | *models.TimeEntry: entry to delete
+//
+// [return] *models.Error: error if any
+func DeleteTimeEntry(conn context.Context, client *mongo.Client, user *models.User, entry *models.TimeEntry) *models.Error {
+
+	objID, err := utils.StringToObjectId(entry.ID)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.BAD_OBJECT_ID),
+			Message: "Bad object id",
+		}
+	}
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.TIME_ENTRY)
+	result, err := coll.DeleteOne(conn, bson.M{"_id": objID, "user": user.ID})
+
+	if err != nil || result.DeletedCount == 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.TIME_ENTRY_NOT_FOUND),
+			Message: "Time entry not found",
+		}
+	}
+
+	return nil
+}
+
+// Get the timesheet report of the given range,
+// aggregated by user, project and day or week
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] request | *TimesheetRequest: report filters
+//
+// [return] []models.TimesheetRow: report rows --> *models.Error: error if any
+func GetTimesheet(conn context.Context, client *mongo.Client, request *TimesheetRequest) ([]models.TimesheetRow, *models.Error) {
+
+	if request.Group == "" {
+		request.Group = models.TIMESHEET_GROUP_DAY
+	}
+
+	if request.Group != models.TIMESHEET_GROUP_DAY && request.Group != models.TIMESHEET_GROUP_WEEK {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_TIMESHEET_GROUP),
+			Message: "Timesheets can only be grouped by day or week",
+		}
+	}
+
+	if request.From <= 0 || request.To <= request.From {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_TIME_RANGE),
+			Message: "The report must end after it starts",
+		}
+	}
+
+	// Entries overlapping the range, running ones included
+	filter := bson.M{
+		"start": bson.M{"$lt": request.To},
+		"$or": bson.A{
+			bson.M{"end": 0},
+			bson.M{"end": bson.M{"$gt": request.From}},
+		},
+	}
+
+	if request.User != "" {
+		filter["user"] = request.User
+	}
+
+	if request.Project != "" {
+		filter["project"] = request.Project
+	}
+
+	coll := client.Database(db.CurrentDatabase).Collection(db.TIME_ENTRY)
+	cursor, err := coll.Find(conn, filter)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get time entries",
+		}
+	}
+
+	entries := []models.TimeEntry{}
+	err = cursor.All(conn, &entries)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get time entries",
+		}
+	}
+
+	rows := aggregateTimesheet(entries, request.Group, request.From, request.To, utils.GetCurrentMillis())
+	resolveTimesheetNames(conn, client, rows)
+	return rows, nil
+}
+
+// Aggregate time entries by user, project and period. Entries are
+// clipped to the range and split when they cross a period boundary.
+//
+// [param] entries | []models.TimeEntry: entries to aggregate
+// [param] group | string: day or week
+// [param] from | int64: range start in milliseconds
+// [param] to | int64: range end in milliseconds
+// [param] now | int64: current time, end of the running entries
+//
+// [return] []models.TimesheetRow: rows sorted by period, user and project
+func aggregateTimesheet(entries []models.TimeEntry, group string, from int64, to int64, now int64) []models.TimesheetRow {
+
+	periodOf := utils.StartOfDay
+	periodLength := utils.MILLIS_PER_DAY
+
+	if group == models.TIMESHEET_GROUP_WEEK {
+		periodOf = utils.StartOfWeek
+		periodLength = 7 * utils.MILLIS_PER_DAY
+	}
+
+	type key struct {
+		user    string
+		project string
+		period  int64
+	}
+
+	totals := map[key]int64{}
+
+	for _, entry := range entries {
+		start := entry.Start
+		end := entry.End
+
+		if end == 0 {
+			end = now
+		}
+
+		if start < from {
+			start = from
+		}
+
+		if end > to {
+			end = to
+		}
+
+		for start < end {
+			period := periodOf(start)
+			periodEnd := period + periodLength
+
+			if periodEnd > end {
+				periodEnd = end
+			}
+
+			totals[key{entry.User, entry.Project, period}] += periodEnd - start
+			start = periodEnd
+		}
+	}
+
+	rows := []models.TimesheetRow{}
+	for k, duration := range totals {
+		rows = append(rows, models.TimesheetRow{
+			Period:   k.period,
+			User:     k.user,
+			Project:  k.project,
+			Duration: duration,
+			Hours:    float64(duration) / float64(60*60*1000),
+		})
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Period != rows[j].Period {
+			return rows[i].Period < rows[j].Period
+		}
+
+		if rows[i].User != rows[j].User {
+			return rows[i].User < rows[j].User
+		}
+
+		return rows[i].Project < rows[j].Project
+	})
+
+	return rows
+}
+
+// Fill the usernames and project names of the timesheet rows
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] rows | []models.TimesheetRow: rows to fill
+func resolveTimesheetNames(conn context.Context, client *mongo.Client, rows []models.TimesheetRow) {
+
+	usernames := map[string]string{}
+	projectNames := map[string]string{}
+
+	for i := range rows {
+		if _, found := usernames[rows[i].User]; !found {
+			usernames[rows[i].User] = ""
+
+			objID, err := utils.StringToObjectId(rows[i].User)
+			if err == nil {
+				var user models.User
+				users := client.Database(db.CurrentDatabase).Collection(db.USER)
+
+				if users.FindOne(conn, bson.M{"_id": objID}).Decode(&user) == nil {
+					usernames[rows[i].User] = user.Username
+				}
+			}
+		}
+
+		if _, found := projectNames[rows[i].Project]; !found {
+			projectNames[rows[i].Project] = ""
+
+			project, err := getProjectById(conn, client, rows[i].Project)
+			if err == nil {
+				projectNames[rows[i].Project] = project.Name
+			}
+		}
+
+		rows[i].Username = usernames[rows[i].User]
+		rows[i].ProjectName = projectNames[rows[i].Project]
+	}
+}
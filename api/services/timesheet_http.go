@@ -0,0 +1,292 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"time"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type TimerRequest struct {
+	Task string `json:"taskid"`
+}
+
+// Start timer HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func StartTimerHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *TimerRequest = &TimerRequest{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	task, getErr := GetTask(conn, client, &models.Task{ID: params.Task})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if !CanSeeTask(conn, client, request.User, task) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot track time on the task",
+		}
+	}
+
+	entry, startErr := StartTimer(conn, client, request.User, task)
+	if startErr != nil {
+		return nil, startErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Timer started", "entry": entry},
+	}, nil
+}
+
+// Stop timer HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func StopTimerHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	entry, stopErr := StopTimer(conn, client, request.User)
+	if stopErr != nil {
+		return nil, stopErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Timer stopped", "entry": entry},
+	}, nil
+}
+
+// Get running timer HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetRunningTimerHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	entry, getErr := GetRunningTimer(conn, client, request.User)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Timer found", "entry": entry},
+	}, nil
+}
+
+// Add manual time entry HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func AddTimeEntryHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var entry *models.TimeEntry = &models.TimeEntry{}
+	err := c.ShouldBindJSON(entry)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	task, getErr := GetTask(conn, client, &models.Task{ID: entry.Task})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if !CanSeeTask(conn, client, request.User, task) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot track time on the task",
+		}
+	}
+
+	addErr := AddTimeEntry(conn, client, request.User, task, entry)
+	if addErr != nil {
+		return nil, addErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Time entry added", "id": entry.ID},
+	}, nil
+}
+
+// Delete time entry HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func DeleteTimeEntryHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var entry *models.TimeEntry = &models.TimeEntry{}
+	err := c.ShouldBindJSON(entry)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	deleteErr := DeleteTimeEntry(conn, client, request.User, entry)
+	if deleteErr != nil {
+		return nil, deleteErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Time entry deleted"},
+	}, nil
+}
+
+// Get timesheet report HTTP API endpoint, without a project
+// only the time of the requesting user is reported.
+// Use ?format=csv to download the report as CSV.
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetTimesheetHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	from, fromErr := strconv.ParseInt(c.Query("from"), 10, 64)
+	to, toErr := strconv.ParseInt(c.Query("to"), 10, 64)
+
+	if fromErr != nil || toErr != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "From and to must be dates in milliseconds",
+		}
+	}
+
+	var params = &TimesheetRequest{
+		From:    from,
+		To:      to,
+		User:    c.Query("user"),
+		Project: c.Query("project"),
+		Group:   c.Query("group"),
+	}
+
+	if params.Project == "" {
+		params.User = request.User.ID
+	} else {
+		project, projectErr := getProjectById(conn, client, params.Project)
+		if projectErr != nil {
+			return nil, projectErr
+		}
+
+		if !CanSeeProject(conn, client, request.User, project) {
+			return nil, &models.Error{
+				Status:  utils.HTTP_STATUS_FORBIDDEN,
+				Error:   error.ACCESS_DENIED,
+				Message: "Access denied: Cannot see the project",
+			}
+		}
+	}
+
+	rows, reportErr := GetTimesheet(conn, client, params)
+	if reportErr != nil {
+		return nil, reportErr
+	}
+
+	if c.Query("format") == "csv" {
+		return nil, writeTimesheetCsv(c, rows)
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Timesheet", "rows": rows},
+	}, nil
+}
+
+// Write the timesheet rows as a CSV attachment
+//
+// [param] c | *gin.Context: context
+// [param] rows | []models.TimesheetRow: rows to write
+//
+// [return] *models.Error: error if any
+func writeTimesheetCsv(c *gin.Context, rows []models.TimesheetRow) *models.Error {
+
+	buffer := bytes.NewBuffer(nil)
+	writer := csv.NewWriter(buffer)
+	writer.Write([]string{"period", "user", "username", "project", "project_name", "hours"})
+
+	for _, row := range rows {
+		writer.Write([]string{
+			time.UnixMilli(row.Period).UTC().Format("2006-01-02"),
+			row.User,
+			row.Username,
+			row.Project,
+			row.ProjectName,
+			strconv.FormatFloat(row.Hours, 'f', 2, 64),
+		})
+	}
+
+	writer.Flush()
+
+	if writer.Error() != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   error.UNEXPECTED_ERROR,
+			Message: "Cannot write the timesheet",
+		}
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=timesheet.csv")
+	c.Data(utils.HTTP_STATUS_OK, "text/csv; charset=utf-8", buffer.Bytes())
+	return nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestTimesheetByDay(t *testing.T) {
+
+	var hour = int64(60 * 60 * 1000)
+	var monday = utils.StartOfWeek(int64(1700000000000))
+
+	var entries = []models.TimeEntry{
+		// crosses midnight: 2h on monday and 1h on tuesday
+		{User: "u1", Project: "p1", Start: monday + 22*hour, End: monday + 25*hour},
+		{User: "u1", Project: "p1", Start: monday + 9*hour, End: monday + 10*hour},
+		{User: "u2", Project: "p1", Start: monday + 9*hour, End: monday + 11*hour},
+		// running timer until now
+		{User: "u2", Project: "p2", Start: monday + 24*hour + 8*hour, End: 0},
+	}
+
+	var rows = aggregateTimesheet(entries, models.TIMESHEET_GROUP_DAY, monday, monday+7*24*hour, monday+24*hour+10*hour)
+
+	if len(rows) != 4 {
+		t.Error("Unexpected number of rows", len(rows))
+		return
+	}
+
+	if rows[0].User != "u1" || rows[0].Period != monday || rows[0].Duration != 3*hour {
+		t.Error("Unexpected first row", rows[0])
+		return
+	}
+
+	if rows[1].User != "u2" || rows[1].Duration != 2*hour {
+		t.Error("Unexpected second row", rows[1])
+		return
+	}
+
+	if rows[2].Period != monday+24*hour || rows[2].Duration != hour {
+		t.Error("The entry crossing midnight must be split", rows[2])
+		return
+	}
+
+	if rows[3].Project != "p2" || rows[3].Hours != 2 {
+		t.Error("The running timer must be counted until now", rows[3])
+		return
+	}
+
+	log.Info("Timesheet aggregated by day")
+}
+
+func TestTimesheetByWeek(t *testing.T) {
+
+	var hour = int64(60 * 60 * 1000)
+	var monday = utils.StartOfWeek(int64(1700000000000))
+
+	var entries = []models.TimeEntry{
+		{User: "u1", Project: "p1", Start: monday + 9*hour, End: monday + 10*hour},
+		{User: "u1", Project: "p1", Start: monday + 3*24*hour, End: monday + 3*24*hour + 2*hour},
+		// starts before the range
+		{User: "u1", Project: "p1", Start: monday - 2*hour, End: monday + hour},
+	}
+
+	var rows = aggregateTimesheet(entries, models.TIMESHEET_GROUP_WEEK, monday, monday+7*24*hour, monday+8*24*hour)
+
+	if len(rows) != 1 || rows[0].Period != monday || rows[0].Duration != 4*hour {
+		t.Error("Unexpected weekly rows", rows)
+		return
+	}
+
+	log.Info("Timesheet aggregated by week")
+}
+
+func TestRunningTimerIndex(t *testing.T) {
+
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var user = primitive.NewObjectID().Hex()
+	var coll = client.Database(db.CurrentDatabase).Collection(db.TIME_ENTRY)
+	defer coll.DeleteMany(conn, bson.M{"user": user})
+
+	_, err := coll.InsertOne(conn, models.TimeEntry{User: user, Start: 1000, End: 0})
+	if err != nil {
+		t.Error("The running timer was not created", err)
+		return
+	}
+
+	_, err = coll.InsertOne(conn, models.TimeEntry{User: user, Start: 2000, End: 0})
+	if !mongo.IsDuplicateKeyError(err) {
+		t.Error("A user must not have two running timers", err)
+		return
+	}
+
+	_, err = coll.InsertOne(conn, models.TimeEntry{User: user, Start: 1000, End: 2000})
+	if err != nil {
+		t.Error("Stopped entries must not be limited", err)
+		return
+	}
+
+	log.Info("Running timer index checked")
+}
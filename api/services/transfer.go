@@ -0,0 +1,469 @@
+package services
+
+import (
+	"context"
+
+	"github.com/akrck02/valhalla-core/configuration"
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Time the new owner has to accept a transfer
+const TRANSFER_EXPIRATION = 3 * utils.MILLIS_PER_DAY
+
+// Request the transfer of a team to one of its members,
+// the owner does not change until the member accepts
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] owner | *models.User: current owner of the team
+// [param] team | *models.Team: team to transfer
+// [param] to | string: id of the new owner
+//
+// [return] *models.TeamTransfer: transfer requested --> *models.Error: error if any
+func RequestTeamTransfer(conn context.Context, client *mongo.Client, owner *models.User, team *models.Team, to string) (*models.TeamTransfer, *models.Error) {
+
+	if utils.IsEmpty(to) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.NO_OWNER),
+			Message: "Team requires an owner",
+		}
+	}
+
+	if to == team.Owner {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.USER_IS_OWNER),
+			Message: "User is owner of the team",
+		}
+	}
+
+	if !isTeamMember(team, to) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.NOT_A_MEMBER),
+			Message: "User is not a member of the team",
+		}
+	}
+
+	now := utils.GetCurrentMillis()
+	transfers := client.Database(db.CurrentDatabase).Collection(db.TEAM_TRANSFER)
+
+	pending, err := transfers.CountDocuments(conn, bson.M{
+		"team":            team.ID,
+		"status":          models.TRANSFER_PENDING,
+		"expiration_date": bson.M{"$gt": now},
+	})
+
+	if err != nil || pending > 0 {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.TRANSFER_PENDING),
+			Message: "There is already a pending transfer",
+		}
+	}
+
+	transfer := &models.TeamTransfer{
+		Team:           team.ID,
+		From:           owner.ID,
+		To:             to,
+		Status:         models.TRANSFER_PENDING,
+		CreationDate:   now,
+		ExpirationDate: now + TRANSFER_EXPIRATION,
+	}
+
+	result, err := transfers.InsertOne(conn, transfer)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.TRANSFER_NOT_UPDATED),
+			Message: "Transfer not requested",
+		}
+	}
+
+	transfer.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	recordTransferAudit(conn, client, transfer, models.AUDIT_TRANSFER_REQUESTED, owner.ID)
+
+	NotifyUser(conn, client, &models.Notification{
+		User:    to,
+		Type:    models.NOTIFICATION_TRANSFER,
+		Title:   owner.Username + " wants to transfer " + team.Name + " to you",
+		Message: team.Description,
+		Source:  transfer.ID,
+	})
+
+	return transfer, nil
+}
+
+// Get team transfer logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] id | string: id of the transfer
+//
+// [return] *models.TeamTransfer: transfer found --> *models.Error: error if any
+func GetTeamTransfer(conn context.Context, client *mongo.Client, id string) (*models.TeamTransfer, *models.Error) {
+
+	objID, err := utils.StringToObjectId(id)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.BAD_OBJECT_ID),
+			Message: "Bad object id",
+		}
+	}
+
+	transfers := client.Database(db.CurrentDatabase).Collection(db.TEAM_TRANSFER)
+
+	var found models.TeamTransfer
+	err = transfers.FindOne(conn, bson.M{"_id": objID}).Decode(&found)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.TRANSFER_NOT_FOUND),
+			Message: "Transfer not found",
+		}
+	}
+
+	return &found, nil
+}
+
+// Accept or decline a transfer, accepting it makes the user the
+// owner of the team and demotes the previous owner to a member
+// with the configured role
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: new owner
+// [param] transfer | *models.TeamTransfer: transfer to answer
+// [param] accept | bool: true to accept the transfer
+//
+// [return] *models.Error: error if any
+func RespondTeamTransfer(conn context.Context, client *mongo.Client, user *models.User, transfer *models.TeamTransfer, accept bool) *models.Error {
+
+	if transfer.To != user.ID {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   int(error.NOT_TRANSFER_RECIPIENT),
+			Message: "The transfer was made to another user",
+		}
+	}
+
+	now := utils.GetCurrentMillis()
+	pendingErr := checkTransferPending(transfer, now)
+
+	if pendingErr != nil {
+		return pendingErr
+	}
+
+	if !accept {
+		updateErr := updateTransferStatus(conn, client, transfer, models.TRANSFER_DECLINED, now)
+		if updateErr != nil {
+			return updateErr
+		}
+
+		recordTransferAudit(conn, client, transfer, models.AUDIT_TRANSFER_DECLINED, user.ID)
+		return nil
+	}
+
+	team, teamErr := GetTeam(conn, client, &models.Team{ID: transfer.Team})
+	if teamErr != nil {
+		return teamErr
+	}
+
+	// Accepting the transfer first keeps two answers from both
+	// changing the owner, it is pending again if the change fails
+	updateErr := updateTransferStatus(conn, client, transfer, models.TRANSFER_ACCEPTED, now)
+	if updateErr != nil {
+		return updateErr
+	}
+
+	demotion := ownerDemotionRole()
+	objID, _ := utils.StringToObjectId(team.ID)
+	teams := client.Database(db.CurrentDatabase).Collection(db.TEAM)
+
+	// The owner must not have changed since the request and the
+	// new owner must still be a member, the previous owner joins
+	// the members without losing the ones added in between
+	result, err := teams.UpdateOne(conn, bson.M{"_id": objID, "owner": transfer.From, "members": transfer.To}, bson.M{
+		"$set": bson.M{
+			"owner":                  transfer.To,
+			"roles." + transfer.From: demotion,
+		},
+		"$unset":    bson.M{"roles." + transfer.To: ""},
+		"$addToSet": bson.M{"members": transfer.From},
+	})
+
+	if err != nil || result.MatchedCount == 0 {
+		revertTransferStatus(conn, client, transfer)
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.UPDATE_ERROR),
+			Message: "Could not change owner",
+		}
+	}
+
+	// The new owner leaves the members, a failure
+	// only leaves the owner listed as a member too
+	_, err = teams.UpdateOne(conn, bson.M{"_id": objID, "owner": transfer.To}, bson.M{
+		"$pull": bson.M{"members": transfer.To},
+	})
+
+	if err != nil {
+		log.FormattedError("Cannot remove owner ${0} from the members of ${1}: ${2}", transfer.To, team.ID, err.Error())
+	}
+
+	recordTransferAudit(conn, client, transfer, models.AUDIT_TRANSFER_ACCEPTED, user.ID)
+	return nil
+}
+
+// Cancel the pending transfers of a team to a user
+// who is no longer a member
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] author | *models.User: user removing the member
+// [param] team | *models.Team: team of the transfers
+// [param] user | string: id of the former member
+func cancelMemberTransfers(conn context.Context, client *mongo.Client, author *models.User, team *models.Team, user string) {
+
+	transfers := client.Database(db.CurrentDatabase).Collection(db.TEAM_TRANSFER)
+	found, err := transfers.Find(conn, bson.M{"team": team.ID, "to": user, "status": models.TRANSFER_PENDING})
+
+	pending := []models.TeamTransfer{}
+	if err != nil || found.All(conn, &pending) != nil {
+		log.FormattedError("Cannot get the transfers of ${0} to ${1}", team.ID, user)
+		return
+	}
+
+	now := utils.GetCurrentMillis()
+	for i := range pending {
+		if updateTransferStatus(conn, client, &pending[i], models.TRANSFER_CANCELLED, now) == nil {
+			recordTransferAudit(conn, client, &pending[i], models.AUDIT_TRANSFER_CANCELLED, author.ID)
+		}
+	}
+}
+
+// Cancel a pending transfer
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user cancelling the transfer
+// [param] transfer | *models.TeamTransfer: transfer to cancel
+//
+// [return] *models.Error: error if any
+func CancelTeamTransfer(conn context.Context, client *mongo.Client, user *models.User, transfer *models.TeamTransfer) *models.Error {
+
+	if transfer.Status != models.TRANSFER_PENDING {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.TRANSFER_NOT_PENDING),
+			Message: "Transfer was already " + transfer.Status,
+		}
+	}
+
+	updateErr := updateTransferStatus(conn, client, transfer, models.TRANSFER_CANCELLED, utils.GetCurrentMillis())
+	if updateErr != nil {
+		return updateErr
+	}
+
+	recordTransferAudit(conn, client, transfer, models.AUDIT_TRANSFER_CANCELLED, user.ID)
+	return nil
+}
+
+// Get the audit trail of a team, newest first
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] team | *models.Team: team of the audit trail
+// [param] request | *models.PageRequest: cursor and limit of the page
+//
+// [return] *models.Page: page of audit entries --> *models.Error: error if any
+func GetTeamAudit(conn context.Context, client *mongo.Client, team *models.Team, request *models.PageRequest) (*models.Page, *models.Error) {
+
+	cursor, cursorErr := decodeCursor(request.Cursor)
+	if cursorErr != nil {
+		return nil, cursorErr
+	}
+
+	auditErr := &models.Error{
+		Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+		Error:   int(error.UNEXPECTED_ERROR),
+		Message: "Cannot get the audit trail",
+	}
+
+	filter := bson.M{"team": team.ID}
+	audits := client.Database(db.CurrentDatabase).Collection(db.TEAM_AUDIT)
+	total, err := audits.CountDocuments(conn, filter)
+
+	if err != nil {
+		return nil, auditErr
+	}
+
+	if cursor != nil {
+		after, keysetErr := keysetFilter("_id", true, cursor)
+		if keysetErr != nil {
+			return nil, keysetErr
+		}
+
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	found, err := audits.Find(conn, filter, options.Find().
+		SetSort(keysetSort("_id", true)).
+		SetLimit(int64(request.Limit+1)))
+
+	entries := []models.TeamAudit{}
+	if err != nil || found.All(conn, &entries) != nil {
+		return nil, auditErr
+	}
+
+	page := &models.Page{Total: total, Limit: request.Limit}
+
+	if len(entries) > request.Limit {
+		entries = entries[:request.Limit]
+		page.NextCursor = encodeCursor(pageCursor{ID: entries[len(entries)-1].ID})
+	}
+
+	page.Items = entries
+	return page, nil
+}
+
+// Record a transfer on the audit trail of its team,
+// failures are logged as they must not undo the transfer
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] transfer | *models.TeamTransfer: transfer to record
+// [param] action | string: audit action
+// [param] author | string: id of the user doing the action
+func recordTransferAudit(conn context.Context, client *mongo.Client, transfer *models.TeamTransfer, action string, author string) {
+
+	audits := client.Database(db.CurrentDatabase).Collection(db.TEAM_AUDIT)
+	_, err := audits.InsertOne(conn, &models.TeamAudit{
+		Team:   transfer.Team,
+		Action: action,
+		Author: author,
+		Target: transfer.To,
+		Source: transfer.ID,
+		Date:   utils.GetCurrentMillis(),
+	})
+
+	if err != nil {
+		log.FormattedError("Cannot record ${0} of transfer ${1}: ${2}", action, transfer.ID, err.Error())
+	}
+}
+
+// Change the status of a transfer only if it is still pending
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] transfer | *models.TeamTransfer: transfer to update
+// [param] status | string: new status
+// [param] now | int64: current time in milliseconds
+//
+// [return] *models.Error: error if any
+func updateTransferStatus(conn context.Context, client *mongo.Client, transfer *models.TeamTransfer, status string, now int64) *models.Error {
+
+	objID, _ := utils.StringToObjectId(transfer.ID)
+	transfers := client.Database(db.CurrentDatabase).Collection(db.TEAM_TRANSFER)
+	result, err := transfers.UpdateOne(conn,
+		bson.M{"_id": objID, "status": models.TRANSFER_PENDING},
+		bson.M{"$set": bson.M{"status": status, "response_date": now}},
+	)
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.TRANSFER_NOT_UPDATED),
+			Message: "Transfer not updated",
+		}
+	}
+
+	if result.ModifiedCount == 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.TRANSFER_NOT_PENDING),
+			Message: "Transfer is not pending",
+		}
+	}
+
+	transfer.Status = status
+	transfer.ResponseDate = now
+	return nil
+}
+
+// Set an accepted transfer as pending again, for
+// owner changes that could not be done
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] transfer | *models.TeamTransfer: transfer to revert
+func revertTransferStatus(conn context.Context, client *mongo.Client, transfer *models.TeamTransfer) {
+
+	objID, _ := utils.StringToObjectId(transfer.ID)
+	transfers := client.Database(db.CurrentDatabase).Collection(db.TEAM_TRANSFER)
+	_, err := transfers.UpdateOne(conn,
+		bson.M{"_id": objID, "status": models.TRANSFER_ACCEPTED, "response_date": transfer.ResponseDate},
+		bson.M{"$set": bson.M{"status": models.TRANSFER_PENDING}, "$unset": bson.M{"response_date": ""}},
+	)
+
+	if err != nil {
+		log.FormattedError("Cannot set transfer ${0} as pending again: ${1}", transfer.ID, err.Error())
+		return
+	}
+
+	transfer.Status = models.TRANSFER_PENDING
+	transfer.ResponseDate = 0
+}
+
+// Check that a transfer can still be answered
+//
+// [param] transfer | *models.TeamTransfer: transfer to check
+// [param] now | int64: current time in milliseconds
+//
+// [return] *models.Error: error if the transfer is not pending
+func checkTransferPending(transfer *models.TeamTransfer, now int64) *models.Error {
+
+	if transfer.Status != models.TRANSFER_PENDING {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.TRANSFER_NOT_PENDING),
+			Message: "Transfer was already " + transfer.Status,
+		}
+	}
+
+	if transfer.ExpirationDate <= now {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_GONE,
+			Error:   int(error.TRANSFER_EXPIRED),
+			Message: "Transfer expired",
+		}
+	}
+
+	return nil
+}
+
+// Get the role of previous owners, admin unless
+// configured as member with TEAM_OWNER_DEMOTION_ROLE
+//
+// [return] string: role of the previous owner
+func ownerDemotionRole() string {
+
+	if configuration.Params.TeamOwnerDemotion == models.TEAM_ROLE_MEMBER {
+		return models.TEAM_ROLE_MEMBER
+	}
+
+	return models.TEAM_ROLE_ADMIN
+}
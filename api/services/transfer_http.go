@@ -0,0 +1,239 @@
+package services
+
+import (
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type TransferRequest struct {
+	ID   string `json:"id"`
+	Team string `json:"teamid"`
+	User string `json:"userid"`
+}
+
+// Request team transfer HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func RequestTeamTransferHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *TransferRequest = &TransferRequest{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	team, getErr := GetTeam(conn, client, &models.Team{ID: params.Team})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if team.RoleOf(request.User.ID) != models.TEAM_ROLE_OWNER {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Only the owner can transfer the team",
+		}
+	}
+
+	transfer, transferErr := RequestTeamTransfer(conn, client, request.User, team, params.User)
+	if transferErr != nil {
+		return nil, transferErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Transfer requested", "id": transfer.ID, "expiration_date": transfer.ExpirationDate},
+	}, nil
+}
+
+// Get team transfer HTTP API endpoint, by ?id=
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetTeamTransferHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	transfer, getErr := GetTeamTransfer(conn, client, c.Query("id"))
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if transfer.From != request.User.ID && transfer.To != request.User.ID {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the transfer",
+		}
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Transfer found", "transfer": transfer},
+	}, nil
+}
+
+// Accept team transfer HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func AcceptTeamTransferHttp(c *gin.Context) (*models.Response, *models.Error) {
+	return respondTeamTransferHttp(c, true)
+}
+
+// Decline team transfer HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func DeclineTeamTransferHttp(c *gin.Context) (*models.Response, *models.Error) {
+	return respondTeamTransferHttp(c, false)
+}
+
+func respondTeamTransferHttp(c *gin.Context, accept bool) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *TransferRequest = &TransferRequest{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	transfer, getErr := GetTeamTransfer(conn, client, params.ID)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	respondErr := RespondTeamTransfer(conn, client, request.User, transfer, accept)
+	if respondErr != nil {
+		return nil, respondErr
+	}
+
+	message := "Transfer declined"
+	if accept {
+		message = "Transfer accepted"
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": message, "team": transfer.Team},
+	}, nil
+}
+
+// Cancel team transfer HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func CancelTeamTransferHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *TransferRequest = &TransferRequest{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	transfer, getErr := GetTeamTransfer(conn, client, params.ID)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if transfer.From != request.User.ID {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Only the owner can cancel the transfer",
+		}
+	}
+
+	cancelErr := CancelTeamTransfer(conn, client, request.User, transfer)
+	if cancelErr != nil {
+		return nil, cancelErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Transfer cancelled"},
+	}, nil
+}
+
+// Get team audit trail HTTP API endpoint, by ?id= paginated with ?cursor= and ?limit=
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetTeamAuditHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	pageRequest, err := utils.GetPageRequest(c)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_PAGE,
+			Message: "Invalid page: " + err.Error(),
+		}
+	}
+
+	team, getErr := GetTeam(conn, client, &models.Team{ID: c.Query("id")})
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if !CanManageTeam(conn, client, request.User, team) {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot see the team audit trail",
+		}
+	}
+
+	audit, auditErr := GetTeamAudit(conn, client, team, pageRequest)
+	if auditErr != nil {
+		return nil, auditErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Audit trail found", "page": audit},
+	}, nil
+}
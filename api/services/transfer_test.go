@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/akrck02/valhalla-core/configuration"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+)
+
+func TestTransferPending(t *testing.T) {
+
+	var now = utils.GetCurrentMillis()
+	var transfer = &models.TeamTransfer{
+		Status:         models.TRANSFER_PENDING,
+		ExpirationDate: now + TRANSFER_EXPIRATION,
+	}
+
+	if err := checkTransferPending(transfer, now); err != nil {
+		t.Error("The transfer must be pending", err)
+		return
+	}
+
+	if err := checkTransferPending(transfer, transfer.ExpirationDate); err == nil || err.Error != error.TRANSFER_EXPIRED {
+		t.Error("The transfer must expire")
+		return
+	}
+
+	transfer.Status = models.TRANSFER_CANCELLED
+	if err := checkTransferPending(transfer, now); err == nil || err.Error != error.TRANSFER_NOT_PENDING {
+		t.Error("Cancelled transfers cannot be answered")
+		return
+	}
+
+	log.Info("Transfer expiration checked")
+}
+
+func TestOwnerDemotionRole(t *testing.T) {
+
+	var previous = configuration.Params.TeamOwnerDemotion
+	defer func() { configuration.Params.TeamOwnerDemotion = previous }()
+
+	configuration.Params.TeamOwnerDemotion = "owner"
+	if ownerDemotionRole() != models.TEAM_ROLE_ADMIN {
+		t.Error("Previous owners must be admins by default")
+		return
+	}
+
+	configuration.Params.TeamOwnerDemotion = models.TEAM_ROLE_MEMBER
+	if ownerDemotionRole() != models.TEAM_ROLE_MEMBER {
+		t.Error("Previous owners must have the configured role")
+		return
+	}
+
+	log.Info("Owner demotion role checked")
+}
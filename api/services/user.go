@@ -5,10 +5,12 @@ import (
 
 	"github.com/akrck02/valhalla-core/db"
 	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/lang"
 	"github.com/akrck02/valhalla-core/log"
 	"github.com/akrck02/valhalla-core/models"
 	"github.com/akrck02/valhalla-core/utils"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -25,6 +27,19 @@ type EmailChangeRequest struct {
 //
 // [return] *models.Error: error if any
 func Register(conn context.Context, client *mongo.Client, user *models.User) *models.Error {
+	return register(conn, client, user, lang.DEFAULT_LOCALE)
+}
+
+// Register a user keeping the locale of the registration
+// as preference, so every email gets to the user in it
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user to register
+// [param] locale | string: locale of the request
+//
+// [return] *models.Error: error if any
+func register(conn context.Context, client *mongo.Client, user *models.User, locale string) *models.Error {
 
 	if utils.IsEmpty(user.Email) {
 		return &models.Error{
@@ -82,22 +97,13 @@ func Register(conn context.Context, client *mongo.Client, user *models.User) *mo
 		}
 	}
 
-	code, err := utils.GenerateValidationCode(user.Email)
-
-	if err != nil {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
-			Error:   int(error.CANNOT_CREATE_VALIDATION_CODE),
-			Message: "User not created",
-		}
-	}
-
 	userToInsert := user.Clone()
 	userToInsert.Password = utils.EncryptSha256(user.Clone().Password)
-	userToInsert.ValidationCode = code
+	userToInsert.Validated = false
+	userToInsert.ValidationCode = ""
 
 	// register user on database
-	_, err = coll.InsertOne(conn, userToInsert)
+	result, err := coll.InsertOne(conn, userToInsert)
 
 	if err != nil {
 		return &models.Error{
@@ -107,6 +113,20 @@ func Register(conn context.Context, client *mongo.Client, user *models.User) *mo
 		}
 	}
 
+	userToInsert.ID = result.InsertedID.(primitive.ObjectID).Hex()
+
+	if locale != lang.DEFAULT_LOCALE {
+		_, localeErr := UpdatePreferences(conn, client, userToInsert, &models.PreferencesPatch{Locale: &locale})
+		if localeErr != nil {
+			log.FormattedError("Cannot keep locale ${0} of ${1}: ${2}", locale, userToInsert.Email, localeErr.Message)
+		}
+	}
+
+	_, codeErr := issueValidationCode(conn, client, userToInsert, false)
+	if codeErr != nil {
+		return codeErr
+	}
+
 	return nil
 }
 
@@ -197,10 +217,6 @@ func EditUser(conn context.Context, client *mongo.Client, user *models.User) *mo
 		log.Info("encrypted password: " + encryptedPass)
 	}
 
-	if user.ProfilePic != "" {
-		toUpdate["$set"].(bson.M)["profilePic"] = user.ProfilePic
-	}
-
 	// update user on database
 	res, err := users.UpdateOne(conn, bson.M{"email": user.Email}, toUpdate)
 
@@ -223,113 +239,17 @@ func EditUser(conn context.Context, client *mongo.Client, user *models.User) *mo
 	return nil
 }
 
-// Change email logic
+// Change email logic, the email changes once the new
+// address is confirmed with the code sent to it
 //
 // [param] conn | context.Context: connection to the database
 // [param] client | *mongo.Client: client to the database
-// [param] user | models.User: user to change email
+// [param] mail | *EmailChangeRequest: current and new email
 //
 // [return] *models.Error: error if any
 func EditUserEmail(conn context.Context, client *mongo.Client, mail *EmailChangeRequest) *models.Error {
-
-	if utils.IsEmpty(mail.Email) || utils.IsEmpty(mail.NewEmail) {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_BAD_REQUEST,
-			Error:   int(error.EMPTY_EMAIL),
-			Message: "Email cannot be empty",
-		}
-	}
-
-	// Equal emails
-	if mail.Email == mail.NewEmail {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_BAD_REQUEST,
-			Error:   int(error.EMAILS_EQUAL),
-			Message: "The new email is the same as the old one",
-		}
-	}
-
-	// validate email
-	var checkedPass = utils.ValidateEmail(mail.Email)
-	if checkedPass.Response != 200 {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_BAD_REQUEST,
-			Error:   int(checkedPass.Response),
-			Message: checkedPass.Message,
-		}
-	}
-
-	// Check if user exists
-	users := client.Database(db.CurrentDatabase).Collection(db.USER)
-	found := mailExists(mail.NewEmail, conn, users)
-
-	if found != nil {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_CONFLICT,
-			Error:   int(error.USER_ALREADY_EXISTS),
-			Message: "That email is already in use",
-		}
-	}
-
-	// update user on database
-	var checkedEmail = utils.ValidateEmail(mail.NewEmail)
-	if checkedEmail.Response != 200 {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_BAD_REQUEST,
-			Error:   int(checkedEmail.Response),
-			Message: checkedEmail.Message,
-		}
-
-	}
-
-	updateStatus, err := users.UpdateOne(conn, bson.M{"email": mail.Email}, bson.M{"$set": bson.M{"email": mail.NewEmail}})
-
-	if err != nil {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
-			Error:   int(error.USER_NOT_UPDATED),
-			Message: "User not updated" + err.Error(),
-		}
-	}
-
-	if updateStatus.MatchedCount == 0 {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_NOT_FOUND,
-			Error:   int(error.USER_NOT_FOUND),
-			Message: "User not found",
-		}
-	}
-
-	if updateStatus.ModifiedCount == 0 {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
-			Error:   int(error.USER_NOT_UPDATED),
-			Message: "User not updated",
-		}
-	}
-
-	// update user devices on database
-	devices := client.Database(db.CurrentDatabase).Collection(db.DEVICE)
-
-	updateStatus, err = devices.UpdateMany(conn, bson.M{"user": mail.Email}, bson.M{"$set": bson.M{"user": mail.NewEmail}})
-
-	if err != nil {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
-			Error:   int(error.USER_NOT_UPDATED),
-			Message: "User devices not updated",
-		}
-	}
-
-	if updateStatus.MatchedCount != 0 && updateStatus.ModifiedCount == 0 {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
-			Error:   int(error.USER_NOT_UPDATED),
-			Message: "User devices not updated",
-		}
-	}
-
-	return nil
+	_, requestErr := requestEmailChange(conn, client, mail)
+	return requestErr
 }
 
 // Change profile picture logic
@@ -350,35 +270,23 @@ func EditUserProfilePicture(conn context.Context, client *mongo.Client, user *mo
 		}
 	}
 
-	var profilePathDir = utils.GetProfilePicturePath("")
-
-	if !utils.ExistsDir(profilePathDir) {
-		err := utils.CreateDir(profilePathDir)
-
-		if err != nil {
-			return &models.Error{
-				Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
-				Error:   int(error.USER_NOT_UPDATED),
-				Message: "User not updated, image not saved :" + err.Error(),
-			}
-		}
+	found, getErr := GetUser(conn, client, user, true)
+	if getErr != nil {
+		return getErr
 	}
 
-	var profilePicPath = utils.GetProfilePicturePath(user.Email)
-	err := utils.SaveFile(profilePicPath, picture)
-
-	if err != nil {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
-			Error:   int(error.USER_NOT_UPDATED),
-			Message: "User not updated, image not saved :" + err.Error(),
-		}
+	media, saveErr := savePictureMedia(conn, client, models.MEDIA_OF_USER, found.ID, picture)
+	if saveErr != nil {
+		return saveErr
 	}
 
-	user.ProfilePic = profilePicPath
-	editErr := EditUser(conn, client, user)
+	users := client.Database(db.CurrentDatabase).Collection(db.USER)
+	_, err := users.UpdateOne(conn, bson.M{"email": found.Email}, bson.M{"$set": bson.M{
+		"profile_pic": media.ID,
+	}})
 
-	if editErr != nil {
+	if err != nil {
+		deleteMedia(conn, client, media.ID, models.MEDIA_OF_USER, found.ID)
 		return &models.Error{
 			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
 			Error:   int(error.USER_NOT_UPDATED),
@@ -386,10 +294,13 @@ func EditUserProfilePicture(conn context.Context, client *mongo.Client, user *mo
 		}
 	}
 
+	user.ProfilePic = media.ID
+	deleteMedia(conn, client, found.ProfilePic, models.MEDIA_OF_USER, found.ID)
 	return nil
 }
 
-// Delete user logic
+// Delete user logic, owned teams are handed over and the
+// account is replaced by an anonymous tombstone
 //
 // [param] conn | context.Context: connection to the database
 // [param] client | *mongo.Client: client to the database
@@ -406,33 +317,18 @@ func DeleteUser(conn context.Context, client *mongo.Client, user *models.User) *
 		}
 	}
 
-	// delete user devices
-	devices := client.Database(db.CurrentDatabase).Collection(db.DEVICE)
-	_, err := devices.DeleteMany(conn, bson.M{"user": user.Email})
-
-	if err != nil {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
-			Error:   int(error.USER_NOT_DELETED),
-			Message: "User not deleted",
-		}
+	notDeleted := &models.Error{
+		Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+		Error:   int(error.USER_NOT_DELETED),
+		Message: "User not deleted",
 	}
 
-	// delete user on database
 	users := client.Database(db.CurrentDatabase).Collection(db.USER)
 
-	var deleteResult *mongo.DeleteResult
-	deleteResult, err = users.DeleteOne(conn, bson.M{"email": user.Email})
+	var found models.User
+	err := users.FindOne(conn, bson.M{"email": user.Email}).Decode(&found)
 
 	if err != nil {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
-			Error:   int(error.USER_NOT_DELETED),
-			Message: "User not deleted",
-		}
-	}
-
-	if deleteResult.DeletedCount == 0 {
 		return &models.Error{
 			Status:  utils.HTTP_STATUS_NOT_FOUND,
 			Error:   int(error.USER_NOT_FOUND),
@@ -440,10 +336,61 @@ func DeleteUser(conn context.Context, client *mongo.Client, user *models.User) *
 		}
 	}
 
+	handOverErr := handOverTeams(conn, client, found.ID)
+	if handOverErr != nil {
+		return handOverErr
+	}
+
+	// delete the data that only makes sense for the user
+	database := client.Database(db.CurrentDatabase)
+	owned := []struct {
+		collection string
+		filter     bson.M
+	}{
+		{db.DEVICE, bson.M{"user": found.Email}},
+		{db.PREFERENCES, bson.M{"user": found.ID}},
+		{db.NOTIFICATION, bson.M{"user": found.ID}},
+		{db.INVITATION, bson.M{"$or": bson.A{bson.M{"user": found.ID}, bson.M{"email": found.Email}}}},
+		{db.TEAM_JOIN_REQUEST, bson.M{"user": found.ID}},
+	}
+
+	for _, data := range owned {
+		_, err = database.Collection(data.collection).DeleteMany(conn, data.filter)
+
+		if err != nil {
+			return notDeleted
+		}
+	}
+
+	exports := []models.DataExport{}
+	cursor, err := database.Collection(db.DATA_EXPORT).Find(conn, bson.M{"user": found.ID})
+
+	if err != nil || cursor.All(conn, &exports) != nil {
+		return notDeleted
+	}
+
+	for i := range exports {
+		expireDataExport(conn, client, &exports[i])
+	}
+
+	deleteMedia(conn, client, found.ProfilePic, models.MEDIA_OF_USER, found.ID)
+
+	// Authored tasks and comments keep pointing to an
+	// anonymous user instead of the deleted account
+	objID, _ := utils.StringToObjectId(found.ID)
+	_, err = users.ReplaceOne(conn, bson.M{"_id": objID}, models.User{
+		Username: DELETED_USERNAME,
+		Deleted:  true,
+	})
+
+	if err != nil {
+		return notDeleted
+	}
+
 	return nil
 }
 
-// Get user logic
+// Get user logic, secure gets leave the password out
 func GetUser(conn context.Context, client *mongo.Client, user *models.User, secure bool) (*models.User, *models.Error) { // get user from database
 
 	users := client.Database(db.CurrentDatabase).Collection(db.USER)
@@ -459,9 +406,44 @@ func GetUser(conn context.Context, client *mongo.Client, user *models.User, secu
 	}
 
 	if secure {
-		found.Password = "****************"
+		found.Password = ""
+	}
+
+	return &found, nil
+}
+
+// Get user by id logic
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] id | string: id of the user
+//
+// [return] *models.User: user found, without password --> *models.Error: error if any
+func GetUserById(conn context.Context, client *mongo.Client, id string) (*models.User, *models.Error) {
+
+	objID, err := utils.StringToObjectId(id)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.BAD_OBJECT_ID),
+			Message: "Bad object id",
+		}
 	}
 
+	users := client.Database(db.CurrentDatabase).Collection(db.USER)
+	var found models.User
+	err = users.FindOne(conn, bson.M{"_id": objID}).Decode(&found)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.USER_NOT_FOUND),
+			Message: "User not found",
+		}
+	}
+
+	found.Password = ""
 	return &found, nil
 }
 
@@ -469,10 +451,11 @@ func GetUser(conn context.Context, client *mongo.Client, user *models.User, secu
 //
 // [param] conn | context.Context: connection to the database
 // [param] client | *mongo.Client: client to the database
-// [param] code | string: code to validate
+// [param] email | string: email of the user
+// [param] code | string: validation code sent to the user
 //
 // [return] *models.Error: error if any
-func ValidateUser(conn context.Context, client *mongo.Client, code string) *models.Error {
+func ValidateUser(conn context.Context, client *mongo.Client, email string, code string) *models.Error {
 
 	if utils.IsEmpty(code) {
 		return &models.Error{
@@ -482,15 +465,19 @@ func ValidateUser(conn context.Context, client *mongo.Client, code string) *mode
 		}
 	}
 
-	var user = &models.User{
-		ValidationCode: code,
+	if utils.IsEmpty(email) {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.EMPTY_EMAIL),
+			Message: "Email cannot be empty",
+		}
 	}
-	coll := client.Database(db.CurrentDatabase).Collection(db.USER)
-	err := coll.FindOne(conn, user).Decode(user)
 
-	log.FormattedInfo("user: ${0}", user.Email)
-	log.FormattedInfo("code: ${0}", code)
+	var user models.User
+	coll := client.Database(db.CurrentDatabase).Collection(db.USER)
+	err := coll.FindOne(conn, bson.M{"email": email}).Decode(&user)
 
+	// Unknown emails look like wrong codes
 	if err != nil {
 		return &models.Error{
 			Status:  utils.HTTP_STATUS_BAD_REQUEST,
@@ -501,33 +488,23 @@ func ValidateUser(conn context.Context, client *mongo.Client, code string) *mode
 
 	if user.Validated {
 		return &models.Error{
-			Status:  utils.HTTP_STATUS_OK,
+			Status:  utils.HTTP_STATUS_CONFLICT,
 			Error:   int(error.USER_ALREADY_VALIDATED),
 			Message: "User already validated",
 		}
 	}
 
-	if user.ValidationCode != code {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_BAD_REQUEST,
-			Error:   int(error.INVALID_VALIDATION_CODE),
-			Message: "Invalid validation code",
-		}
+	checkErr := checkValidationCode(&user, code, utils.GetCurrentMillis())
+	if checkErr != nil {
+		return checkErr
 	}
 
-	user.ValidationCode = ""
-	user.Validated = true
-
 	// update user on database
-	result, editerr := coll.UpdateOne(conn, bson.M{"email": user.Email}, bson.M{"$set": bson.M{"validation_code": "", "validated": true}})
-
-	if result.MatchedCount == 0 {
-		return &models.Error{
-			Status:  utils.HTTP_STATUS_NOT_FOUND,
-			Error:   int(error.USER_NOT_FOUND),
-			Message: "User not found",
-		}
-	}
+	objID, _ := utils.StringToObjectId(user.ID)
+	result, editerr := coll.UpdateOne(conn, bson.M{"_id": objID, "validated": false}, bson.M{
+		"$set":   bson.M{"validated": true},
+		"$unset": bson.M{"validation_code": "", "validation_sent": ""},
+	})
 
 	if editerr != nil {
 		return &models.Error{
@@ -537,6 +514,14 @@ func ValidateUser(conn context.Context, client *mongo.Client, code string) *mode
 		}
 	}
 
+	if result.MatchedCount == 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.USER_ALREADY_VALIDATED),
+			Message: "User already validated",
+		}
+	}
+
 	return nil
 }
 
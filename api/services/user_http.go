@@ -3,7 +3,6 @@ package services
 import (
 	"github.com/akrck02/valhalla-core/db"
 	"github.com/akrck02/valhalla-core/error"
-	"github.com/akrck02/valhalla-core/log"
 	"github.com/akrck02/valhalla-core/models"
 	"github.com/akrck02/valhalla-core/utils"
 	"github.com/gin-gonic/gin"
@@ -14,6 +13,7 @@ import (
 // [param] c | *gin.Context: context
 func RegisterHttp(c *gin.Context) (*models.Response, *models.Error) {
 
+	var request = utils.GetRequestMetadata(c)
 	var client = db.CreateClient()
 	var conn = db.Connect(*client)
 	defer db.Disconnect(*client, conn)
@@ -28,7 +28,7 @@ func RegisterHttp(c *gin.Context) (*models.Response, *models.Error) {
 		}
 	}
 
-	var error = Register(conn, client, user)
+	var error = register(conn, client, user, request.Locale)
 	if error != nil {
 		return nil, error
 	}
@@ -118,7 +118,8 @@ func EditUserHttp(c *gin.Context) (*models.Response, *models.Error) {
 	}, nil
 }
 
-// Change email HTTP API endpoint
+// Change email HTTP API endpoint, the change
+// is applied once the new address is confirmed
 //
 // [param] c | *gin.Context: context
 func EditUserEmailHttp(c *gin.Context) (*models.Response, *models.Error) {
@@ -155,11 +156,47 @@ func EditUserEmailHttp(c *gin.Context) (*models.Response, *models.Error) {
 
 	return &models.Response{
 		Code:     utils.HTTP_STATUS_OK,
-		Response: gin.H{"message": "Email changed"},
+		Response: gin.H{"message": "Email change requested, check the new email to confirm it"},
 	}, nil
 }
 
-// Delete user HTTP API endpoint
+// Confirm email change HTTP API endpoint, responds
+// with the new token of the device
+//
+// [param] c | *gin.Context: context
+func ConfirmEmailChangeHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params struct {
+		Code string `json:"code"`
+	}
+
+	err := c.ShouldBindJSON(&params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request",
+		}
+	}
+
+	token, confirmErr := ConfirmEmailChange(conn, client, request.User, params.Code, request.Authorization)
+	if confirmErr != nil {
+		return nil, confirmErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Email changed", "auth": token},
+	}, nil
+}
+
+// Delete user HTTP API endpoint, the account is
+// deleted once the grace period is over
 //
 // [param] c | *gin.Context: context
 func DeleteUserHttp(c *gin.Context) (*models.Response, *models.Error) {
@@ -188,14 +225,14 @@ func DeleteUserHttp(c *gin.Context) (*models.Response, *models.Error) {
 		}
 	}
 
-	deleteErr := DeleteUser(conn, client, user)
+	deletionDate, deleteErr := ScheduleUserDeletion(conn, client, request.User)
 	if deleteErr != nil {
 		return nil, deleteErr
 	}
 
 	return &models.Response{
 		Code:     utils.HTTP_STATUS_OK,
-		Response: gin.H{"message": "User deleted"},
+		Response: gin.H{"message": "User deletion scheduled", "deletion_date": deletionDate},
 	}, nil
 }
 
@@ -303,11 +340,7 @@ func ValidateUserHttp(c *gin.Context) (*models.Response, *models.Error) {
 	var conn = db.Connect(*client)
 	defer db.Disconnect(*client, conn)
 
-	// Get code from url GET parameter
-	code := c.Query("code")
-	log.Info("Query code: " + code)
-
-	var error = ValidateUser(conn, client, code)
+	var error = ValidateUser(conn, client, c.Query("email"), c.Query("code"))
 	if error != nil {
 		return nil, error
 	}
@@ -318,4 +351,25 @@ func ValidateUserHttp(c *gin.Context) (*models.Response, *models.Error) {
 	}, nil
 }
 
+// Resend validation code HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+func ResendValidationCodeHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	resendErr := ResendValidationCode(conn, client, request.User)
+	if resendErr != nil {
+		return nil, resendErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Validation code sent"},
+	}, nil
+}
+
 // Get
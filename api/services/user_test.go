@@ -700,13 +700,36 @@ func TestEditUserEmail(t *testing.T) {
 		NewEmail: newEmail,
 	}
 
-	err = EditUserEmail(conn, client, &emailChangeRequest)
+	code, err := requestEmailChange(conn, client, &emailChangeRequest)
+
+	if err != nil {
+		t.Error("The user email change was not requested", err)
+		return
+	}
+
+	log.Info("User email change requested")
+
+	// The email only changes once confirmed
+	found, err := GetUser(conn, client, user, true)
+
+	if err != nil || found.Email != email {
+		t.Error("The user email changed before the confirmation")
+		return
+	}
+
+	var newToken string
+	newToken, err = ConfirmEmailChange(conn, client, found, code, token)
 
 	if err != nil {
 		t.Error("The user email was not changed", err)
 		return
 	}
 
+	if newToken == "" || newToken == token {
+		t.Error("The device token was not reissued")
+		return
+	}
+
 	log.Info("User email changed")
 
 	user.Email = newEmail
@@ -1502,22 +1525,29 @@ func TestValidationCode(t *testing.T) {
 		return
 	}
 
-	// get the user
-	user, err = GetUser(conn, client, user, true)
+	// get a new code, only its hash is stored
+	code, err := issueValidationCode(conn, client, user, false)
 
 	if err != nil {
-		t.Error("The user was not found", err)
+		t.Error("The validation code was not created", err)
 		return
 	}
 
 	// validate the user
-	err = ValidateUser(conn, client, user.ValidationCode)
+	err = ValidateUser(conn, client, user.Email, code)
 
 	if err != nil {
 		t.Error("The user was not validated", err)
 		return
 	}
 
+	err = ValidateUser(conn, client, user.Email, code)
+
+	if err == nil || err.Status != utils.HTTP_STATUS_CONFLICT || err.Error != error.USER_ALREADY_VALIDATED {
+		t.Error("The user was validated twice")
+		return
+	}
+
 	// delete the user
 	err = DeleteUser(conn, client, user)
 
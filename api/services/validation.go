@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/url"
+
+	"github.com/akrck02/valhalla-core/configuration"
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/mail"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Time a validation code can be used
+const VALIDATION_CODE_EXPIRATION = 2 * utils.MILLIS_PER_DAY
+
+// Time to wait before sending another validation code
+const VALIDATION_RESEND_INTERVAL = 5 * 60 * 1000
+
+// Send a new validation code to a user, the previous one stops
+// working. Only the hash of the code is stored
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user to validate
+// [param] limited | bool: true to only send it if the last one is old enough
+//
+// [return] string: code sent --> *models.Error: error if any
+func issueValidationCode(conn context.Context, client *mongo.Client, user *models.User, limited bool) (string, *models.Error) {
+
+	code, err := utils.GenerateValidationCode(user.Email)
+
+	if err != nil {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.CANNOT_CREATE_VALIDATION_CODE),
+			Message: "Cannot create the validation code",
+		}
+	}
+
+	now := utils.GetCurrentMillis()
+	filter := bson.M{"email": user.Email, "validated": false}
+
+	// Checked on the update so concurrent requests cannot both send a code
+	if limited {
+		filter["validation_sent"] = bson.M{"$lte": now - VALIDATION_RESEND_INTERVAL}
+	}
+
+	users := client.Database(db.CurrentDatabase).Collection(db.USER)
+	result, err := users.UpdateOne(conn, filter, bson.M{"$set": bson.M{
+		"validation_code": utils.EncryptSha256(code),
+		"validation_sent": now,
+	}})
+
+	if err == nil && result.MatchedCount == 0 && limited {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_TOO_MANY_REQUESTS,
+			Error:   int(error.VALIDATION_RESEND_LIMIT),
+			Message: "Wait a few minutes before asking for another code",
+		}
+	}
+
+	if err != nil || result.MatchedCount == 0 {
+		return "", &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.CANNOT_CREATE_VALIDATION_CODE),
+			Message: "Cannot create the validation code",
+		}
+	}
+
+	user.ValidationSent = now
+
+	query := url.Values{"email": {user.Email}, "code": {code}}
+	sendEmail(conn, client, userLocale(conn, client, user), user.Email, mail.TEMPLATE_VALIDATION, map[string]interface{}{
+		"Username": user.Username,
+		"Link":     publicLink("user/validate", query),
+	})
+
+	return code, nil
+}
+
+// Send the validation code again, at most once
+// every few minutes
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user to validate
+//
+// [return] *models.Error: error if any
+func ResendValidationCode(conn context.Context, client *mongo.Client, user *models.User) *models.Error {
+
+	if user.Validated {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.USER_ALREADY_VALIDATED),
+			Message: "User already validated",
+		}
+	}
+
+	_, issueErr := issueValidationCode(conn, client, user, true)
+	return issueErr
+}
+
+// Check a validation code against the hash stored for a user
+//
+// [param] user | *models.User: user to validate
+// [param] code | string: code sent to the user
+// [param] now | int64: current time in milliseconds
+//
+// [return] *models.Error: error if the code cannot validate the user
+func checkValidationCode(user *models.User, code string, now int64) *models.Error {
+
+	hashed := utils.EncryptSha256(code)
+	if subtle.ConstantTimeCompare([]byte(hashed), []byte(user.ValidationCode)) != 1 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.INVALID_VALIDATION_CODE),
+			Message: "Invalid validation code",
+		}
+	}
+
+	if user.ValidationSent+VALIDATION_CODE_EXPIRATION <= now {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_GONE,
+			Error:   int(error.VALIDATION_CODE_EXPIRED),
+			Message: "Validation code expired, ask for a new one",
+		}
+	}
+
+	return nil
+}
+
+// Get if a user is restricted for not validating the email,
+// only when the restriction is configured
+//
+// [param] user | *models.User: user to check
+//
+// [return] *models.Error: error if the user is restricted
+func checkUserValidated(user *models.User) *models.Error {
+
+	if !configuration.Params.RestrictUnvalidated || user.Validated {
+		return nil
+	}
+
+	return &models.Error{
+		Status:  utils.HTTP_STATUS_FORBIDDEN,
+		Error:   int(error.USER_NOT_VALIDATED),
+		Message: "Validate your email first",
+	}
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/akrck02/valhalla-core/configuration"
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/mock"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+)
+
+func TestValidationCodeCheck(t *testing.T) {
+
+	user := &models.User{
+		ValidationCode: utils.EncryptSha256("code"),
+		ValidationSent: 1000,
+	}
+
+	if err := checkValidationCode(user, "code", 1000+VALIDATION_CODE_EXPIRATION-1); err != nil {
+		t.Error("The code must validate the user", err)
+		return
+	}
+
+	if err := checkValidationCode(user, user.ValidationCode, 1000); err == nil || err.Error != error.INVALID_VALIDATION_CODE {
+		t.Error("The stored hash must not validate the user")
+		return
+	}
+
+	if err := checkValidationCode(user, "code", 1000+VALIDATION_CODE_EXPIRATION); err == nil || err.Error != error.VALIDATION_CODE_EXPIRED {
+		t.Error("Expired codes must not validate the user")
+		return
+	}
+
+	log.Info("Validation codes checked")
+}
+
+func TestValidationResendLimit(t *testing.T) {
+
+	err := ResendValidationCode(nil, nil, &models.User{Validated: true})
+	if err == nil || err.Error != error.USER_ALREADY_VALIDATED {
+		t.Error("Validated users must not get codes")
+		return
+	}
+
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var user = &models.User{
+		Username: mock.Username(),
+		Email:    mock.Email(),
+		Password: mock.Password(),
+	}
+
+	err = Register(conn, client, user)
+	if err != nil {
+		t.Error("The user was not registered", err)
+		return
+	}
+
+	defer DeleteUser(conn, client, user)
+
+	// The limit is checked on the stored user, not on a stale copy
+	err = ResendValidationCode(conn, client, &models.User{Email: user.Email})
+	if err == nil || err.Status != utils.HTTP_STATUS_TOO_MANY_REQUESTS {
+		t.Error("Codes must not be sent again right away")
+		return
+	}
+
+	log.Info("Validation resend limit checked")
+}
+
+func TestUnvalidatedRestriction(t *testing.T) {
+
+	restricted := configuration.Params.RestrictUnvalidated
+	defer func() { configuration.Params.RestrictUnvalidated = restricted }()
+
+	configuration.Params.RestrictUnvalidated = false
+	if checkUserValidated(&models.User{}) != nil {
+		t.Error("Unvalidated users must not be restricted by default")
+		return
+	}
+
+	configuration.Params.RestrictUnvalidated = true
+	if err := checkUserValidated(&models.User{}); err == nil || err.Error != error.USER_NOT_VALIDATED {
+		t.Error("Unvalidated users must be restricted")
+		return
+	}
+
+	if checkUserValidated(&models.User{Validated: true}) != nil {
+		t.Error("Validated users must not be restricted")
+		return
+	}
+
+	log.Info("Unvalidated restriction checked")
+}
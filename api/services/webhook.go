@@ -0,0 +1,679 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/events"
+	"github.com/akrck02/valhalla-core/log"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Attempts of a delivery before it is marked as failed
+const WEBHOOK_MAX_ATTEMPTS = 6
+
+// Wait after the first failed attempt, doubled on every retry
+const WEBHOOK_BACKOFF = 30 * 1000
+
+// Longest wait between two attempts
+const WEBHOOK_MAX_BACKOFF = 60 * 60 * 1000
+
+// Failed deliveries in a row before the webhook is disabled
+const WEBHOOK_MAX_FAILURES = 5
+
+// Deliveries sent on every run of the webhook job
+const WEBHOOK_BATCH = 50
+
+// Time the receiver has to answer a delivery
+const WEBHOOK_TIMEOUT = 10 * time.Second
+
+const WEBHOOK_SECRET_LENGTH = 32
+
+const (
+	WEBHOOK_EVENT_HEADER     = "X-Valhalla-Event"
+	WEBHOOK_DELIVERY_HEADER  = "X-Valhalla-Delivery"
+	WEBHOOK_SIGNATURE_HEADER = "X-Valhalla-Signature"
+)
+
+// Events webhooks of teams and projects can subscribe to
+var WEBHOOK_TEAM_EVENTS = []string{events.EVENT_TEAM_UPDATED, events.EVENT_TEAM_MEMBER_ADDED, events.EVENT_TEAM_MEMBER_REMOVED}
+var WEBHOOK_PROJECT_EVENTS = []string{events.EVENT_TASK_CREATED, events.EVENT_TASK_UPDATED}
+
+// Payload sent to the webhooks, signed with their secret
+type webhookPayload struct {
+	Event   string      `json:"event"`
+	Team    string      `json:"team,omitempty"`
+	Project string      `json:"project,omitempty"`
+	Date    int64       `json:"date"`
+	Data    interface{} `json:"data"`
+}
+
+// Create a webhook on a team or a project
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] author | *models.User: user creating the webhook
+// [param] webhook | *models.Webhook: webhook to create
+//
+// [return] string: secret of the webhook --> *models.Error: error if any
+func CreateWebhook(conn context.Context, client *mongo.Client, author *models.User, webhook *models.Webhook) (string, *models.Error) {
+
+	allowed, targetErr := webhookEvents(webhook)
+	if targetErr != nil {
+		return "", targetErr
+	}
+
+	urlErr := validateWebhookURL(webhook.URL)
+	if urlErr != nil {
+		return "", urlErr
+	}
+
+	eventsErr := validateWebhookEvents(webhook.Events, allowed)
+	if eventsErr != nil {
+		return "", eventsErr
+	}
+
+	notCreated := &models.Error{
+		Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+		Error:   int(error.WEBHOOK_NOT_CREATED),
+		Message: "Webhook not created",
+	}
+
+	secret, err := utils.GenerateOTP(WEBHOOK_SECRET_LENGTH)
+	if err != nil {
+		return "", notCreated
+	}
+
+	webhook.Secret = secret
+	webhook.Active = true
+	webhook.Failures = 0
+	webhook.Author = author.ID
+	webhook.CreationDate = utils.GetCurrentMillis()
+	webhook.DisableDate = 0
+	webhook.ID = ""
+
+	webhooks := client.Database(db.CurrentDatabase).Collection(db.WEBHOOK)
+	result, err := webhooks.InsertOne(conn, webhook)
+
+	if err != nil {
+		return "", notCreated
+	}
+
+	webhook.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return secret, nil
+}
+
+// Get a webhook by id
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] id | string: id of the webhook
+//
+// [return] *models.Webhook: webhook found --> *models.Error: error if any
+func GetWebhook(conn context.Context, client *mongo.Client, id string) (*models.Webhook, *models.Error) {
+
+	objID, err := utils.StringToObjectId(id)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.BAD_OBJECT_ID),
+			Message: "Bad object id",
+		}
+	}
+
+	var found models.Webhook
+	webhooks := client.Database(db.CurrentDatabase).Collection(db.WEBHOOK)
+	err = webhooks.FindOne(conn, bson.M{"_id": objID}).Decode(&found)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.WEBHOOK_NOT_FOUND),
+			Message: "Webhook not found",
+		}
+	}
+
+	return &found, nil
+}
+
+// Get the webhooks of a team or a project
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] target | *models.Webhook: team or project of the webhooks
+//
+// [return] []models.Webhook: webhooks found --> *models.Error: error if any
+func GetWebhooks(conn context.Context, client *mongo.Client, target *models.Webhook) ([]models.Webhook, *models.Error) {
+
+	webhooks := client.Database(db.CurrentDatabase).Collection(db.WEBHOOK)
+	found, err := webhooks.Find(conn, webhookTargetFilter(target),
+		options.Find().SetSort(bson.M{"creation_date": 1}))
+
+	items := []models.Webhook{}
+	if err != nil || found.All(conn, &items) != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get webhooks",
+		}
+	}
+
+	return items, nil
+}
+
+// Delete a webhook and its delivery log
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] webhook | *models.Webhook: webhook to delete
+//
+// [return] *models.Error: error if any
+func DeleteWebhook(conn context.Context, client *mongo.Client, webhook *models.Webhook) *models.Error {
+
+	objID, _ := utils.StringToObjectId(webhook.ID)
+	webhooks := client.Database(db.CurrentDatabase).Collection(db.WEBHOOK)
+	result, err := webhooks.DeleteOne(conn, bson.M{"_id": objID})
+
+	if err != nil || result.DeletedCount == 0 {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.WEBHOOK_NOT_FOUND),
+			Message: "Webhook not found",
+		}
+	}
+
+	deliveries := client.Database(db.CurrentDatabase).Collection(db.WEBHOOK_DELIVERY)
+	deliveries.DeleteMany(conn, bson.M{"webhook": webhook.ID})
+	return nil
+}
+
+// Enable a webhook again, the failures are forgotten
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] webhook | *models.Webhook: webhook to enable
+//
+// [return] *models.Error: error if any
+func EnableWebhook(conn context.Context, client *mongo.Client, webhook *models.Webhook) *models.Error {
+
+	objID, _ := utils.StringToObjectId(webhook.ID)
+	webhooks := client.Database(db.CurrentDatabase).Collection(db.WEBHOOK)
+	_, err := webhooks.UpdateOne(conn, bson.M{"_id": objID}, bson.M{
+		"$set":   bson.M{"active": true, "failures": 0},
+		"$unset": bson.M{"disable_date": ""},
+	})
+
+	if err != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.WEBHOOK_NOT_UPDATED),
+			Message: "Webhook not enabled",
+		}
+	}
+
+	webhook.Active = true
+	webhook.Failures = 0
+	webhook.DisableDate = 0
+	return nil
+}
+
+// Get the delivery log of a webhook, newest first
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] webhook | *models.Webhook: webhook of the deliveries
+// [param] request | *models.PageRequest: page to get
+//
+// [return] *models.Page: page of deliveries --> *models.Error: error if any
+func GetWebhookDeliveries(conn context.Context, client *mongo.Client, webhook *models.Webhook, request *models.PageRequest) (*models.Page, *models.Error) {
+
+	cursor, cursorErr := decodeCursor(request.Cursor)
+	if cursorErr != nil {
+		return nil, cursorErr
+	}
+
+	deliveriesErr := &models.Error{
+		Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+		Error:   int(error.UNEXPECTED_ERROR),
+		Message: "Cannot get the deliveries",
+	}
+
+	filter := bson.M{"webhook": webhook.ID}
+	deliveries := client.Database(db.CurrentDatabase).Collection(db.WEBHOOK_DELIVERY)
+	total, err := deliveries.CountDocuments(conn, filter)
+
+	if err != nil {
+		return nil, deliveriesErr
+	}
+
+	if cursor != nil {
+		after, keysetErr := keysetFilter("_id", true, cursor)
+		if keysetErr != nil {
+			return nil, keysetErr
+		}
+
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	found, err := deliveries.Find(conn, filter, options.Find().
+		SetSort(keysetSort("_id", true)).
+		SetLimit(int64(request.Limit+1)))
+
+	items := []models.WebhookDelivery{}
+	if err != nil || found.All(conn, &items) != nil {
+		return nil, deliveriesErr
+	}
+
+	page := &models.Page{Total: total, Limit: request.Limit}
+
+	if len(items) > request.Limit {
+		items = items[:request.Limit]
+		page.NextCursor = encodeCursor(pageCursor{ID: items[len(items)-1].ID})
+	}
+
+	page.Items = items
+	return page, nil
+}
+
+// Get a delivery by id
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] id | string: id of the delivery
+//
+// [return] *models.WebhookDelivery: delivery found --> *models.Error: error if any
+func GetWebhookDelivery(conn context.Context, client *mongo.Client, id string) (*models.WebhookDelivery, *models.Error) {
+
+	objID, err := utils.StringToObjectId(id)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   int(error.BAD_OBJECT_ID),
+			Message: "Bad object id",
+		}
+	}
+
+	var found models.WebhookDelivery
+	deliveries := client.Database(db.CurrentDatabase).Collection(db.WEBHOOK_DELIVERY)
+	err = deliveries.FindOne(conn, bson.M{"_id": objID}).Decode(&found)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_NOT_FOUND,
+			Error:   int(error.DELIVERY_NOT_FOUND),
+			Message: "Delivery not found",
+		}
+	}
+
+	return &found, nil
+}
+
+// Send the payload of a delivery again as a new delivery,
+// the original one is kept on the log as it was
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] webhook | *models.Webhook: webhook of the delivery
+// [param] delivery | *models.WebhookDelivery: delivery to send again
+//
+// [return] *models.WebhookDelivery: new delivery --> *models.Error: error if any
+func RedeliverWebhook(conn context.Context, client *mongo.Client, webhook *models.Webhook, delivery *models.WebhookDelivery) (*models.WebhookDelivery, *models.Error) {
+
+	if !webhook.Active {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_CONFLICT,
+			Error:   int(error.WEBHOOK_DISABLED),
+			Message: "Webhook is disabled, enable it first",
+		}
+	}
+
+	now := utils.GetCurrentMillis()
+	redelivery := &models.WebhookDelivery{
+		Webhook:      webhook.ID,
+		Event:        delivery.Event,
+		Payload:      delivery.Payload,
+		Status:       models.DELIVERY_PENDING,
+		NextAttempt:  now,
+		Redelivery:   delivery.ID,
+		CreationDate: now,
+	}
+
+	deliveries := client.Database(db.CurrentDatabase).Collection(db.WEBHOOK_DELIVERY)
+	result, err := deliveries.InsertOne(conn, redelivery)
+
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.WEBHOOK_NOT_UPDATED),
+			Message: "Delivery not created",
+		}
+	}
+
+	redelivery.ID = result.InsertedID.(primitive.ObjectID).Hex()
+	return redelivery, nil
+}
+
+// Queue the deliveries of an event to the active webhooks
+// subscribed to it, failures are logged so they never fail
+// the change that raised the event
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] target | *models.Webhook: team or project of the event
+// [param] kind | string: type of the event
+// [param] data | interface{}: content of the event
+func queueWebhooks(conn context.Context, client *mongo.Client, target *models.Webhook, kind string, data interface{}) {
+
+	webhooks := client.Database(db.CurrentDatabase).Collection(db.WEBHOOK)
+	filter := webhookTargetFilter(target)
+	filter["active"] = true
+	filter["events"] = bson.M{"$in": bson.A{kind, models.WEBHOOK_ALL_EVENTS}}
+	found, err := webhooks.Find(conn, filter)
+
+	subscribed := []models.Webhook{}
+	if err != nil || found.All(conn, &subscribed) != nil {
+		log.FormattedError("Cannot get the webhooks of ${0}", kind)
+		return
+	}
+
+	if len(subscribed) == 0 {
+		return
+	}
+
+	now := utils.GetCurrentMillis()
+	payload, err := json.Marshal(webhookPayload{
+		Event:   kind,
+		Team:    target.Team,
+		Project: target.Project,
+		Date:    now,
+		Data:    data,
+	})
+
+	if err != nil {
+		log.FormattedError("Cannot encode the ${0} webhook payload: ${1}", kind, err.Error())
+		return
+	}
+
+	queued := []interface{}{}
+	for _, webhook := range subscribed {
+		queued = append(queued, models.WebhookDelivery{
+			Webhook:      webhook.ID,
+			Event:        kind,
+			Payload:      string(payload),
+			Status:       models.DELIVERY_PENDING,
+			NextAttempt:  now,
+			CreationDate: now,
+		})
+	}
+
+	deliveries := client.Database(db.CurrentDatabase).Collection(db.WEBHOOK_DELIVERY)
+	_, err = deliveries.InsertMany(conn, queued)
+
+	if err != nil {
+		log.FormattedError("Cannot queue the ${0} webhooks: ${1}", kind, err.Error())
+	}
+}
+
+// Send the deliveries whose next attempt has been reached
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+//
+// [return] *models.Error: error if any
+func DeliverWebhooks(conn context.Context, client *mongo.Client) *models.Error {
+
+	now := utils.GetCurrentMillis()
+	deliveries := client.Database(db.CurrentDatabase).Collection(db.WEBHOOK_DELIVERY)
+	found, err := deliveries.Find(conn,
+		bson.M{"status": models.DELIVERY_PENDING, "next_attempt": bson.M{"$lte": now}},
+		options.Find().SetSort(bson.M{"next_attempt": 1}).SetLimit(WEBHOOK_BATCH),
+	)
+
+	due := []models.WebhookDelivery{}
+	if err != nil || found.All(conn, &due) != nil {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_INTERNAL_SERVER_ERROR,
+			Error:   int(error.UNEXPECTED_ERROR),
+			Message: "Cannot get the pending deliveries",
+		}
+	}
+
+	webhooks := map[string]*models.Webhook{}
+	for i := range due {
+		webhook, loaded := webhooks[due[i].Webhook]
+		if !loaded {
+			webhook, _ = GetWebhook(conn, client, due[i].Webhook)
+			webhooks[due[i].Webhook] = webhook
+		}
+
+		deliverWebhook(conn, client, webhook, &due[i], now)
+	}
+
+	return nil
+}
+
+// Try to send a delivery, failures are retried after a growing
+// wait and the webhook is disabled after failing too many times
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] webhook | *models.Webhook: webhook of the delivery, nil if it was deleted
+// [param] delivery | *models.WebhookDelivery: delivery to send
+// [param] now | int64: current time in milliseconds
+func deliverWebhook(conn context.Context, client *mongo.Client, webhook *models.Webhook, delivery *models.WebhookDelivery, now int64) {
+
+	objID, _ := utils.StringToObjectId(delivery.ID)
+	deliveries := client.Database(db.CurrentDatabase).Collection(db.WEBHOOK_DELIVERY)
+
+	if webhook == nil || !webhook.Active {
+		deliveries.UpdateOne(conn, bson.M{"_id": objID}, bson.M{
+			"$set":   bson.M{"status": models.DELIVERY_FAILED, "last_error": "Webhook disabled"},
+			"$unset": bson.M{"next_attempt": ""},
+		})
+		return
+	}
+
+	code, sendErr := sendWebhook(webhook, delivery)
+	attempts := delivery.Attempts + 1
+
+	if sendErr == nil {
+		deliveries.UpdateOne(conn, bson.M{"_id": objID}, bson.M{
+			"$set":   bson.M{"status": models.DELIVERY_DELIVERED, "attempts": attempts, "response_code": code, "delivery_date": now},
+			"$unset": bson.M{"next_attempt": "", "last_error": ""},
+		})
+
+		if webhook.Failures > 0 {
+			webhookID, _ := utils.StringToObjectId(webhook.ID)
+			webhooks := client.Database(db.CurrentDatabase).Collection(db.WEBHOOK)
+			webhooks.UpdateOne(conn, bson.M{"_id": webhookID}, bson.M{"$set": bson.M{"failures": 0}})
+			webhook.Failures = 0
+		}
+
+		return
+	}
+
+	update := bson.M{
+		"attempts":      attempts,
+		"response_code": code,
+		"last_error":    sendErr.Error(),
+		"next_attempt":  now + webhookBackoff(attempts),
+	}
+
+	if attempts < WEBHOOK_MAX_ATTEMPTS {
+		deliveries.UpdateOne(conn, bson.M{"_id": objID}, bson.M{"$set": update})
+		return
+	}
+
+	update["status"] = models.DELIVERY_FAILED
+	delete(update, "next_attempt")
+	deliveries.UpdateOne(conn, bson.M{"_id": objID}, bson.M{"$set": update, "$unset": bson.M{"next_attempt": ""}})
+	recordWebhookFailure(conn, client, webhook, now)
+}
+
+// Count a failed delivery of a webhook, disabling it and
+// telling its author when it fails too many times in a row
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] webhook | *models.Webhook: webhook that failed
+// [param] now | int64: current time in milliseconds
+func recordWebhookFailure(conn context.Context, client *mongo.Client, webhook *models.Webhook, now int64) {
+
+	webhookID, _ := utils.StringToObjectId(webhook.ID)
+	webhooks := client.Database(db.CurrentDatabase).Collection(db.WEBHOOK)
+
+	var updated models.Webhook
+	err := webhooks.FindOneAndUpdate(conn,
+		bson.M{"_id": webhookID},
+		bson.M{"$inc": bson.M{"failures": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+
+	if err != nil {
+		return
+	}
+
+	webhook.Failures = updated.Failures
+	if !updated.Active || updated.Failures < WEBHOOK_MAX_FAILURES {
+		return
+	}
+
+	webhooks.UpdateOne(conn, bson.M{"_id": webhookID}, bson.M{"$set": bson.M{"active": false, "disable_date": now}})
+	webhook.Active = false
+	webhook.DisableDate = now
+
+	log.FormattedInfo("Webhook ${0} disabled after ${1} failed deliveries", webhook.ID, utils.Int2String(updated.Failures))
+	NotifyUser(conn, client, &models.Notification{
+		User:    webhook.Author,
+		Type:    models.NOTIFICATION_WEBHOOK,
+		Title:   "Your webhook was disabled",
+		Message: webhook.URL + " failed " + utils.Int2String(updated.Failures) + " deliveries in a row",
+		Source:  webhook.ID,
+	})
+}
+
+// Get the wait before the next attempt of a delivery,
+// doubled on every failed attempt up to a limit
+//
+// [param] attempts | int: failed attempts so far
+//
+// [return] int64: wait in milliseconds
+func webhookBackoff(attempts int) int64 {
+	return exponentialBackoff(attempts, WEBHOOK_BACKOFF, WEBHOOK_MAX_BACKOFF)
+}
+
+// Get the events a webhook can subscribe to, it must
+// be on a team or on a project but not on both
+//
+// [param] webhook | *models.Webhook: webhook to check
+//
+// [return] []string: events of its team or project --> *models.Error: error if the target is not valid
+func webhookEvents(webhook *models.Webhook) ([]string, *models.Error) {
+
+	switch {
+	case webhook.Team != "" && webhook.Project == "":
+		return WEBHOOK_TEAM_EVENTS, nil
+	case webhook.Project != "" && webhook.Team == "":
+		return WEBHOOK_PROJECT_EVENTS, nil
+	}
+
+	return nil, &models.Error{
+		Status:  utils.HTTP_STATUS_BAD_REQUEST,
+		Error:   int(error.INVALID_WEBHOOK_TARGET),
+		Message: "Webhooks must be on a team or on a project",
+	}
+}
+
+// Get the filter of the webhooks of a team or a project, webhooks
+// are stored without the field of the target they are not on
+//
+// [param] target | *models.Webhook: team or project of the webhooks
+//
+// [return] bson.M: filter of the webhooks
+func webhookTargetFilter(target *models.Webhook) bson.M {
+
+	if target.Team != "" {
+		return bson.M{"team": target.Team}
+	}
+
+	return bson.M{"project": target.Project}
+}
+
+// Check the url of a webhook, it must not be an internal address
+//
+// [param] address | string: url of the webhook
+//
+// [return] *models.Error: error if the url is not valid
+func validateWebhookURL(address string) *models.Error {
+
+	invalid := &models.Error{
+		Status:  utils.HTTP_STATUS_BAD_REQUEST,
+		Error:   int(error.INVALID_WEBHOOK_URL),
+		Message: "Webhook urls must be public http or https urls without credentials",
+	}
+
+	parsed, err := url.Parse(address)
+
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" || parsed.User != nil {
+		return invalid
+	}
+
+	// Names are checked again once resolved, when delivering
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	ip := net.ParseIP(host)
+
+	if ip == nil && (host == "localhost" || strings.HasSuffix(host, ".localhost")) {
+		ip = net.IPv4(127, 0, 0, 1)
+	}
+
+	if ip != nil && !webhookAddressAllowed(ip) {
+		return invalid
+	}
+
+	return nil
+}
+
+// Check the event filter of a webhook
+//
+// [param] filter | []string: events of the webhook
+// [param] allowed | []string: events of its team or project
+//
+// [return] *models.Error: error if an event is not allowed
+func validateWebhookEvents(filter []string, allowed []string) *models.Error {
+
+	invalid := &models.Error{
+		Status:  utils.HTTP_STATUS_BAD_REQUEST,
+		Error:   int(error.INVALID_WEBHOOK_EVENTS),
+		Message: "Webhook events must be * or some of " + strings.Join(allowed, ", "),
+	}
+
+	if len(filter) == 0 {
+		return invalid
+	}
+
+	known := map[string]bool{models.WEBHOOK_ALL_EVENTS: true}
+	for _, event := range allowed {
+		known[event] = true
+	}
+
+	for _, event := range filter {
+		if !known[event] {
+			return invalid
+		}
+	}
+
+	return nil
+}
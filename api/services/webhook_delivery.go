@@ -0,0 +1,134 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/akrck02/valhalla-core/configuration"
+	"github.com/akrck02/valhalla-core/models"
+)
+
+var errWebhookAddress = errors.New("Webhook address is not public")
+
+// Addresses are checked once resolved, right before connecting,
+// so a host cannot resolve to an internal address after creation
+var webhookDialer = &net.Dialer{
+	Timeout: WEBHOOK_TIMEOUT,
+	Control: func(network string, address string, conn syscall.RawConn) error {
+
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+
+		if !webhookAddressAllowed(net.ParseIP(host)) {
+			return errWebhookAddress
+		}
+
+		return nil
+	},
+}
+
+// Redirects are not followed and proxies are not used
+// so deliveries only reach the webhook url
+var webhookClient = &http.Client{
+	Timeout: WEBHOOK_TIMEOUT,
+	Transport: &http.Transport{
+		DialContext:         webhookDialer.DialContext,
+		TLSHandshakeTimeout: WEBHOOK_TIMEOUT,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Post a delivery to the url of its webhook
+//
+// [param] webhook | *models.Webhook: webhook of the delivery
+// [param] delivery | *models.WebhookDelivery: delivery to send
+//
+// [return] int: http status of the response, 0 if none --> error: error if the delivery failed
+func sendWebhook(webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+
+	request, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "Valhalla-Webhooks")
+	request.Header.Set(WEBHOOK_EVENT_HEADER, delivery.Event)
+	request.Header.Set(WEBHOOK_DELIVERY_HEADER, delivery.ID)
+	request.Header.Set(WEBHOOK_SIGNATURE_HEADER, webhookSignature(webhook.Secret, delivery.Payload))
+
+	response, err := webhookClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, &webhookStatusError{response.StatusCode}
+	}
+
+	return response.StatusCode, nil
+}
+
+// Responses of the receivers out of the 2xx range
+type webhookStatusError struct {
+	status int
+}
+
+func (e *webhookStatusError) Error() string {
+	return "Unexpected response " + strconv.Itoa(e.status) + " " + http.StatusText(e.status)
+}
+
+// Sign a payload with the secret of a webhook, receivers compute
+// the same HMAC-SHA256 of the body to check it comes from Valhalla
+//
+// [param] secret | string: secret of the webhook
+// [param] payload | string: body of the delivery
+//
+// [return] string: signature, like sha256=hex
+func webhookSignature(secret string, payload string) string {
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Get if webhooks can post to an address, loopback, private,
+// link-local, multicast and unspecified addresses are internal
+// unless the configuration allows private networks
+//
+// [param] ip | net.IP: address to check
+//
+// [return] bool: true if the address is allowed
+func webhookAddressAllowed(ip net.IP) bool {
+
+	if ip == nil {
+		return false
+	}
+
+	if configuration.Params.WebhookPrivateNetworks {
+		return true
+	}
+
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+
+	"github.com/akrck02/valhalla-core/db"
+	"github.com/akrck02/valhalla-core/error"
+	"github.com/akrck02/valhalla-core/models"
+	"github.com/akrck02/valhalla-core/utils"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Create webhook HTTP API endpoint, the secret is only
+// shown on the response
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func CreateWebhookHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var webhook *models.Webhook = &models.Webhook{}
+	err := c.ShouldBindJSON(webhook)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	accessErr := checkWebhookAccess(conn, client, request.User, webhook)
+	if accessErr != nil {
+		return nil, accessErr
+	}
+
+	secret, createErr := CreateWebhook(conn, client, request.User, webhook)
+	if createErr != nil {
+		return nil, createErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Webhook created", "id": webhook.ID, "secret": secret},
+	}, nil
+}
+
+// Get webhooks HTTP API endpoint, of a team with ?team=
+// or of a project with ?project=
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetWebhooksHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var target = &models.Webhook{Team: c.Query("team"), Project: c.Query("project")}
+
+	accessErr := checkWebhookAccess(conn, client, request.User, target)
+	if accessErr != nil {
+		return nil, accessErr
+	}
+
+	webhooks, getErr := GetWebhooks(conn, client, target)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Webhooks found", "webhooks": webhooks},
+	}, nil
+}
+
+// Delete webhook HTTP API endpoint
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func DeleteWebhookHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	webhook, getErr := getManagedWebhook(c, conn, client, request.User)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	deleteErr := DeleteWebhook(conn, client, webhook)
+	if deleteErr != nil {
+		return nil, deleteErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Webhook deleted"},
+	}, nil
+}
+
+// Enable webhook HTTP API endpoint, for webhooks
+// disabled after failing too many deliveries
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func EnableWebhookHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	webhook, getErr := getManagedWebhook(c, conn, client, request.User)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	enableErr := EnableWebhook(conn, client, webhook)
+	if enableErr != nil {
+		return nil, enableErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Webhook enabled"},
+	}, nil
+}
+
+// Get webhook deliveries HTTP API endpoint, of the webhook
+// with ?id=, paginated with ?cursor= and ?limit=
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func GetWebhookDeliveriesHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	pageRequest, err := utils.GetPageRequest(c)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_PAGE,
+			Message: "Invalid page: " + err.Error(),
+		}
+	}
+
+	webhook, getErr := GetWebhook(conn, client, c.Query("id"))
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	accessErr := checkWebhookAccess(conn, client, request.User, webhook)
+	if accessErr != nil {
+		return nil, accessErr
+	}
+
+	deliveries, deliveriesErr := GetWebhookDeliveries(conn, client, webhook, pageRequest)
+	if deliveriesErr != nil {
+		return nil, deliveriesErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Deliveries found", "page": deliveries},
+	}, nil
+}
+
+// Redeliver webhook HTTP API endpoint, sends the
+// payload of a delivery again as a new delivery
+//
+// [param] c | *gin.Context: context
+//
+// [return] *models.Response: response | *models.Error: error
+func RedeliverWebhookHttp(c *gin.Context) (*models.Response, *models.Error) {
+
+	var request = utils.GetRequestMetadata(c)
+	var client = db.CreateClient()
+	var conn = db.Connect(*client)
+	defer db.Disconnect(*client, conn)
+
+	var params *models.WebhookDelivery = &models.WebhookDelivery{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	delivery, deliveryErr := GetWebhookDelivery(conn, client, params.ID)
+	if deliveryErr != nil {
+		return nil, deliveryErr
+	}
+
+	webhook, getErr := GetWebhook(conn, client, delivery.Webhook)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	accessErr := checkWebhookAccess(conn, client, request.User, webhook)
+	if accessErr != nil {
+		return nil, accessErr
+	}
+
+	redelivery, redeliverErr := RedeliverWebhook(conn, client, webhook, delivery)
+	if redeliverErr != nil {
+		return nil, redeliverErr
+	}
+
+	return &models.Response{
+		Code:     utils.HTTP_STATUS_OK,
+		Response: gin.H{"message": "Delivery queued", "id": redelivery.ID},
+	}, nil
+}
+
+// Get the webhook of the request body if the user can manage it
+//
+// [param] c | *gin.Context: context
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user of the request
+//
+// [return] *models.Webhook: webhook found --> *models.Error: error if any
+func getManagedWebhook(c *gin.Context, conn context.Context, client *mongo.Client, user *models.User) (*models.Webhook, *models.Error) {
+
+	var params *models.Webhook = &models.Webhook{}
+	err := c.ShouldBindJSON(params)
+	if err != nil {
+		return nil, &models.Error{
+			Status:  utils.HTTP_STATUS_BAD_REQUEST,
+			Error:   error.INVALID_REQUEST,
+			Message: "Invalid request body",
+		}
+	}
+
+	webhook, getErr := GetWebhook(conn, client, params.ID)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	accessErr := checkWebhookAccess(conn, client, user, webhook)
+	if accessErr != nil {
+		return nil, accessErr
+	}
+
+	return webhook, nil
+}
+
+// Check the user manages the team or the project of a webhook
+//
+// [param] conn | context.Context: connection to the database
+// [param] client | *mongo.Client: client to the database
+// [param] user | *models.User: user of the request
+// [param] webhook | *models.Webhook: webhook to check
+//
+// [return] *models.Error: error if the user cannot manage the webhook
+func checkWebhookAccess(conn context.Context, client *mongo.Client, user *models.User, webhook *models.Webhook) *models.Error {
+
+	_, targetErr := webhookEvents(webhook)
+	if targetErr != nil {
+		return targetErr
+	}
+
+	allowed := false
+	if webhook.Team != "" {
+		team, teamErr := GetTeam(conn, client, &models.Team{ID: webhook.Team})
+		if teamErr != nil {
+			return teamErr
+		}
+
+		allowed = CanManageTeam(conn, client, user, team)
+	} else {
+		project, projectErr := getProjectById(conn, client, webhook.Project)
+		if projectErr != nil {
+			return projectErr
+		}
+
+		allowed = CanManageProject(conn, client, user, project)
+	}
+
+	if !allowed {
+		return &models.Error{
+			Status:  utils.HTTP_STATUS_FORBIDDEN,
+			Error:   error.ACCESS_DENIED,
+			Message: "Access denied: Cannot manage the webhooks",
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Blob storage on a local directory, only
+// for a single API instance or a shared volume
+type Local struct {
+	Root string
+}
+
+// Create a local blob storage
+//
+// [param] root | string: directory of the objects
+//
+// [return] *Local: the storage
+func NewLocal(root string) *Local {
+	return &Local{Root: root}
+}
+
+func (l *Local) Put(ctx context.Context, key string, content []byte, contentType string) error {
+
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	// Write aside and rename, readers never see half written objects
+	temp := path + ".tmp"
+	err = os.WriteFile(temp, content, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(temp, path)
+}
+
+func (l *Local) Get(ctx context.Context, key string) ([]byte, error) {
+
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+
+	return content, err
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// Get the path of an object, keys cannot leave the root
+//
+// [param] key | string: key of the object
+//
+// [return] string: path of the object --> error: error if the key is not valid
+func (l *Local) path(key string) (string, error) {
+
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(l.Root, clean), nil
+}
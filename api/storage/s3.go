@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Blob storage on an S3-compatible service like MinIO,
+// objects are addressed path-style as endpoint/bucket/key
+type S3 struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Client    *http.Client
+}
+
+// Create an S3-compatible blob storage
+//
+// [param] endpoint | string: url of the service, like http://minio:9000
+// [param] region | string: region of the bucket
+// [param] bucket | string: bucket of the objects
+// [param] accessKey | string: access key id
+// [param] secretKey | string: secret access key
+//
+// [return] *S3: the storage
+func NewS3(endpoint string, region string, bucket string, accessKey string, secretKey string) *S3 {
+
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3{
+		Endpoint:  strings.TrimSuffix(endpoint, "/"),
+		Region:    region,
+		Bucket:    bucket,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		Client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (s *S3) Put(ctx context.Context, key string, content []byte, contentType string) error {
+
+	response, err := s.do(ctx, http.MethodPut, key, content, contentType)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+	return s3Error(response)
+}
+
+func (s *S3) Get(ctx context.Context, key string) ([]byte, error) {
+
+	response, err := s.do(ctx, http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	err = s3Error(response)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(response.Body)
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+
+	response, err := s.do(ctx, http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	return s3Error(response)
+}
+
+// Send a signed request for an object
+//
+// [param] ctx | context.Context: context of the request
+// [param] method | string: http method
+// [param] key | string: key of the object
+// [param] content | []byte: body of the request, nil if none
+// [param] contentType | string: content type of the body
+//
+// [return] *http.Response: response --> error: error if the request could not be sent
+func (s *S3) do(ctx context.Context, method string, key string, content []byte, contentType string) (*http.Response, error) {
+
+	if key == "" || strings.Contains(key, "..") {
+		return nil, ErrInvalidKey
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, s.Endpoint+s.objectPath(key), bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	s.sign(request, content, time.Now().UTC())
+	return s.Client.Do(request)
+}
+
+// Get the escaped path of an object
+//
+// [param] key | string: key of the object
+//
+// [return] string: path of the object on the endpoint
+func (s *S3) objectPath(key string) string {
+
+	segments := strings.Split(s.Bucket+"/"+key, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(segment), "+", "%2B")
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+// Sign a request with AWS signature version 4
+//
+// [param] request | *http.Request: request to sign
+// [param] content | []byte: body of the request
+// [param] now | time.Time: time of the signature
+func (s *S3) sign(request *http.Request, content []byte, now time.Time) {
+
+	payloadHash := sha256Hex(content)
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		"host:" + request.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + s.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	signingKey := hmacSha256([]byte("AWS4"+s.SecretKey), day)
+	signingKey = hmacSha256(signingKey, s.Region)
+	signingKey = hmacSha256(signingKey, "s3")
+	signingKey = hmacSha256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// Get the error of an unsuccessful response
+//
+// [param] response | *http.Response: response to check
+//
+// [return] error: error if the status is not 2xx
+func s3Error(response *http.Response) error {
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+	return errors.New("s3 responded " + strconv.Itoa(response.StatusCode) + ": " + string(body))
+}
+
+func sha256Hex(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/akrck02/valhalla-core/configuration"
+	"github.com/akrck02/valhalla-core/log"
+)
+
+const (
+	DRIVER_LOCAL = "local"
+	DRIVER_S3    = "s3"
+)
+
+var ErrNotFound = errors.New("object not found")
+var ErrInvalidKey = errors.New("invalid object key")
+
+// Blob stores objects by key, keys are
+// relative paths like images/ab/abcd.jpg
+type Blob interface {
+	Put(ctx context.Context, key string, content []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Blob storage of the API, set up on start
+var Current Blob
+
+// Set up the blob storage configured with the STORAGE
+// environment variable, local disk unless s3 is configured
+func Setup() {
+
+	if configuration.Params.Storage == DRIVER_S3 {
+		Current = NewS3(
+			configuration.Params.S3Endpoint,
+			configuration.Params.S3Region,
+			configuration.Params.S3Bucket,
+			configuration.Params.S3AccessKey,
+			configuration.Params.S3SecretKey,
+		)
+
+		log.FormattedInfo("Storage on s3 bucket ${0} at ${1}", configuration.Params.S3Bucket, configuration.Params.S3Endpoint)
+		return
+	}
+
+	Current = NewLocal(configuration.MEDIA_PATH)
+	log.FormattedInfo("Storage on local disk at ${0}", configuration.MEDIA_PATH)
+}
+
+// Get the content-addressed key of an object, the same
+// content always has the same key so it is stored once
+//
+// [param] namespace | string: kind of object, like images or attachments
+// [param] content | []byte: content of the object
+// [param] extension | string: extension of the object, with the dot
+//
+// [return] string: key of the object
+func ContentAddress(namespace string, content []byte, extension string) string {
+
+	hash := sha256.Sum256(content)
+	hexHash := hex.EncodeToString(hash[:])
+
+	return namespace + "/" + hexHash[:2] + "/" + hexHash + extension
+}
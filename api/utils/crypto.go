@@ -1,9 +1,13 @@
 package utils
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
+	"strings"
 
 	"github.com/akrck02/valhalla-core/configuration"
 	"github.com/akrck02/valhalla-core/models"
@@ -20,7 +24,7 @@ const OTP_CHARS = "1234567890"
 // [return] string | The token --> error if something went wrong
 func GenerateAuthToken(user *models.User, device *models.Device) (string, error) {
 
-	now := getCurrentMillis()
+	now := GetCurrentMillis()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"device":    device.UserAgent + "-" + device.Address,
 		"username":  user.Username,
@@ -92,3 +96,44 @@ func GenerateOTP(length int) (string, error) {
 
 	return string(buffer), nil
 }
+
+// Sign a payload with the server secret
+//
+// [param] payload | string | The payload to sign
+//
+// [return] string | The payload and its signature, safe to use on urls
+func SignPayload(payload string) string {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(payloadSignature(encoded))
+}
+
+// Verify a payload signed with the server secret
+//
+// [param] token | string | The signed payload
+//
+// [return] string | The payload --> error if the signature is not valid
+func VerifySignedPayload(token string) (string, error) {
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return "", errors.New("malformed token")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, payloadSignature(parts[0])) {
+		return "", errors.New("invalid signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", errors.New("malformed token")
+	}
+
+	return string(payload), nil
+}
+
+func payloadSignature(encoded string) []byte {
+	mac := hmac.New(sha256.New, []byte(configuration.Params.Secret))
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
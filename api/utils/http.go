@@ -2,8 +2,10 @@ package utils
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"mime/multipart"
+	"strconv"
 
 	"github.com/akrck02/valhalla-core/models"
 	"github.com/gin-gonic/gin"
@@ -60,6 +62,34 @@ func MultipartToBytes(c *gin.Context, key string) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+const DEFAULT_PAGE_SIZE = 20
+const MAXIMUM_PAGE_SIZE = 100
+
+// GetPageRequest reads the ?cursor=, ?limit= and ?sort= query parameters
+//
+// [param] c | *gin.Context: gin context
+//
+// [return] *models.PageRequest: page request --> error: error if the limit is not valid
+func GetPageRequest(c *gin.Context) (*models.PageRequest, error) {
+
+	request := &models.PageRequest{
+		Cursor: c.Query("cursor"),
+		Limit:  DEFAULT_PAGE_SIZE,
+		Sort:   c.Query("sort"),
+	}
+
+	if c.Query("limit") != "" {
+		limit, err := strconv.Atoi(c.Query("limit"))
+		if err != nil || limit < 1 || limit > MAXIMUM_PAGE_SIZE {
+			return nil, errors.New("limit must be between 1 and " + strconv.Itoa(MAXIMUM_PAGE_SIZE))
+		}
+
+		request.Limit = limit
+	}
+
+	return request, nil
+}
+
 // GetRequestMetadata returns the request metadata
 //
 // [param] c | *gin.Context: gin context
@@ -16,6 +16,10 @@ const (
 	HTTP_STATUS_METHOD_NOT_ALLOWED         = 405
 	HTTP_STATUS_NOT_ACCEPTABLE             = 406
 	HTTP_STATUS_CONFLICT                   = 409
+	HTTP_STATUS_GONE                       = 410
+	HTTP_STATUS_REQUEST_ENTITY_TOO_LARGE   = 413
+	HTTP_STATUS_UNSUPPORTED_MEDIA_TYPE     = 415
+	HTTP_STATUS_TOO_MANY_REQUESTS          = 429
 	HTTP_STATUS_INTERNAL_SERVER_ERROR      = 500
 	HTTP_STATUS_NOT_IMPLEMENTED            = 501
 	HTTP_STATUS_BAD_GATEWAY                = 502
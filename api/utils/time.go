@@ -2,6 +2,40 @@ package utils
 
 import "time"
 
-func getCurrentMillis() int64 {
+const MILLIS_PER_DAY = int64(24 * time.Hour / time.Millisecond)
+
+// Get the current time in milliseconds
+//
+// [return] int64: current unix time in milliseconds
+func GetCurrentMillis() int64 {
 	return time.Now().UnixMilli()
 }
+
+// Get the start of the UTC day the given time belongs to
+//
+// [param] millis | int64: unix time in milliseconds
+//
+// [return] int64: unix time in milliseconds of the start of the day
+func StartOfDay(millis int64) int64 {
+	return millis - (millis % MILLIS_PER_DAY)
+}
+
+// Get the start of the UTC week (monday) the given time belongs to
+//
+// [param] millis | int64: unix time in milliseconds
+//
+// [return] int64: unix time in milliseconds of the start of the week
+func StartOfWeek(millis int64) int64 {
+	day := StartOfDay(millis)
+	weekday := int64(time.UnixMilli(day).UTC().Weekday())
+	return day - ((weekday+6)%7)*MILLIS_PER_DAY
+}
+
+// Format a time in milliseconds as a readable UTC date
+//
+// [param] millis | int64: unix time in milliseconds
+//
+// [return] string: formatted date
+func FormatMillis(millis int64) string {
+	return time.UnixMilli(millis).UTC().Format("2006-01-02 15:04 UTC")
+}